@@ -45,6 +45,22 @@ type Clone struct {
 	// /src/workspace directory.
 	// +optional
 	GitRef *string `json:"gitRef,omitempty"`
+
+	// Depth limits the number of commits that are fetched, which makes
+	// cloning repositories with a long history faster. The GitRef is
+	// fetched directly, so it must be a branch, tag or full commit hash.
+	//
+	// This field is optional. When omitted, the full history is cloned.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Depth *int32 `json:"depth,omitempty"`
+
+	// CredentialsSecretName is the name of a secret with credentials for
+	// cloning a private repository. The secret must be in the namespace of
+	// the test. It may contain an SSH key with the ssh-privatekey key, or a
+	// git credentials file with the .git-credentials key.
+	// +optional
+	CredentialsSecretName *string `json:"credentialsSecretName,omitempty"`
 }
 
 // Build defines expectations regarding which container image,
@@ -72,11 +88,25 @@ type Build struct {
 	// +optional
 	Args []string `json:"args,omitempty"`
 
+	// WorkingDir is the directory where the build command runs. A
+	// relative path is resolved against the /src/workspace directory,
+	// where the repository is cloned. When unset, the command runs in
+	// /src/workspace.
+	// +optional
+	WorkingDir *string `json:"workingDir,omitempty"`
+
 	// Env are environment variables that should be set within the build
 	// container. This is provided for compilers that alter behavior due
 	// to certain environment variables.
 	// +optional
 	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Resources are the compute resources, such as CPU, memory and
+	// ephemeral storage, requested for the build container. Builds that
+	// clone large repositories may need to request ephemeral storage to
+	// avoid eviction.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // Run defines expectations regarding the runtime environment for the
@@ -102,6 +132,21 @@ type Run struct {
 	// +optional
 	Args []string `json:"args,omitempty"`
 
+	// ArgsFrom references a key in a ConfigMap, which must be in the same
+	// namespace as the test. The value of the key is split on whitespace,
+	// including newlines, and each field becomes a command line argument.
+	// These arguments are placed before any arguments in Args, so an inline
+	// argument can override a flag that is also set in the ConfigMap.
+	// +optional
+	ArgsFrom *corev1.ConfigMapKeySelector `json:"argsFrom,omitempty"`
+
+	// WorkingDir is the directory where the run command starts. A
+	// relative path is resolved against the /src/workspace directory, so
+	// a binary compiled by the build container can be invoked relative to
+	// the checkout. When unset, the command starts in /src/workspace.
+	// +optional
+	WorkingDir *string `json:"workingDir,omitempty"`
+
 	// Env are environment variables that should be set within the
 	// running container.
 	// +optional
@@ -110,6 +155,20 @@ type Run struct {
 	// VolumeMounts permit sharing directories across containers.
 	// +optional
 	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Resources are the compute resources, such as CPU, memory and
+	// ephemeral storage, requested for the run container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Sidecars are containers that run alongside the run container, such as
+	// a proxy or a metrics scraper. Their names must be unique and must not
+	// be the name of the run container or an init container ("run", "clone",
+	// "build" or "ready"). The state of the component depends only on the run
+	// container, so sidecars do not need to exit. However, a pod with a
+	// sidecar that is still running occupies its node until it is deleted.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
 }
 
 // Driver defines a component that orchestrates the server and clients in the
@@ -135,6 +194,44 @@ type Driver struct {
 	// +optional
 	Pool *string `json:"pool,omitempty"`
 
+	// DNSConfig specifies DNS parameters of the pod of the driver, such as
+	// nameservers, search domains and the ndots option. They are merged with
+	// the parameters generated from the DNSPolicy.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// DNSPolicy sets the DNS policy of the pod of the driver. If unset, the
+	// Kubernetes default of ClusterFirst is used.
+	// +optional
+	DNSPolicy *corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// Affinity adds node and pod affinity rules to the pod of the driver, such
+	// as spreading pods across zones. The rules are merged with those that
+	// the controller sets. Requirements on node labels that conflict with the
+	// pool of the driver are removed, since the pool is always enforced.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Tolerations allow the pod of the driver to be scheduled on nodes with
+	// matching taints, such as preemptible nodes. When set, they replace the
+	// default tolerations of the controller.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Volumes are added to the pod of the driver, so they can be mounted by the
+	// volume mounts of its run container and sidecars. For example, an
+	// emptyDir volume may provide scratch space. Their names must not match
+	// the volumes that the controller adds, such as the workspace.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// NodeSelector adds node labels that the pod of the driver requires, such
+	// as a label for nodes with GPUs, to the label of its pool. The pool
+	// always takes precedence: a key of the pool label, or of the default pool
+	// label of the driver when no pool is set, is rejected.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
 	// Clone specifies the repository and snapshot where the code for the driver
 	// can be found. This is used to test alternative implementations for the
 	// driver. Most often, this will not be set. When unset, the operator will
@@ -188,6 +285,44 @@ type Server struct {
 	// +optional
 	Pool *string `json:"pool,omitempty"`
 
+	// DNSConfig specifies DNS parameters of the pod of the server, such as
+	// nameservers, search domains and the ndots option. They are merged with
+	// the parameters generated from the DNSPolicy.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// DNSPolicy sets the DNS policy of the pod of the server. If unset, the
+	// Kubernetes default of ClusterFirst is used.
+	// +optional
+	DNSPolicy *corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// Affinity adds node and pod affinity rules to the pod of the server, such
+	// as spreading pods across zones. The rules are merged with those that
+	// the controller sets. Requirements on node labels that conflict with the
+	// pool of the server are removed, since the pool is always enforced.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Tolerations allow the pod of the server to be scheduled on nodes with
+	// matching taints, such as preemptible nodes. When set, they replace the
+	// default tolerations of the controller.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Volumes are added to the pod of the server, so they can be mounted by the
+	// volume mounts of its run container and sidecars. For example, an
+	// emptyDir volume may provide scratch space. Their names must not match
+	// the volumes that the controller adds, such as the workspace.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// NodeSelector adds node labels that the pod of the server requires, such
+	// as a label for nodes with GPUs, to the label of its pool. The pool
+	// always takes precedence: a key of the pool label, or of the default pool
+	// label of the server when no pool is set, is rejected.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
 	// Clone specifies the repository and snapshot where the code for the server
 	// can be found. This field should not be set if the code has been prebuilt
 	// in the run image.
@@ -242,6 +377,44 @@ type Client struct {
 	// +optional
 	Pool *string `json:"pool,omitempty"`
 
+	// DNSConfig specifies DNS parameters of the pod of the client, such as
+	// nameservers, search domains and the ndots option. They are merged with
+	// the parameters generated from the DNSPolicy.
+	// +optional
+	DNSConfig *corev1.PodDNSConfig `json:"dnsConfig,omitempty"`
+
+	// DNSPolicy sets the DNS policy of the pod of the client. If unset, the
+	// Kubernetes default of ClusterFirst is used.
+	// +optional
+	DNSPolicy *corev1.DNSPolicy `json:"dnsPolicy,omitempty"`
+
+	// Affinity adds node and pod affinity rules to the pod of the client, such
+	// as spreading pods across zones. The rules are merged with those that
+	// the controller sets. Requirements on node labels that conflict with the
+	// pool of the client are removed, since the pool is always enforced.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Tolerations allow the pod of the client to be scheduled on nodes with
+	// matching taints, such as preemptible nodes. When set, they replace the
+	// default tolerations of the controller.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Volumes are added to the pod of the client, so they can be mounted by the
+	// volume mounts of its run container and sidecars. For example, an
+	// emptyDir volume may provide scratch space. Their names must not match
+	// the volumes that the controller adds, such as the workspace.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// NodeSelector adds node labels that the pod of the client requires, such
+	// as a label for nodes with GPUs, to the label of its pool. The pool
+	// always takes precedence: a key of the pool label, or of the default pool
+	// label of the client when no pool is set, is rejected.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
 	// Clone specifies the repository and snapshot where the code for the client
 	// can be found. This field should not be set if the code has been prebuilt
 	// in the run image.
@@ -273,10 +446,102 @@ type Client struct {
 // Results defines where and how test results and artifacts should be
 // stored.
 type Results struct {
+	// Audience is the audience of a service account token that is
+	// mounted in the driver, so it can upload results with workload
+	// identity federation. It must be a URL with a host, such as
+	// "//iam.googleapis.com/projects/...". If omitted, no token is mounted.
+	// +optional
+	Audience *string `json:"audience,omitempty"`
+
 	// BigQueryTable names a dataset where the results of the test
 	// should be stored. If omitted, no results are saved to BigQuery.
 	// +optional
 	BigQueryTable *string `json:"bigQueryTable,omitempty"`
+
+	// GCSBucket names a Cloud Storage bucket where the driver uploads the
+	// raw result artifacts of the test. If omitted, no artifacts are
+	// uploaded.
+	// +optional
+	GCSBucket *string `json:"gcsBucket,omitempty"`
+
+	// GCSPrefix is prepended to the names of the objects that the driver
+	// uploads to GCSBucket, such as "results/master/". It requires
+	// GCSBucket to be set.
+	// +optional
+	GCSPrefix *string `json:"gcsPrefix,omitempty"`
+
+	// PartitionField names a TIMESTAMP or DATE column of the BigQuery
+	// table that partitions it. If omitted while PartitionType is set, the
+	// table is partitioned by the time the results are written. It
+	// requires BigQueryTable to be set.
+	// +optional
+	PartitionField *string `json:"partitionField,omitempty"`
+
+	// PartitionType is the granularity of the partitions of the BigQuery
+	// table. It defaults to DAY when PartitionField is set, and it
+	// requires BigQueryTable to be set.
+	// +kubebuilder:validation:Enum=HOUR;DAY;MONTH;YEAR
+	// +optional
+	PartitionType PartitionType `json:"partitionType,omitempty"`
+}
+
+// PartitionType names the granularity of the time partitions of a BigQuery
+// table.
+type PartitionType string
+
+const (
+	// HourPartition partitions a table by hour.
+	HourPartition PartitionType = "HOUR"
+
+	// DayPartition partitions a table by day.
+	DayPartition PartitionType = "DAY"
+
+	// MonthPartition partitions a table by month.
+	MonthPartition PartitionType = "MONTH"
+
+	// YearPartition partitions a table by year.
+	YearPartition PartitionType = "YEAR"
+)
+
+// ParameterTimeout sets the timeout of the tests expanded from a parameter
+// matrix that have a parameter set to a value.
+type ParameterTimeout struct {
+	// Parameter is the name of a parameter in the ParameterMatrix.
+	Parameter string `json:"parameter"`
+
+	// Value is one of the values of the parameter.
+	Value string `json:"value"`
+
+	// TimeoutSeconds is the timeout of the tests that have the parameter
+	// set to the value, in place of the TimeoutSeconds of the test.
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int32 `json:"timeoutSeconds"`
+}
+
+// ColocationTopology names the topology domain where the clients and servers
+// of a test should be placed together.
+type ColocationTopology string
+
+const (
+	// NodeColocation places the clients of a test on the same node as its
+	// servers.
+	NodeColocation ColocationTopology = "Node"
+
+	// ZoneColocation places the clients of a test in the same zone as its
+	// servers.
+	ZoneColocation ColocationTopology = "Zone"
+)
+
+// Affinity defines hints for the placement of the pods of a test, relative
+// to each other.
+type Affinity struct {
+	// Colocation requests that the clients of the test are placed in the
+	// same topology domain as its servers, so a test can measure best-case
+	// latency. The pool of each component still applies, so clients and
+	// servers must share a pool to be placed on the same node.
+	// +kubebuilder:validation:Enum=Node;Zone
+	// +optional
+	Colocation ColocationTopology `json:"colocation,omitempty"`
 }
 
 // LoadTestSpec defines the desired state of LoadTest
@@ -295,12 +560,62 @@ type LoadTestSpec struct {
 	// +optional
 	Clients []Client `json:"clients,omitempty"`
 
+	// Affinity configures the placement of the pods of the test relative
+	// to each other.
+	// +optional
+	Affinity *Affinity `json:"affinity,omitempty"`
+
+	// ImagePullSecrets are the names of secrets in the namespace of the
+	// test that are used to pull the images of its pods. When omitted, the
+	// secrets in the defaults of the controller are used.
+	// +optional
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
 	// Results configures where the results of the test should be
 	// stored. When omitted, the results will only be stored in
 	// Kubernetes for a limited time.
 	// +optional
 	Results *Results `json:"results,omitempty"`
 
+	// ParameterMatrix maps the names of parameters to lists of values. When
+	// set, the test runner expands the test into one test for each
+	// combination of values, rendering ScenariosJSON as a Go template with
+	// the values of the combination (e.g. {{.messageSize}}). The controller
+	// does not use this field.
+	// +optional
+	ParameterMatrix map[string][]string `json:"parameterMatrix,omitempty"`
+
+	// ParameterTimeouts set the timeouts of the tests that the test runner
+	// expands from the ParameterMatrix, based on their parameter values.
+	// Each expanded test takes the longest timeout of the entries that match
+	// its values, or TimeoutSeconds if no entry matches. The controller does
+	// not use this field.
+	// +optional
+	ParameterTimeouts []ParameterTimeout `json:"parameterTimeouts,omitempty"`
+
+	// MaxRetries is the number of times the controller restarts the test
+	// when a server or client fails before the driver terminates. Each
+	// restart deletes the pods of the test and creates them again. When
+	// the retries are exhausted, the test is marked as errored. Failures
+	// of the driver are not retried. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRetries int32 `json:"maxRetries,omitempty"`
+
+	// Priority orders tests that wait for nodes in the same pools. When
+	// there are not enough nodes for all of them, tests with a higher
+	// priority are scheduled first. Tests with the same priority are
+	// scheduled in order of creation. Defaults to 0.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Scenarios is a list of strings, each with the contents of a Scenarios
+	// message formatted as JSON. The scenarios of all entries are combined
+	// into one Scenarios message, after any scenarios in ScenariosJSON, and
+	// run by the driver in order.
+	// +optional
+	Scenarios []string `json:"scenarios,omitempty"`
+
 	// ScenariosJSON is string with the contents of a Scenarios message,
 	// formatted as JSON. See the Scenarios protobuf definition for details:
 	// https://github.com/grpc/grpc-proto/blob/master/grpc/testing/control.proto.
@@ -338,6 +653,12 @@ const (
 	// completed successfully. The run container has started.
 	Running LoadTestState = "Running"
 
+	// Stopping states indicate that some of the load test's pods have
+	// terminated successfully, but the driver has not yet terminated. This
+	// state is transient. It resolves to a Succeeded or Errored state when
+	// the driver terminates.
+	Stopping LoadTestState = "Stopping"
+
 	// Succeeded states indicate the driver pod's run container has terminated
 	// successfully, signaled by a zero exit code.
 	Succeeded LoadTestState = "Succeeded"
@@ -345,12 +666,22 @@ const (
 	// Errored states indicate the load test encountered a problem that prevented
 	// a successful run.
 	Errored LoadTestState = "Errored"
+
+	// TimedOut states indicate the load test did not terminate before its
+	// timeout. This distinguishes a test that ran out of time from one that
+	// failed, which is Errored.
+	TimedOut LoadTestState = "TimedOut"
+
+	// Cancelled states indicate the load test was stopped by an operator
+	// before it terminated on its own. Its pods are deleted, but the test is
+	// kept, so its status remains available.
+	Cancelled LoadTestState = "Cancelled"
 )
 
-// IsTerminated returns true if the test has finished due to a success, failure
-// or error. Otherwise, it returns false.
+// IsTerminated returns true if the test has finished due to a success, failure,
+// error, timeout or cancellation. Otherwise, it returns false.
 func (lts LoadTestState) IsTerminated() bool {
-	return lts == Succeeded || lts == Errored
+	return lts == Succeeded || lts == Errored || lts == TimedOut || lts == Cancelled
 }
 
 // InitContainerError is the reason string when an init container has failed on
@@ -368,22 +699,83 @@ var FailedSettingDefaultsError = "FailedSettingDefaults"
 // ConfigurationError is the reason string when a LoadTest spec is invalid.
 var ConfigurationError = "ConfigurationError"
 
+// PodEvicted is the reason string when one of the load test's pods was evicted
+// from its node.
+var PodEvicted = "PodEvicted"
+
+// Preempted is the reason string when one of the load test's pods was
+// terminated because its node was preempted or shut down. This is a failure
+// of the infrastructure, rather than of the benchmark.
+var Preempted = "Preempted"
+
+// EphemeralStorageEvicted is the reason string when one of the load test's pods
+// was evicted because it used too much ephemeral storage, or its node ran out
+// of ephemeral storage. Requesting ephemeral storage in the Resources of the
+// build or run container may prevent this.
+var EphemeralStorageEvicted = "EphemeralStorageEvicted"
+
 // PodsMissing is the reason string when the load test is missing pods and is still
 // in the Initializing state.
 var PodsMissing = "PodsMissing"
 
+// AwaitingSchedule is the reason string when the load test has created all of
+// its pods, but some of them have not been scheduled onto a node. This
+// usually means that the pool does not have enough free nodes.
+var AwaitingSchedule = "AwaitingSchedule"
+
+// PullingImage is the reason string when the load test has created all of its
+// pods and they are scheduled, but some of them are waiting for their images
+// to be pulled.
+var PullingImage = "PullingImage"
+
+// PodsTerminated is the reason string when some of the load test's pods have
+// terminated and the load test is in the Stopping state.
+var PodsTerminated = "PodsTerminated"
+
+// WorkersSucceeded is the reason string when a load test without a driver
+// succeeded, because all of its servers and clients exited successfully.
+var WorkersSucceeded = "WorkersSucceeded"
+
 // PoolError is the reason string when a driver, client or server requires nodes
 // from a nonexistent pool.
 var PoolError = "PoolError"
 
 // TimeoutErrored is the reason string when the load test has not yet terminated
-// but exceeded the timeout.
+// but exceeded the timeout. It was used with the Errored state before the
+// TimedOut state was introduced, and may still appear on older tests.
 var TimeoutErrored = "TimeoutErrored"
 
+// TimeoutExceeded is the reason string when the load test has not yet
+// terminated but exceeded the timeout, and is in the TimedOut state.
+var TimeoutExceeded = "TimeoutExceeded"
+
+// WorkerRetried is the reason string when a server or client of the load test
+// failed, and the controller is restarting the test because it has retries
+// left.
+var WorkerRetried = "WorkerRetried"
+
+// CancelRequested is the reason string when the load test was cancelled,
+// because the cancel annotation was set on it.
+var CancelRequested = "CancelRequested"
+
 // KubernetesError is the reason string when an issue occurs with Kubernetes
 // that is not known to be directly related to a load test.
 var KubernetesError = "KubernetesError"
 
+// LoadTestCondition records that a load test entered a state.
+type LoadTestCondition struct {
+	// State is the state that the load test entered.
+	State LoadTestState `json:"state"`
+
+	// Reason is a camel-case string that indicates the reasoning behind the
+	// transition to the state.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// LastTransitionTime is the time when the load test entered the state.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
 // LoadTestStatus defines the observed state of LoadTest
 type LoadTestStatus struct {
 	// State identifies the current state of the load test. It is
@@ -410,6 +802,51 @@ type LoadTestStatus struct {
 	// Failed or Errored states.
 	// +optional
 	StopTime *metav1.Time `json:"stopTime,omitempty"`
+
+	// FailedDriver is the number of driver pods that have errored. It is
+	// either 0 or 1.
+	// +optional
+	FailedDriver int32 `json:"failedDriver,omitempty"`
+
+	// FailedServers is the number of server pods that have errored.
+	// +optional
+	FailedServers int32 `json:"failedServers,omitempty"`
+
+	// FailedClients is the number of client pods that have errored.
+	// +optional
+	FailedClients int32 `json:"failedClients,omitempty"`
+
+	// Pods is the number of pods that the controller has created for the
+	// load test, out of the pods it requires.
+	// +optional
+	Pods int32 `json:"pods,omitempty"`
+
+	// Retries is the number of times the controller has restarted the load
+	// test after a failure of a server or client.
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
+
+	// QPS is the number of queries per second that the load test achieved.
+	// It is set from the results that the driver reports in the termination
+	// message of its run container, when the driver succeeds.
+	// +optional
+	QPS *float64 `json:"qps,omitempty"`
+
+	// LatencyP50Ms is the median latency of the load test in milliseconds,
+	// as reported by the driver.
+	// +optional
+	LatencyP50Ms *float64 `json:"latencyP50Ms,omitempty"`
+
+	// LatencyP99Ms is the 99th percentile latency of the load test in
+	// milliseconds, as reported by the driver.
+	// +optional
+	LatencyP99Ms *float64 `json:"latencyP99Ms,omitempty"`
+
+	// Conditions is the history of the states of the load test, in the
+	// order they were entered. A condition is only added when the state
+	// changes, so consecutive conditions never share a state.
+	// +optional
+	Conditions []LoadTestCondition `json:"conditions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,153 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// loadtestlog is for logging in this package.
+var loadtestlog = logf.Log.WithName("loadtest-resource")
+
+// LoadTestDefaulter sets the defaults of the controller on a LoadTest. This
+// cannot be done in this package, since the defaults depend on it. It returns
+// an error if the test has no viable defaults.
+type LoadTestDefaulter func(test *LoadTest) error
+
+// LoadTestValidator checks a LoadTest against the configuration of the
+// controller, such as its defaults. This cannot be done in this package,
+// since the configuration depends on it. The message of the error should
+// start with the reason that the controller would set on the test.
+type LoadTestValidator func(test *LoadTest) error
+
+// defaultWithController is the LoadTestDefaulter that was set up with the
+// webhook. It is nil if the webhook has not been set up.
+var defaultWithController LoadTestDefaulter
+
+// validateWithController is the LoadTestValidator that was set up with the
+// webhook. It is nil if the webhook has not been set up.
+var validateWithController LoadTestValidator
+
+// SetupWebhookWithManager registers the mutating and validating admission
+// webhooks for LoadTests with a manager. The defaulter is applied to each test
+// that is created or updated, so the stored test is complete. The validator
+// runs after the checks that only need the LoadTest. Either may be nil.
+func (r *LoadTest) SetupWebhookWithManager(mgr ctrl.Manager, defaulter LoadTestDefaulter, validator LoadTestValidator) error {
+	defaultWithController = defaulter
+	validateWithController = validator
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-e2etest-grpc-io-v1-loadtest,mutating=true,failurePolicy=fail,groups=e2etest.grpc.io,resources=loadtests,verbs=create;update,versions=v1,name=mloadtest.kb.io
+
+var _ webhook.Defaulter = &LoadTest{}
+
+// Default sets the defaults of the controller on the spec of a LoadTest. The
+// defaults only fill fields that are unset, so applying them to a test that
+// was already defaulted does not change it. If the test has no viable
+// defaults, it is left unchanged for the validating webhook to reject. The
+// controller applies the defaults to its own copy of tests that were stored
+// before the webhook was enabled, without updating them.
+func (r *LoadTest) Default() {
+	if defaultWithController == nil {
+		return
+	}
+	loadtestlog.Info("default", "name", r.Name)
+
+	test := r.DeepCopy()
+	if err := defaultWithController(test); err != nil {
+		loadtestlog.Info("could not set defaults", "name", r.Name, "error", err.Error())
+		return
+	}
+	test.Spec.DeepCopyInto(&r.Spec)
+}
+
+// +kubebuilder:webhook:verbs=create,path=/validate-e2etest-grpc-io-v1-loadtest,mutating=false,failurePolicy=fail,groups=e2etest.grpc.io,resources=loadtests,versions=v1,name=vloadtest.kb.io
+
+var _ webhook.Validator = &LoadTest{}
+
+// ValidateCreate rejects LoadTests that the controller could never run, so
+// they fail when they are created instead of being left in the Errored
+// state. Each message starts with the reason that the controller would have
+// set on the test.
+func (r *LoadTest) ValidateCreate() error {
+	loadtestlog.Info("validate create", "name", r.Name)
+
+	allErrs := r.validateSpec()
+	if len(allErrs) == 0 && validateWithController != nil {
+		if err := validateWithController(r); err != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), err.Error()))
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("LoadTest").GroupKind(), r.Name, allErrs)
+}
+
+// ValidateUpdate allows all updates. The controller updates tests to set
+// their defaults, and tests that were created before the webhook must remain
+// possible to update.
+func (r *LoadTest) ValidateUpdate(old runtime.Object) error {
+	return nil
+}
+
+// ValidateDelete allows all deletions.
+func (r *LoadTest) ValidateDelete() error {
+	return nil
+}
+
+// validateSpec returns the problems with the spec that can be found without
+// the configuration of the controller.
+func (r *LoadTest) validateSpec() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if len(r.Spec.Servers) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("servers"), fmt.Sprintf("%s: a test requires at least one server", ConfigurationError)))
+	}
+	if len(r.Spec.Clients) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("clients"), fmt.Sprintf("%s: a test requires at least one client", ConfigurationError)))
+	}
+
+	// Clients that are colocated on the node of a server can only be
+	// scheduled if they are in the same pool, since each node belongs to a
+	// single pool.
+	if r.Spec.Affinity != nil && r.Spec.Affinity.Colocation == NodeColocation {
+		for i, client := range r.Spec.Clients {
+			for _, server := range r.Spec.Servers {
+				if client.Pool == nil || server.Pool == nil || *client.Pool == *server.Pool {
+					continue
+				}
+				allErrs = append(allErrs, field.Invalid(specPath.Child("clients").Index(i).Child("pool"), *client.Pool,
+					fmt.Sprintf("%s: clients colocated on the node of a server must be in the pool of the server %q", ConfigurationError, *server.Pool)))
+				break
+			}
+		}
+	}
+
+	return allErrs
+}
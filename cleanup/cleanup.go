@@ -115,7 +115,7 @@ func quitWorkers(ctx context.Context, q callQuitter, ownedPods []*corev1.Pod, lo
 			continue
 		}
 
-		padStatus, _, _ := status.StateForPodStatus(&ownedPods[i].Status)
+		padStatus, _, _ := status.StateForPod(ownedPods[i])
 		if padStatus == status.Pending {
 			q.callQuit(ctx, ownedPods[i], log)
 		}
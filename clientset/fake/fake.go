@@ -0,0 +1,471 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides an in-memory implementation of the LoadTestGetter
+// interface, for use in the tests of tools that manage load tests.
+//
+// The fake stores tests in a map instead of a cluster. Since no controller
+// runs, the status of a test does not change on its own. Instead, tests can
+// script the statuses that a load test reports with SetStatuses:
+//
+//	getter := fake.NewLoadTestGetter("default")
+//	getter.SetStatuses("my-test",
+//		grpcv1.LoadTestStatus{State: grpcv1.Running},
+//		grpcv1.LoadTestStatus{State: grpcv1.Succeeded},
+//	)
+//
+// Each Get of the test then advances it to the next status, remaining at
+// the last one. Tests without scripted statuses keep the status set with
+// SetCreatedStatus when they were created.
+//
+// Failures of the cluster can be simulated with SetError, and the calls that
+// were made are recorded, so tests can check them with Actions.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	clientset "github.com/grpc/test-infra/clientset"
+)
+
+// loadTestResource identifies load tests in errors returned by the fake.
+var loadTestResource = grpcv1.GroupVersion.WithResource("loadtests").GroupResource()
+
+// Action records a call to a LoadTestGetter.
+type Action struct {
+	// Verb is the kind of call: "create", "get", "list", "delete", "watch"
+	// or "patch".
+	Verb string
+
+	// Name is the name of the test, or empty for calls that are not made
+	// for a single test.
+	Name string
+
+	// Time is when the call was made.
+	Time time.Time
+
+	// Err is the error returned by the call, if any.
+	Err error
+}
+
+// LoadTestGetter is an in-memory clientset.LoadTestGetter for a single
+// namespace. It is safe for concurrent use.
+type LoadTestGetter struct {
+	mux             sync.Mutex
+	namespace       string
+	tests           map[string]*grpcv1.LoadTest
+	statuses        map[string][]grpcv1.LoadTestStatus
+	createdStatus   grpcv1.LoadTestStatus
+	errs            map[string]error
+	actions         []Action
+	watchers        []*watcher
+	resourceVersion int
+}
+
+// watcher is a watch with the selectors it was created with.
+type watcher struct {
+	*watch.RaceFreeFakeWatcher
+	selector selector
+}
+
+var _ clientset.LoadTestGetter = &LoadTestGetter{}
+
+// NewLoadTestGetter creates a fake LoadTestGetter for a namespace. Any tests
+// that are passed are stored as if they had been created.
+func NewLoadTestGetter(namespace string, tests ...*grpcv1.LoadTest) *LoadTestGetter {
+	g := &LoadTestGetter{
+		namespace: namespace,
+		tests:     make(map[string]*grpcv1.LoadTest),
+		statuses:  make(map[string][]grpcv1.LoadTestStatus),
+		errs:      make(map[string]error),
+	}
+	for _, test := range tests {
+		g.store(test.DeepCopy())
+	}
+	return g
+}
+
+// SetStatuses scripts the statuses of a test. Each call to Get for the test
+// sets the next status, and the test keeps the last status once all have been
+// used. The statuses replace any that were scripted before, and they may be
+// set before the test is created. A Modified event is sent to watchers each
+// time a status is set.
+func (g *LoadTestGetter) SetStatuses(name string, statuses ...grpcv1.LoadTestStatus) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	g.statuses[name] = append([]grpcv1.LoadTestStatus(nil), statuses...)
+}
+
+// SetCreatedStatus sets the status of tests when they are created, as if the
+// controller had reconciled them. It does not change tests that are already
+// stored, and scripted statuses replace it on each Get.
+func (g *LoadTestGetter) SetCreatedStatus(status grpcv1.LoadTestStatus) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	g.createdStatus = status
+}
+
+// SetError makes calls with a verb, such as "create" or "list", return an
+// error without reading or changing the stored tests. Setting a nil error
+// makes the calls succeed again.
+func (g *LoadTestGetter) SetError(verb string, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	if err == nil {
+		delete(g.errs, verb)
+		return
+	}
+	g.errs[verb] = err
+}
+
+// Actions returns the calls that were made, in order.
+func (g *LoadTestGetter) Actions() []Action {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	return append([]Action(nil), g.actions...)
+}
+
+// Create stores a copy of a test, setting its namespace, UID, resource
+// version, creation timestamp and the status set with SetCreatedStatus. It
+// returns an AlreadyExists error if a test with the same name is stored.
+func (g *LoadTestGetter) Create(test *grpcv1.LoadTest, opts metav1.CreateOptions) (_ *grpcv1.LoadTest, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	defer g.record("create", test.Name, &err)
+
+	if err = g.errs["create"]; err != nil {
+		return nil, err
+	}
+	if test.Namespace != "" && test.Namespace != g.namespace {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("namespace %q does not match the namespace %q of the getter", test.Namespace, g.namespace))
+	}
+	if test.Name == "" {
+		return nil, kerrors.NewBadRequest("test has no name")
+	}
+	if _, ok := g.tests[test.Name]; ok {
+		return nil, kerrors.NewAlreadyExists(loadTestResource, test.Name)
+	}
+
+	created := test.DeepCopy()
+	g.createdStatus.DeepCopyInto(&created.Status)
+	g.store(created)
+	g.notify(watch.Added, created)
+	return created.DeepCopy(), nil
+}
+
+// Get returns a copy of a stored test. If statuses were scripted for the test,
+// it first advances the test to the next status. It returns a NotFound error
+// if no test with the name is stored.
+func (g *LoadTestGetter) Get(name string, opts metav1.GetOptions) (_ *grpcv1.LoadTest, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	defer g.record("get", name, &err)
+
+	if err = g.errs["get"]; err != nil {
+		return nil, err
+	}
+	test, ok := g.tests[name]
+	if !ok {
+		return nil, kerrors.NewNotFound(loadTestResource, name)
+	}
+
+	if statuses := g.statuses[name]; len(statuses) > 0 {
+		test.Status = statuses[0]
+		g.statuses[name] = statuses[1:]
+		g.bumpResourceVersion(test)
+		g.notify(watch.Modified, test)
+	}
+
+	return test.DeepCopy(), nil
+}
+
+// List returns copies of the stored tests that match the label and field
+// selectors in the options, sorted by name. If a limit is set, at most that
+// many tests are returned.
+func (g *LoadTestGetter) List(opts metav1.ListOptions) (_ *grpcv1.LoadTestList, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	defer g.record("list", "", &err)
+
+	if err = g.errs["list"]; err != nil {
+		return nil, err
+	}
+	sel, err := newSelector(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(g.tests))
+	for name := range g.tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	list := &grpcv1.LoadTestList{}
+	list.ResourceVersion = fmt.Sprint(g.resourceVersion)
+	for _, name := range names {
+		if opts.Limit > 0 && int64(len(list.Items)) >= opts.Limit {
+			break
+		}
+		test := g.tests[name]
+		if sel.matches(test) {
+			list.Items = append(list.Items, *test.DeepCopy())
+		}
+	}
+	return list, nil
+}
+
+// Delete removes a stored test and any statuses scripted for it. It returns a
+// NotFound error if no test with the name is stored.
+func (g *LoadTestGetter) Delete(name string, opts metav1.DeleteOptions) (err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	defer g.record("delete", name, &err)
+
+	if err = g.errs["delete"]; err != nil {
+		return err
+	}
+	test, ok := g.tests[name]
+	if !ok {
+		return kerrors.NewNotFound(loadTestResource, name)
+	}
+	delete(g.tests, name)
+	delete(g.statuses, name)
+	g.notify(watch.Deleted, test)
+	return nil
+}
+
+// Watch returns a watcher that receives an event each time a test that
+// matches the label and field selectors in the options is created, modified
+// or deleted after the call. The watcher buffers watch.DefaultChanSize events.
+// Once that many events are unread, further events are dropped, so tests that
+// do not consume events may miss some but never block or panic.
+func (g *LoadTestGetter) Watch(opts metav1.ListOptions) (_ watch.Interface, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	defer g.record("watch", "", &err)
+
+	if err = g.errs["watch"]; err != nil {
+		return nil, err
+	}
+	sel, err := newSelector(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{
+		RaceFreeFakeWatcher: watch.NewRaceFreeFake(),
+		selector:            sel,
+	}
+	g.watchers = append(g.watchers, w)
+	return w, nil
+}
+
+// Patch applies a JSON merge patch to a stored test and returns a copy of
+// the result. Patches to the "status" subresource only change the status.
+// Other patch types and subresources are not supported.
+func (g *LoadTestGetter) Patch(name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (_ *grpcv1.LoadTest, err error) {
+	g.mux.Lock()
+	defer g.mux.Unlock()
+	defer g.record("patch", name, &err)
+
+	if err = g.errs["patch"]; err != nil {
+		return nil, err
+	}
+	if pt != types.MergePatchType {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("unsupported patch type %q", pt))
+	}
+	statusOnly := false
+	switch {
+	case len(subresources) == 0:
+	case len(subresources) == 1 && subresources[0] == "status":
+		statusOnly = true
+	default:
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("unsupported subresources %v", subresources))
+	}
+
+	test, ok := g.tests[name]
+	if !ok {
+		return nil, kerrors.NewNotFound(loadTestResource, name)
+	}
+
+	patched, err := mergePatch(test, data)
+	if err != nil {
+		return nil, kerrors.NewBadRequest(fmt.Sprintf("could not apply patch: %v", err))
+	}
+	if statusOnly {
+		status := patched.Status
+		patched = test.DeepCopy()
+		patched.Status = status
+	}
+	patched.Name = test.Name
+	patched.Namespace = test.Namespace
+	patched.UID = test.UID
+	patched.CreationTimestamp = test.CreationTimestamp
+
+	g.tests[name] = patched
+	g.bumpResourceVersion(patched)
+	g.notify(watch.Modified, patched)
+	return patched.DeepCopy(), nil
+}
+
+// record appends a call to the actions, with the error that the call
+// returns. It is deferred by each call, so the error is read when the call
+// returns. It must be called with the mutex held.
+func (g *LoadTestGetter) record(verb, name string, err *error) {
+	g.actions = append(g.actions, Action{
+		Verb: verb,
+		Name: name,
+		Time: time.Now(),
+		Err:  *err,
+	})
+}
+
+// store saves a test, filling in the fields that the API server would set.
+// It must be called with the mutex held.
+func (g *LoadTestGetter) store(test *grpcv1.LoadTest) {
+	test.Namespace = g.namespace
+	if test.UID == "" {
+		test.UID = types.UID(fmt.Sprintf("%s-%s", g.namespace, test.Name))
+	}
+	if test.CreationTimestamp.IsZero() {
+		test.CreationTimestamp = metav1.Now()
+	}
+	g.bumpResourceVersion(test)
+	g.tests[test.Name] = test
+}
+
+// bumpResourceVersion sets a new resource version on a test. It must be
+// called with the mutex held.
+func (g *LoadTestGetter) bumpResourceVersion(test *grpcv1.LoadTest) {
+	g.resourceVersion++
+	test.ResourceVersion = fmt.Sprint(g.resourceVersion)
+}
+
+// notify sends an event with a copy of a test to all watchers that have not
+// been stopped and whose selectors match the test. Events for watchers whose
+// buffer is full are dropped. It must be called with the mutex held.
+func (g *LoadTestGetter) notify(eventType watch.EventType, test *grpcv1.LoadTest) {
+	active := g.watchers[:0]
+	for _, w := range g.watchers {
+		if w.IsStopped() {
+			continue
+		}
+		active = append(active, w)
+		if !w.selector.matches(test) {
+			continue
+		}
+		// Events are only sent with the mutex held, so the buffer cannot
+		// fill between this check and the send.
+		if len(w.ResultChan()) >= int(watch.DefaultChanSize) {
+			continue
+		}
+		w.Action(eventType, test.DeepCopy())
+	}
+	g.watchers = active
+}
+
+// selector matches tests against the label and field selectors of list
+// options. The fields metadata.name and metadata.namespace are supported.
+type selector struct {
+	labels labels.Selector
+	fields fields.Selector
+}
+
+// newSelector parses the selectors in list options. It returns a BadRequest
+// error if either selector is not valid.
+func newSelector(opts metav1.ListOptions) (selector, error) {
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return selector{}, kerrors.NewBadRequest(fmt.Sprintf("invalid label selector: %v", err))
+	}
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return selector{}, kerrors.NewBadRequest(fmt.Sprintf("invalid field selector: %v", err))
+	}
+	return selector{labels: labelSelector, fields: fieldSelector}, nil
+}
+
+// matches returns true if a test matches both selectors.
+func (s selector) matches(test *grpcv1.LoadTest) bool {
+	return s.labels.Matches(labels.Set(test.Labels)) && s.fields.Matches(fields.Set{
+		"metadata.name":      test.Name,
+		"metadata.namespace": test.Namespace,
+	})
+}
+
+// mergePatch applies a JSON merge patch, as described in RFC 7386, to a copy
+// of a test.
+func mergePatch(test *grpcv1.LoadTest, data []byte) (*grpcv1.LoadTest, error) {
+	original, err := json.Marshal(test)
+	if err != nil {
+		return nil, err
+	}
+
+	var target, patch interface{}
+	if err := json.Unmarshal(original, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, err
+	}
+
+	merged, err := json.Marshal(mergeJSON(target, patch))
+	if err != nil {
+		return nil, err
+	}
+
+	patched := new(grpcv1.LoadTest)
+	if err := json.Unmarshal(merged, patched); err != nil {
+		return nil, err
+	}
+	return patched, nil
+}
+
+// mergeJSON merges a decoded JSON patch into a decoded JSON target. Objects
+// are merged recursively, null values remove keys and any other value
+// replaces the target.
+func mergeJSON(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeJSON(targetObject[key], value)
+	}
+	return targetObject
+}
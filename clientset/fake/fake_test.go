@@ -0,0 +1,277 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("LoadTestGetter", func() {
+	var getter *LoadTestGetter
+
+	newTest := func(name string, labels map[string]string) *grpcv1.LoadTest {
+		return &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		getter = NewLoadTestGetter("tests")
+	})
+
+	It("creates and gets a test", func() {
+		created, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Namespace).To(Equal("tests"))
+		Expect(created.UID).ToNot(BeEmpty())
+		Expect(created.CreationTimestamp.IsZero()).To(BeFalse())
+
+		fetched, err := getter.Get("a", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched).To(Equal(created))
+	})
+
+	It("returns copies of stored tests", func() {
+		_, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		fetched, err := getter.Get("a", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		fetched.Spec.TimeoutSeconds = 100
+
+		fetched, err = getter.Get("a", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fetched.Spec.TimeoutSeconds).To(BeZero())
+	})
+
+	It("returns an error when creating a test that exists", func() {
+		_, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(kerrors.IsAlreadyExists(err)).To(BeTrue())
+	})
+
+	It("returns an error when getting a test that does not exist", func() {
+		_, err := getter.Get("missing", metav1.GetOptions{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("deletes a test", func() {
+		_, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(getter.Delete("a", metav1.DeleteOptions{})).To(Succeed())
+		_, err = getter.Get("a", metav1.GetOptions{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+
+		err = getter.Delete("a", metav1.DeleteOptions{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("lists tests by name, filtered by labels and limited", func() {
+		getter = NewLoadTestGetter("tests",
+			newTest("c", map[string]string{"pool": "x"}),
+			newTest("a", map[string]string{"pool": "x"}),
+			newTest("b", map[string]string{"pool": "y"}),
+		)
+
+		names := func(list *grpcv1.LoadTestList) []string {
+			var names []string
+			for _, test := range list.Items {
+				names = append(names, test.Name)
+			}
+			return names
+		}
+
+		list, err := getter.List(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(list)).To(Equal([]string{"a", "b", "c"}))
+
+		list, err = getter.List(metav1.ListOptions{LabelSelector: "pool=x"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(list)).To(Equal([]string{"a", "c"}))
+
+		list, err = getter.List(metav1.ListOptions{Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(list)).To(Equal([]string{"a"}))
+	})
+
+	It("advances scripted statuses on each get", func() {
+		getter.SetStatuses("a",
+			grpcv1.LoadTestStatus{State: grpcv1.Running},
+			grpcv1.LoadTestStatus{State: grpcv1.Succeeded},
+		)
+		_, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		var states []grpcv1.LoadTestState
+		for i := 0; i < 3; i++ {
+			test, err := getter.Get("a", metav1.GetOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			states = append(states, test.Status.State)
+		}
+		Expect(states).To(Equal([]grpcv1.LoadTestState{grpcv1.Running, grpcv1.Succeeded, grpcv1.Succeeded}))
+	})
+
+	It("sends events to watchers", func() {
+		watcher, err := getter.Watch(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		defer watcher.Stop()
+
+		getter.SetStatuses("a", grpcv1.LoadTestStatus{State: grpcv1.Running})
+		_, err = getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = getter.Get("a", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getter.Delete("a", metav1.DeleteOptions{})).To(Succeed())
+
+		var eventTypes []watch.EventType
+		for i := 0; i < 3; i++ {
+			var event watch.Event
+			Eventually(watcher.ResultChan()).Should(Receive(&event))
+			Expect(event.Object.(*grpcv1.LoadTest).Name).To(Equal("a"))
+			eventTypes = append(eventTypes, event.Type)
+		}
+		Expect(eventTypes).To(Equal([]watch.EventType{watch.Added, watch.Modified, watch.Deleted}))
+	})
+
+	It("stops sending events to stopped watchers", func() {
+		watcher, err := getter.Watch(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		watcher.Stop()
+
+		_, err = getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("only sends events for tests that match the selectors of a watcher", func() {
+		watcher, err := getter.Watch(metav1.ListOptions{
+			LabelSelector: "pool=x",
+			FieldSelector: "metadata.name=b",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		defer watcher.Stop()
+
+		for _, test := range []*grpcv1.LoadTest{
+			newTest("a", map[string]string{"pool": "x"}),
+			newTest("b", map[string]string{"pool": "y"}),
+			newTest("b2", map[string]string{"pool": "x"}),
+		} {
+			_, err = getter.Create(test, metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(getter.Delete("b", metav1.DeleteOptions{})).To(Succeed())
+		_, err = getter.Create(newTest("b", map[string]string{"pool": "x"}), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		var event watch.Event
+		Eventually(watcher.ResultChan()).Should(Receive(&event))
+		Expect(event.Type).To(Equal(watch.Added))
+		Expect(event.Object.(*grpcv1.LoadTest).Labels).To(HaveKeyWithValue("pool", "x"))
+		Consistently(watcher.ResultChan()).ShouldNot(Receive())
+	})
+
+	It("drops events when the buffer of a watcher is full", func() {
+		watcher, err := getter.Watch(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		defer watcher.Stop()
+
+		for i := 0; i < int(watch.DefaultChanSize)+10; i++ {
+			_, err = getter.Create(newTest(fmt.Sprintf("test-%d", i), nil), metav1.CreateOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(watcher.ResultChan()).To(HaveLen(int(watch.DefaultChanSize)))
+	})
+
+	It("returns errors for invalid selectors", func() {
+		_, err := getter.Watch(metav1.ListOptions{FieldSelector: "metadata.name"})
+		Expect(kerrors.IsBadRequest(err)).To(BeTrue())
+		_, err = getter.List(metav1.ListOptions{LabelSelector: "pool in"})
+		Expect(kerrors.IsBadRequest(err)).To(BeTrue())
+	})
+
+	It("sets the created status on new tests", func() {
+		getter.SetCreatedStatus(grpcv1.LoadTestStatus{State: grpcv1.Running})
+		created, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Status.State).To(Equal(grpcv1.Running))
+	})
+
+	It("returns errors set for a verb until they are cleared", func() {
+		unavailable := kerrors.NewServiceUnavailable("try again")
+		getter.SetError("create", unavailable)
+		_, err := getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).To(Equal(unavailable))
+		_, err = getter.Get("a", metav1.GetOptions{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+
+		getter.SetError("create", nil)
+		_, err = getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("records actions with their errors", func() {
+		unavailable := kerrors.NewServiceUnavailable("try again")
+		getter.SetError("create", unavailable)
+		_, _ = getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		getter.SetError("create", nil)
+		_, _ = getter.Create(newTest("a", nil), metav1.CreateOptions{})
+		_, _ = getter.List(metav1.ListOptions{})
+		_ = getter.Delete("a", metav1.DeleteOptions{})
+
+		actions := getter.Actions()
+		Expect(actions).To(HaveLen(4))
+		Expect(actions[0].Verb).To(Equal("create"))
+		Expect(actions[0].Name).To(Equal("a"))
+		Expect(actions[0].Err).To(Equal(unavailable))
+		Expect(actions[1].Err).ToNot(HaveOccurred())
+		Expect(actions[2].Verb).To(Equal("list"))
+		Expect(actions[2].Name).To(BeEmpty())
+		Expect(actions[3].Verb).To(Equal("delete"))
+		Expect(actions[3].Time).ToNot(BeTemporally("<", actions[0].Time))
+	})
+
+	It("applies merge patches", func() {
+		_, err := getter.Create(newTest("a", map[string]string{"pool": "x", "keep": "yes"}), metav1.CreateOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		patched, err := getter.Patch("a", types.MergePatchType, []byte(`{"metadata": {"labels": {"pool": null, "new": "label"}}}`), metav1.PatchOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(patched.Labels).To(Equal(map[string]string{"keep": "yes", "new": "label"}))
+
+		patched, err = getter.Patch("a", types.MergePatchType, []byte(`{"spec": {"timeoutSeconds": 5}, "status": {"state": "Running"}}`), metav1.PatchOptions{}, "status")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(patched.Status.State).To(Equal(grpcv1.Running))
+		Expect(patched.Spec.TimeoutSeconds).To(BeZero())
+
+		_, err = getter.Patch("a", types.JSONPatchType, []byte(`[]`), metav1.PatchOptions{})
+		Expect(kerrors.IsBadRequest(err)).To(BeTrue())
+	})
+})
@@ -2,6 +2,8 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 )
@@ -19,6 +21,14 @@ type LoadTestGetter interface {
 
 	// Delete removes a new test resource, given its name.
 	Delete(name string, opts metav1.DeleteOptions) error
+
+	// Watch streams changes to tests, given its options.
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+
+	// Patch applies a patch of the given type to a test, given its name. If
+	// subresources are given, the patch applies to the subresource (e.g.
+	// "status") instead.
+	Patch(name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*grpcv1.LoadTest, error)
 }
 
 // LoadTestInterface provides methods for accessing a LoadTestGetter when given
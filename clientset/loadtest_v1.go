@@ -2,6 +2,8 @@ package v1
 
 import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 
@@ -58,6 +60,29 @@ func (l *loadTestV1Getter) Delete(name string, opts metav1.DeleteOptions) error
 		Error()
 }
 
+func (l *loadTestV1Getter) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	opts.Watch = true
+	return l.client.Get().
+		Namespace(l.ns).
+		Resource("loadtests").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Watch()
+}
+
+func (l *loadTestV1Getter) Patch(name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*grpcv1.LoadTest, error) {
+	patchedTest := &grpcv1.LoadTest{}
+	err := l.client.Patch(pt).
+		Namespace(l.ns).
+		Resource("loadtests").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do().
+		Into(patchedTest)
+	return patchedTest, err
+}
+
 type loadTestV1 struct {
 	client rest.Interface
 }
@@ -17,16 +17,22 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/yaml"
 
@@ -39,7 +45,7 @@ import (
 var (
 	scheme             = runtime.NewScheme()
 	setupLog           = ctrl.Log.WithName("setup")
-	errMissingDefaults = errors.New("missing flag -defaults-file")
+	errMissingDefaults = errors.New("missing flag -defaults-file or -defaults-configmap")
 )
 
 func init() {
@@ -50,39 +56,61 @@ func init() {
 
 func main() {
 	var defaultsFile string
+	var defaultsConfigMap string
+	var defaultsConfigMapKey string
 	var metricsAddr string
 	var enableLeaderElection bool
 	var namespace string
 	var reconciliationTimeout time.Duration
+	var podDeletionGracePeriod time.Duration
+	var capacityRequeueInterval time.Duration
+	var maxCapacityRequeueInterval time.Duration
+	var enableWebhooks bool
 
 	flag.StringVar(&defaultsFile, "defaults-file", "config/defaults.yaml", "Path to a YAML file with a default configuration.")
+	flag.StringVar(&defaultsConfigMap, "defaults-configmap", "", "ConfigMap with the default configuration, in the form <namespace>/<name> (overrides -defaults-file). It is read once at startup, so the controller must be restarted after the ConfigMap is edited.")
+	flag.StringVar(&defaultsConfigMapKey, "defaults-configmap-key", config.DefaultsConfigMapKey, "Key of the default configuration in the ConfigMap.")
 	flag.StringVar(&metricsAddr, "metrics-addr", ":3777", "Address the metrics endpoint binds to.")
 	flag.StringVar(&namespace, "namespace", "", "Limits resources considered to a specific namespace.")
 	flag.DurationVar(&reconciliationTimeout, "reconciliation-timeout", 0, "Timeout for each load test reconciliation.")
+	flag.DurationVar(&podDeletionGracePeriod, "pod-deletion-grace-period", 5*time.Second, "Grace period for pods of deleted load tests (0 uses the grace period of each pod).")
+	flag.DurationVar(&capacityRequeueInterval, "capacity-requeue-interval", controllers.DefaultCapacityRequeueInterval, "Interval at which load tests that wait for available nodes are reconciled.")
+	flag.DurationVar(&maxCapacityRequeueInterval, "max-capacity-requeue-interval", 0, "Longest interval at which load tests that have waited a long time for nodes are reconciled (0 disables backoff).")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false, "Serve the admission webhooks for load tests, which set their defaults and validate them when they are created. This requires certificates for the webhook server.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false, "Enable leader election (ensures only one controller is active).")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
-	if defaultsFile == "" {
-		setupLog.Error(errMissingDefaults, "cannot start without defaults")
-		os.Exit(1)
-	}
-
-	defaultsBytes, err := ioutil.ReadFile(defaultsFile)
-	if err != nil {
-		setupLog.Error(err, "could not read defaults file")
-		os.Exit(1)
-	}
-
 	defaultOptions := config.Defaults{}
-	if err := yaml.Unmarshal(defaultsBytes, &defaultOptions); err != nil {
-		setupLog.Error(err, "could not parse the defaults file contents")
-		os.Exit(1)
-	}
-
-	if err := defaultOptions.Validate(); err != nil {
-		setupLog.Error(err, "failed to start due to invalid defaults")
+	switch {
+	case defaultsConfigMap != "":
+		defaults, err := readDefaultsConfigMap(defaultsConfigMap, defaultsConfigMapKey)
+		if err != nil {
+			setupLog.Error(err, "could not load defaults from ConfigMap", "configMap", defaultsConfigMap)
+			os.Exit(1)
+		}
+		defaultOptions = *defaults
+
+	case defaultsFile != "":
+		defaultsBytes, err := ioutil.ReadFile(defaultsFile)
+		if err != nil {
+			setupLog.Error(err, "could not read defaults file")
+			os.Exit(1)
+		}
+
+		if err := yaml.Unmarshal(defaultsBytes, &defaultOptions); err != nil {
+			setupLog.Error(err, "could not parse the defaults file contents")
+			os.Exit(1)
+		}
+
+		if err := defaultOptions.Validate(); err != nil {
+			setupLog.Error(err, "failed to start due to invalid defaults")
+			os.Exit(1)
+		}
+
+	default:
+		setupLog.Error(errMissingDefaults, "cannot start without defaults")
 		os.Exit(1)
 	}
 
@@ -105,10 +133,21 @@ func main() {
 		Log:      ctrl.Log.WithName("controllers").WithName("LoadTest"),
 		Scheme:   mgr.GetScheme(),
 		Timeout:  reconciliationTimeout,
+
+		PodDeletionGracePeriod:     podDeletionGracePeriod,
+		CapacityRequeueInterval:    capacityRequeueInterval,
+		MaxCapacityRequeueInterval: maxCapacityRequeueInterval,
+		DefaultsAtAdmission:        enableWebhooks,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "LoadTest")
 		os.Exit(1)
 	}
+	if enableWebhooks {
+		if err = (&grpcv1.LoadTest{}).SetupWebhookWithManager(mgr, defaultOptions.SetLoadTestDefaults, controllers.NewLoadTestValidator(&defaultOptions)); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "LoadTest")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")
@@ -117,3 +156,24 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// readDefaultsConfigMap fetches a ConfigMap, given a reference in the form
+// <namespace>/<name>, and parses the defaults stored under a key.
+func readDefaultsConfigMap(ref, key string) (*config.Defaults, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid ConfigMap reference %q, expected <namespace>/<name>", ref)
+	}
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("could not create client: %v", err)
+	}
+
+	cfgMap := new(corev1.ConfigMap)
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, cfgMap); err != nil {
+		return nil, fmt.Errorf("could not get ConfigMap: %v", err)
+	}
+
+	return config.DefaultsFromConfigMap(cfgMap, key)
+}
@@ -14,57 +14,511 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
+	"github.com/google/uuid"
+	corev1 "k8s.io/api/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	clientset "github.com/grpc/test-infra/clientset"
 	"github.com/grpc/test-infra/tools/runner"
+	"github.com/grpc/test-infra/tools/runner/junit"
 )
 
+// exitCodeCRDNotInstalled is the exit code when the cluster does not serve
+// LoadTest resources. It is distinct from the exit code of log.Fatalf, so
+// scripts can tell a misconfigured cluster apart from other failures.
+const exitCodeCRDNotInstalled = 3
+
+// exitCodeRunTimeout is the exit code when the run timeout passes before all
+// tests finish. The report is still written with the results collected.
+const exitCodeRunTimeout = 4
+
+// exitCodeControllerNotReady is the exit code when the LoadTest CRD or the
+// controller did not become ready within the time given by
+// -wait-for-controller. No tests are submitted.
+const exitCodeControllerNotReady = 5
+
 func main() {
 	var i runner.FileNames
 	var c runner.ConcurrencyLevels
 	var a string
 	var p time.Duration
 	var retries uint
+	var o string
+	var summaryJSON string
+	var resume string
+	var junitNameTemplate string
+	var gitRef string
+	var cluster string
+	var logSampleFirst int
+	var logSampleEvery int
+	var bufferLogs bool
+	var logBufferFlushOnWarning bool
+	var healthAddr string
+	var logDir string
+	var logDirMaxOpen int
+	var maxParameterCombinations int
+	var dryRun bool
+	var namespace string
+	var createNamespace bool
+	var logFormat string
+	var pollJitter float64
+	var retryBaseDelay time.Duration
+	var retryMaxDelay time.Duration
+	var poolProperties bool
+	var sortReport bool
+	var adoptExisting bool
+	var resultsOnly bool
+	var batchSize int
+	var noPodsDeadline time.Duration
+	var driverLogLines int64
+	var globalConcurrency int
+	var failFast bool
+	var drainTimeout time.Duration
+	var batchInterval time.Duration
+	var resultsSelector string
+	var valuesFile string
+	var pollMin time.Duration
+	var pollMax time.Duration
+	var pollExpectedDuration time.Duration
+	var printQueues bool
+	var explain string
+	var annotateOutcome bool
+	var defaultsFile string
+	var runTimeout time.Duration
+	var runTimeoutKeepTests bool
+	var watch bool
+	var waitForController time.Duration
+	var controllerDeployment string
 
-	flag.Var(&i, "i", "input files containing load test configurations")
+	flag.Var(&i, "i", "input files containing load test configurations (\"-\" reads from standard input)")
+	flag.StringVar(&valuesFile, "values", "", "YAML file with values that are substituted into the input files, which are expanded as Go templates (disabled if empty)")
 	flag.Var(&c, "c", "concurrency level, in the form [<queue name>:]<concurrency level>")
 	flag.StringVar(&a, "annotation-key", "pool", "annotation key to parse for queue assignment")
 	flag.DurationVar(&p, "polling-interval", 20*time.Second, "polling interval for load test status")
 	flag.UintVar(&retries, "polling-retries", 2, "Maximum retries in case of communication failure")
+	flag.StringVar(&o, "o", "", "name of the output file for the JUnit XML report")
+	flag.StringVar(&summaryJSON, "summary-json", "", "name of the output file for a JSON summary with the number of passed, failed, not created and skipped tests (disabled if empty)")
+	flag.StringVar(&resume, "resume", "", "checkpoint file with the results of each test, which is updated as tests finish; tests that passed in an earlier run with the same configuration are skipped (disabled if empty)")
+	flag.StringVar(&junitNameTemplate, "junit-name-template", "", "Go template for the name of the JUnit report, with fields {{.Date}}, {{.Timestamp}}, {{.GitRef}} and {{.Cluster}}")
+	flag.StringVar(&gitRef, "git-ref", os.Getenv("GIT_REF"), "git ref of the code under test, used in the JUnit report name (defaults to $GIT_REF)")
+	flag.StringVar(&cluster, "cluster", os.Getenv("CLUSTER_NAME"), "name of the cluster, used in the JUnit report name (defaults to $CLUSTER_NAME)")
+	flag.IntVar(&logSampleFirst, "log-sample-first", 0, "number of repeated informational messages logged for each test before sampling starts (0 disables sampling)")
+	flag.IntVar(&logSampleEvery, "log-sample-every", 10, "log every nth repeated informational message for each test once sampling starts")
+	flag.BoolVar(&bufferLogs, "log-buffer", false, "hold the messages of each test until it finishes, so the output of concurrent tests does not interleave")
+	flag.BoolVar(&logBufferFlushOnWarning, "log-buffer-flush-on-warning", false, "write buffered messages as soon as a test logs a warning or error")
+	flag.StringVar(&healthAddr, "health-addr", "", "address to serve /healthz and /readyz probes on (disabled if empty)")
+	flag.StringVar(&logDir, "log-dir", "", "directory for a separate log file for each test (disabled if empty)")
+	flag.IntVar(&logDirMaxOpen, "log-dir-max-open", 64, "maximum number of log files in the log directory that are open at the same time")
+	flag.IntVar(&maxParameterCombinations, "max-parameter-combinations", 100, "maximum number of tests that the parameter matrix of a single test may expand into")
+	flag.BoolVar(&dryRun, "dry-run", false, "validate configurations and log the tests that would run, without creating them")
+	flag.BoolVar(&printQueues, "print-queues", false, "print the number of tests and concurrency level of each queue and exit, without contacting the cluster")
+	flag.StringVar(&explain, "explain", "", "name of an existing test to diagnose, printing why it is waiting and exiting without modifying the cluster")
+	flag.StringVar(&defaultsFile, "defaults-file", "", "YAML file with the defaults of the controller, which -explain requires to resolve default pools")
+	flag.StringVar(&namespace, "namespace", corev1.NamespaceDefault, "namespace in which load tests are created")
+	flag.BoolVar(&createNamespace, "create-namespace", false, "create the namespace if it does not exist")
+	flag.DurationVar(&pollMin, "poll-min", 5*time.Second, "shortest interval between polls of a test when adaptive polling is enabled")
+	flag.DurationVar(&pollMax, "poll-max", 0, "longest interval between polls of a test, which enables adaptive polling in place of -polling-interval (0 disables adaptive polling)")
+	flag.DurationVar(&pollExpectedDuration, "poll-expected-duration", 0, "expected running time of tests, near which adaptive polling speeds up (0 if unknown)")
+	flag.Float64Var(&pollJitter, "poll-jitter", 0, "fraction of the polling interval by which each poll is randomly shifted, to spread polls of concurrent tests (0 disables jitter)")
+	flag.DurationVar(&retryBaseDelay, "retry-base-delay", runner.DefaultRetryBaseDelay, "delay before the first retry of a failed create or poll operation, doubled for each further retry")
+	flag.DurationVar(&retryMaxDelay, "retry-max-delay", runner.DefaultRetryMaxDelay, "maximum delay between retries of a failed create or poll operation")
+	flag.BoolVar(&poolProperties, "junit-pool-properties", false, "add the number of passed, failed and skipped tests in each queue to the JUnit report as properties")
+	flag.BoolVar(&sortReport, "sort-report", false, "sort test suites and test cases in the JUnit report by name, instead of the order in which tests started")
+	flag.BoolVar(&adoptExisting, "adopt-existing", false, "monitor existing tests with the same configuration instead of creating new ones, so reruns do not duplicate tests")
+	flag.DurationVar(&noPodsDeadline, "no-pods-deadline", 0, "abort tests that have no pods this long after they were submitted (0 disables the deadline)")
+	flag.DurationVar(&runTimeout, "run-timeout", 0, "time limit for the whole run, after which running tests are reported as timed out and deleted, and the runner exits with an error (0 disables the limit)")
+	flag.BoolVar(&runTimeoutKeepTests, "run-timeout-keep-tests", false, "leave tests that are running when the run timeout passes on the cluster, instead of deleting them")
+	flag.DurationVar(&drainTimeout, "drain-timeout", 0, "time to wait for running tests to be deleted after an interrupt, before exiting regardless (0 waits indefinitely)")
+	flag.BoolVar(&failFast, "fail-fast", false, "stop starting tests in all queues after the first test fails, letting running tests finish")
+	flag.IntVar(&globalConcurrency, "global-concurrency", 0, "maximum number of tests that run at the same time across all queues, including running tests that are adopted (0 is unlimited)")
+	flag.Int64Var(&driverLogLines, "driver-log-lines", 0, "number of lines of driver logs to report for each test that fails (0 disables driver logs)")
+	flag.IntVar(&batchSize, "batch-size", 0, "number of tests in each queue that are started before pausing for the batch interval (0 disables batching)")
+	flag.DurationVar(&batchInterval, "batch-interval", 10*time.Second, "pause between batches of tests started in each queue")
+	flag.BoolVar(&annotateOutcome, "annotate-outcome", false, "annotate each test that terminates with its outcome, duration and results location")
+	flag.BoolVar(&resultsOnly, "results-only", false, "report the status of existing tests instead of creating tests from input files")
+	flag.StringVar(&resultsSelector, "results-selector", "", "label selector for the existing tests reported with -results-only (all tests if empty)")
+	flag.DurationVar(&waitForController, "wait-for-controller", 0, "time to wait for the LoadTest CRD to be installed and the controller to have a ready replica before submitting tests, exiting if they are not ready in time (0 disables waiting)")
+	flag.StringVar(&controllerDeployment, "controller-deployment", "test-infra-system/controller-manager", "namespace and name of the deployment of the controller that -wait-for-controller waits for")
+	flag.BoolVar(&watch, "watch", false, "print the state and elapsed time of each test to stdout on each poll, as a table that is redrawn when stdout is a terminal and as a line for each change otherwise (logs are still written to stderr)")
+	flag.StringVar(&logFormat, "log-format", "text", "format of test logs, either text or json (json is written to stdout)")
 	flag.Parse()
 
-	inputConfigs, err := runner.DecodeFromFiles(i)
-	if err != nil {
-		log.Fatalf("Failed to decode: %v", err)
+	var jsonLogWriter *runner.JSONLogWriter
+	switch logFormat {
+	case "text":
+	case "json":
+		jsonLogWriter = runner.NewJSONLogWriter(os.Stdout)
+	default:
+		log.Fatalf("Unknown log format %q", logFormat)
+	}
+
+	startTime := time.Now()
+	reportName := defaultJUnitSuiteName(startTime)
+	if junitNameTemplate != "" {
+		tmpl, err := junit.ParseNameTemplate(junitNameTemplate)
+		if err != nil {
+			log.Fatalf("Failed to validate JUnit name template: %v", err)
+		}
+		reportName, err = junit.RenderName(tmpl, junit.NameTemplateData{
+			Date:      startTime.Format("20060102"),
+			Timestamp: startTime.Format(time.RFC3339),
+			GitRef:    gitRef,
+			Cluster:   cluster,
+		})
+		if err != nil {
+			log.Fatalf("Failed to render JUnit name template: %v", err)
+		}
+	}
+
+	if pollMin <= 0 {
+		log.Fatalf("Invalid adaptive polling: -poll-min %v must be positive", pollMin)
+	}
+
+	if pollMax > 0 && pollMin > pollMax {
+		log.Fatalf("Invalid adaptive polling: -poll-min %v is longer than -poll-max %v", pollMin, pollMax)
+	}
+
+	if resultsOnly && dryRun {
+		log.Fatalf("Cannot combine -results-only with -dry-run")
+	}
+
+	if resultsOnly && printQueues {
+		log.Fatalf("Cannot combine -results-only with -print-queues")
+	}
+
+	if watch && resultsOnly {
+		log.Fatalf("Cannot combine -watch with -results-only")
+	}
+
+	if watch && jsonLogWriter != nil {
+		log.Fatalf("Cannot combine -watch with -log-format=json, since both write to stdout")
+	}
+
+	if explain != "" {
+		if defaultsFile == "" {
+			log.Fatalf("Cannot use -explain without -defaults-file")
+		}
+		defaults, err := runner.DecodeDefaultsFile(defaultsFile)
+		if err != nil {
+			log.Fatalf("Failed to decode defaults: %v", err)
+		}
+		explainer := runner.NewExplainer(runner.NewLoadTestGetter(namespace), runner.NewPodGetter(namespace), runner.NewNodeGetter(), defaults)
+		if err := explainer.Explain(os.Stdout, explain); err != nil {
+			log.Fatalf("Failed to explain test: %v", err)
+		}
+		return
+	}
+
+	var inputConfigs []*grpcv1.LoadTest
+	var err error
+	if !resultsOnly {
+		var values map[string]string
+		if valuesFile != "" {
+			values, err = runner.DecodeValuesFile(valuesFile)
+			if err != nil {
+				log.Fatalf("Failed to decode values: %v", err)
+			}
+		}
+
+		inputConfigs, err = runner.DecodeFromFilesWithValues(i, values)
+		if err != nil {
+			log.Fatalf("Failed to decode: %v", err)
+		}
+
+		inputConfigs, err = runner.ExpandParameterMatrices(inputConfigs, maxParameterCombinations)
+		if err != nil {
+			log.Fatalf("Failed to expand parameter matrices: %v", err)
+		}
+	}
+
+	if printQueues {
+		configQueueMap := runner.CreateQueueMap(inputConfigs, runner.QueueSelectorFromAnnotation(a))
+		assignments, err := runner.QueueAssignments(configQueueMap, c)
+		if err != nil {
+			log.Fatalf("Failed to validate concurrency levels: %v", err)
+		}
+		if err := runner.WriteQueueAssignments(os.Stdout, assignments); err != nil {
+			log.Fatalf("Failed to print queues: %v", err)
+		}
+		return
+	}
+
+	var loadTestGetter clientset.LoadTestGetter
+	if !dryRun {
+		if createNamespace && !resultsOnly {
+			if err := runner.EnsureNamespace(runner.NewNamespaceGetter(), namespace); err != nil {
+				log.Fatalf("Failed to ensure namespace exists: %v", err)
+			}
+		}
+		loadTestGetter = runner.NewLoadTestGetter(namespace)
+		// When waiting for the controller, a missing CRD is retried with the
+		// rest of the readiness check instead of failing right away.
+		if waitForController == 0 || resultsOnly {
+			if err := runner.CheckLoadTestCRD(loadTestGetter); err != nil {
+				if errors.Is(err, runner.ErrLoadTestCRDNotInstalled) {
+					log.Printf("Failed preflight check: %v", err)
+					os.Exit(exitCodeCRDNotInstalled)
+				}
+				log.Fatalf("Failed preflight check: %v", err)
+			}
+		}
+	}
+
+	if resultsOnly {
+		inputConfigs, err = runner.ListTests(loadTestGetter, resultsSelector)
+		if err != nil {
+			log.Fatalf("Failed to list existing tests: %v", err)
+		}
+		log.Printf("Results only: reporting %d existing tests", len(inputConfigs))
 	}
 
 	configQueueMap := runner.CreateQueueMap(inputConfigs, runner.QueueSelectorFromAnnotation(a))
-	err = runner.ValidateConcurrencyLevels(configQueueMap, c)
-	if err != nil {
-		log.Fatalf("Failed to validate concurrency levels: %v", err)
+	if !resultsOnly {
+		err = runner.ValidateConcurrencyLevels(configQueueMap, c)
+		if err != nil {
+			log.Fatalf("Failed to validate concurrency levels: %v", err)
+		}
 	}
 
 	log.Printf("Annotation key for queue assignment: %s", a)
-	log.Printf("Polling interval: %v", p)
+	if pollMax > 0 {
+		log.Printf("Polling interval: adaptive from %v to %v", pollMin, pollMax)
+	} else {
+		log.Printf("Polling interval: %v", p)
+	}
+	log.Printf("Polling jitter: %v", pollJitter)
 	log.Printf("Polling retries: %d", retries)
+	log.Printf("Retry delays: %v to %v", retryBaseDelay, retryMaxDelay)
+	if batchSize > 0 {
+		log.Printf("Batches: %d tests every %v", batchSize, batchInterval)
+	}
 	log.Printf("Test counts per queue: %v", runner.CountConfigs(configQueueMap))
 	log.Printf("Queue concurrency levels: %v", c)
+	if globalConcurrency > 0 {
+		log.Printf("Global concurrency level: %d", globalConcurrency)
+	}
+	if runTimeout > 0 {
+		log.Printf("Run timeout: %v", runTimeout)
+	}
+	log.Printf("Namespace: %s", namespace)
+	log.Printf("Output file: %s", o)
+	log.Printf("Report name: %s", reportName)
 
-	r := runner.NewRunner(runner.NewLoadTestGetter(), runner.AfterIntervalFunction(p), retries)
+	if dryRun {
+		log.Printf("Dry run: no tests will be created")
+		for qName, configs := range configQueueMap {
+			log.Printf("Queue %q would run %d tests at concurrency level %d", qName, len(configs), c[qName])
+		}
+	}
+
+	if healthAddr != "" && !dryRun {
+		log.Printf("Serving health checks on %s", healthAddr)
+		go func() {
+			if err := http.ListenAndServe(healthAddr, runner.NewHealthHandler(loadTestGetter)); err != nil {
+				log.Fatalf("Failed to serve health checks: %v", err)
+			}
+		}()
+	}
+
+	r := runner.NewRunner(loadTestGetter, runner.AfterIntervalWithJitter(p, pollJitter), retries, runner.ExponentialBackoff(retryBaseDelay, retryMaxDelay), dryRun)
+	r.SetAdoptExisting(adoptExisting)
+	r.SetBatching(batchSize, batchInterval)
+	r.SetNoPodsDeadline(noPodsDeadline)
+	r.SetGlobalConcurrency(globalConcurrency)
+	r.SetFailFast(failFast)
+	r.SetAnnotateOutcome(annotateOutcome)
+	r.SetKeepTestsOnRunTimeout(runTimeoutKeepTests)
+	if pollMax > 0 {
+		r.SetPollSchedule(&runner.AdaptivePollSchedule{
+			Min:      pollMin,
+			Max:      pollMax,
+			Expected: pollExpectedDuration,
+			Jitter:   pollJitter,
+		})
+	}
+	if driverLogLines > 0 && !dryRun {
+		r.SetDriverLogs(runner.NewPodGetter(namespace), driverLogLines)
+	}
+	if waitForController > 0 && !dryRun && !resultsOnly {
+		parts := strings.SplitN(controllerDeployment, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			log.Fatalf("Invalid -controller-deployment %q: expected <namespace>/<name>", controllerDeployment)
+		}
+		r.SetReadinessChecker(runner.AllReady(
+			runner.NewCRDReadinessChecker(loadTestGetter),
+			runner.NewDeploymentReadinessChecker(runner.NewDeploymentGetter(parts[0]), parts[1]),
+		), waitForController)
+	}
+	if resume != "" && !dryRun {
+		checkpoint, err := runner.LoadCheckpoint(resume)
+		if err != nil {
+			log.Fatalf("Failed to load checkpoint: %v", err)
+		}
+		log.Printf("Resuming from checkpoint %q with the results of %d tests", resume, checkpoint.Len())
+		r.SetCheckpoint(checkpoint)
+	}
 
 	logPrefixFmt := runner.LogPrefixFmt(configQueueMap)
 
+	var logFiles *runner.LogFiles
+	if logDir != "" {
+		logFiles, err = runner.NewLogFiles(logDir, logDirMaxOpen)
+		if err != nil {
+			log.Fatalf("Failed to set up log directory: %v", err)
+		}
+		log.Printf("Log directory: %s", logDir)
+	}
+
+	var logBuffer *runner.LogBuffer
+	if bufferLogs {
+		logBuffer = runner.NewLogBuffer(logBufferFlushOnWarning)
+	}
+
+	report := junit.NewReport(uuid.New().String(), reportName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if runTimeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, runTimeout)
+		defer cancelTimeout()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("Received %v, cancelling tests", sig)
+		cancel()
+	}()
+
+	if err := r.WaitUntilReady(ctx); err != nil {
+		log.Printf("Not submitting tests: %v", err)
+		os.Exit(exitCodeControllerNotReady)
+	}
+
 	done := make(chan string)
 
 	for qName, configs := range configQueueMap {
-		reporter := runner.NewTestSuiteReporter(qName, logPrefixFmt)
-		go r.Run(configs, reporter, c[qName], done)
+		reportSuite := report.NewTestSuite(qName, runner.SuiteName(qName))
+		reporter := runner.NewTestSuiteReporter(qName, logPrefixFmt, reportSuite)
+		reporter.SetLoggerWrapper(func(logger runner.Logger) runner.Logger {
+			if logBuffer != nil {
+				logger = logBuffer.NewLogger(logger)
+			}
+			if logSampleFirst > 0 {
+				logger = runner.NewSamplingLogger(logger, logSampleFirst, logSampleEvery)
+			}
+			return logger
+		})
+		if logFiles != nil {
+			reporter.SetLogFiles(logFiles)
+		}
+		if jsonLogWriter != nil {
+			reporter.SetJSONLogWriter(jsonLogWriter)
+		}
+		if resultsOnly {
+			go runner.ReportExisting(configs, reporter, done)
+			continue
+		}
+		go r.Run(ctx, configs, reporter, c[qName], done)
+	}
+
+	// The watcher reads snapshots of the runner, so it shows the results of
+	// the polls of the runner rather than polling the tests again.
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	if watch {
+		watcher := runner.NewWatcher(r.Snapshot, os.Stdout, runner.IsTerminal(os.Stdout))
+		go func() {
+			watcher.Watch(watchCtx, p)
+			close(watchDone)
+		}()
+	} else {
+		close(watchDone)
+	}
+
+	var queues []string
+	for qName := range configQueueMap {
+		queues = append(queues, qName)
+	}
+	if unfinished := runner.WaitForQueues(ctx, done, queues, drainTimeout); len(unfinished) > 0 {
+		log.Printf("Queues %v did not finish within the drain timeout of %v", unfinished, drainTimeout)
+		for _, name := range r.ActiveTests() {
+			log.Printf("Test %s may not have been deleted", name)
+		}
+	}
+	stopWatch()
+	<-watchDone
+	runTimedOut := errors.Is(ctx.Err(), context.DeadlineExceeded)
+	if runTimedOut {
+		log.Printf("Run did not finish within the run timeout of %v", runTimeout)
+	}
+
+	for _, summary := range report.Summarize() {
+		log.Printf("Queue %q: %d passed, %d failed, %d skipped", summary.ID, summary.Passed, summary.Failed, summary.Skipped)
+		if poolProperties {
+			report.AddProperty(fmt.Sprintf("%s.passed", summary.ID), fmt.Sprint(summary.Passed))
+			report.AddProperty(fmt.Sprintf("%s.failed", summary.ID), fmt.Sprint(summary.Failed))
+			report.AddProperty(fmt.Sprintf("%s.skipped", summary.ID), fmt.Sprint(summary.Skipped))
+		}
 	}
 
-	for range configQueueMap {
-		qName := <-done
-		log.Printf("Done running tests for queue %q", qName)
+	report.SetDuration(time.Since(startTime))
+	report.Finalize()
+	if sortReport {
+		report.Sort()
 	}
+
+	// The report is written without the context of the run, so the results
+	// collected before the run timeout are written after it passes.
+	if o != "" {
+		outputFile, err := os.Create(o)
+		if err != nil {
+			log.Fatalf("Failed to create output file %q: %v", o, err)
+		}
+		if err = report.WriteToStream(outputFile, 2); err != nil {
+			outputFile.Close()
+			log.Fatalf("Failed to write report to output file %q: %v", o, err)
+		}
+		if err = outputFile.Close(); err != nil {
+			log.Fatalf("Failed to close output file %q: %v", o, err)
+		}
+	}
+
+	if summaryJSON != "" {
+		summaryFile, err := os.Create(summaryJSON)
+		if err != nil {
+			log.Fatalf("Failed to create summary file %q: %v", summaryJSON, err)
+		}
+		if err = runner.NewSummary(report).WriteJSON(summaryFile); err != nil {
+			summaryFile.Close()
+			log.Fatalf("Failed to write summary to file %q: %v", summaryJSON, err)
+		}
+		if err = summaryFile.Close(); err != nil {
+			log.Fatalf("Failed to close summary file %q: %v", summaryJSON, err)
+		}
+	}
+
+	if runTimedOut {
+		os.Exit(exitCodeRunTimeout)
+	}
+}
+
+// defaultJUnitSuiteName returns the name of the JUnit report when no name
+// template is given. The name is a timestamp of the start of the run.
+func defaultJUnitSuiteName(startTime time.Time) string {
+	return startTime.Format(time.RFC3339)
 }
@@ -26,6 +26,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"text/template"
@@ -54,7 +55,8 @@ based on where the manager and container images will live and run.
 
 This configure tool accepts two arguments. The first is <template-file>, which
 is the input YAML file with placeholders for string interpolation. The second is
-<output-file>, which is the path to write the output on disk.
+<output-file>, which is the path to write the output on disk. If <output-file>
+is "-", the output is written to stdout.
 
 The string interpolation is based on Go's text/template package. See
 https://pkg.go.dev/text/template for a description of the syntax. All flags
@@ -100,11 +102,6 @@ container images that are not used as init containers.`)
 		exitWithErrorf(1, true, "could not open and parse <template-file>: %v", err)
 	}
 
-	outputFile, err := os.Create(flag.Arg(1))
-	if err != nil {
-		exitWithErrorf(1, true, "could not create <output-file>: %v", err)
-	}
-
 	outputBuilder := &strings.Builder{}
 	if err := templ.Execute(outputBuilder, data); err != nil {
 		exitWithErrorf(1, false, "could not generate config from template: %v", err)
@@ -123,9 +120,41 @@ container images that are not used as init containers.`)
 		}
 	}
 
-	if _, err := outputFile.WriteString(output); err != nil {
+	outputFile, err := openOutput(flag.Arg(1), os.Stdout)
+	if err != nil {
+		exitWithErrorf(1, true, "could not create <output-file>: %v", err)
+	}
+	if _, err := io.WriteString(outputFile, output); err != nil {
+		outputFile.Close()
 		exitWithErrorf(1, false, "could not write config to output file: %v", err)
 	}
+	if err := outputFile.Close(); err != nil {
+		exitWithErrorf(1, false, "could not close output file: %v", err)
+	}
+}
+
+// stdoutPath is the output path that selects stdout instead of a file.
+const stdoutPath = "-"
+
+// nopWriteCloser wraps a writer with a Close method that does nothing. It
+// prevents stdout from being closed along with the output.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements the io.Closer interface.
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// openOutput returns the destination for the generated config. If the path
+// is "-", the config is written to stdout. Otherwise, the file at the path is
+// created or truncated.
+func openOutput(path string, stdout io.Writer) (io.WriteCloser, error) {
+	if path == stdoutPath {
+		return nopWriteCloser{stdout}, nil
+	}
+	return os.Create(path)
 }
 
 // exitWithErrorf aborts the process, logging a message to the command line and,
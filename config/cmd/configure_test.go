@@ -0,0 +1,61 @@
+/*
+Copyright 2020 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("openOutput", func() {
+	It("writes to stdout when the path is -", func() {
+		stdout := &bytes.Buffer{}
+		output, err := openOutput("-", stdout)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = io.WriteString(output, "cloneImage: clone\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Close()).To(Succeed())
+		Expect(stdout.String()).To(Equal("cloneImage: clone\n"))
+	})
+
+	It("writes to a file when given a path", func() {
+		dir, err := ioutil.TempDir("", "configure")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		stdout := &bytes.Buffer{}
+		path := filepath.Join(dir, "defaults.yaml")
+		output, err := openOutput(path, stdout)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = io.WriteString(output, "cloneImage: clone\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.Close()).To(Succeed())
+
+		data, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("cloneImage: clone\n"))
+		Expect(stdout.Len()).To(Equal(0))
+	})
+})
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultsConfigMapKey is the default key of the ConfigMap entry that
+// contains the defaults, formatted as YAML.
+const DefaultsConfigMapKey = "defaults.yaml"
+
+// DefaultsFromConfigMap parses and validates the defaults stored as YAML in
+// an entry of a ConfigMap. An error is returned if the entry is missing, does
+// not parse or contains invalid defaults.
+//
+// The controller reads the ConfigMap once at startup and does not watch it, so
+// edits to the ConfigMap take effect only after the controller is restarted.
+func DefaultsFromConfigMap(cfgMap *corev1.ConfigMap, key string) (*Defaults, error) {
+	data, ok := cfgMap.Data[key]
+	if !ok {
+		return nil, errors.Errorf("ConfigMap %s/%s has no key %q", cfgMap.Namespace, cfgMap.Name, key)
+	}
+
+	defaults := new(Defaults)
+	if err := yaml.UnmarshalStrict([]byte(data), defaults); err != nil {
+		return nil, errors.Wrapf(err, "could not parse defaults in ConfigMap %s/%s", cfgMap.Namespace, cfgMap.Name)
+	}
+
+	if err := defaults.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid defaults in ConfigMap %s/%s", cfgMap.Namespace, cfgMap.Name)
+	}
+
+	return defaults, nil
+}
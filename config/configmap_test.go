@@ -0,0 +1,84 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("DefaultsFromConfigMap", func() {
+	newConfigMap := func(data string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "loadtest-defaults",
+				Namespace: "test-infra-system",
+			},
+			Data: map[string]string{
+				DefaultsConfigMapKey: data,
+			},
+		}
+	}
+
+	It("parses valid defaults", func() {
+		cfgMap := newConfigMap(`
+componentNamespace: default
+cloneImage: gcr.io/grpc-fake-project/test-infra/clone
+readyImage: gcr.io/grpc-fake-project/test-infra/ready
+driverImage: gcr.io/grpc-fake-project/test-infra/driver
+languages:
+- language: cxx
+  buildImage: l.gcr.io/google/bazel:latest
+  runImage: gcr.io/grpc-fake-project/test-infra/cxx
+`)
+
+		defaults, err := DefaultsFromConfigMap(cfgMap, DefaultsConfigMapKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(defaults.CloneImage).To(Equal("gcr.io/grpc-fake-project/test-infra/clone"))
+		Expect(defaults.Languages).To(HaveLen(1))
+		Expect(defaults.Languages[0].Language).To(Equal("cxx"))
+	})
+
+	It("returns an error when the key is missing", func() {
+		cfgMap := newConfigMap("")
+		_, err := DefaultsFromConfigMap(cfgMap, "other.yaml")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when the content is not YAML", func() {
+		cfgMap := newConfigMap("cloneImage: [unterminated")
+		_, err := DefaultsFromConfigMap(cfgMap, DefaultsConfigMapKey)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when the content has unknown fields", func() {
+		cfgMap := newConfigMap("cloneImages: gcr.io/grpc-fake-project/test-infra/clone")
+		_, err := DefaultsFromConfigMap(cfgMap, DefaultsConfigMapKey)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when the defaults are invalid", func() {
+		cfgMap := newConfigMap(`
+readyImage: gcr.io/grpc-fake-project/test-infra/ready
+driverImage: gcr.io/grpc-fake-project/test-infra/driver
+`)
+		_, err := DefaultsFromConfigMap(cfgMap, DefaultsConfigMapKey)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -26,6 +26,15 @@ const (
 	// https://docs.bazel.build/versions/master/output_directories.html.
 	BazelCacheMountPath = "/root/.cache/bazel"
 
+	// BigQueryPartitionFieldEnv specifies the name of the env variable that
+	// holds the column which partitions the table where results are written.
+	BigQueryPartitionFieldEnv = "BQ_PARTITION_FIELD"
+
+	// BigQueryPartitionTypeEnv specifies the name of the env variable that
+	// holds the granularity of the partitions of the table where results are
+	// written, such as DAY.
+	BigQueryPartitionTypeEnv = "BQ_PARTITION_TYPE"
+
 	// BigQueryTableEnv specifies the name of the env variable that holds the name
 	// of the table where results should be written.
 	BigQueryTableEnv = "BQ_RESULT_TABLE"
@@ -34,10 +43,33 @@ const (
 	// a binary or other bundle required to run the tests.
 	BuildInitContainerName = "build"
 
+	// CancelAnnotation is the key of an annotation that cancels a load test
+	// when it is set to "true". The controller deletes the pods of the test
+	// and marks it Cancelled, but keeps the test and its status.
+	CancelAnnotation = "e2etest.grpc.io/cancel"
+
 	// ClientRole is the value the controller expects for the RoleLabel
 	// on a client component.
 	ClientRole = "client"
 
+	// CloneCredentialsDirEnv specifies the name of the env variable that
+	// contains the directory with credentials for cloning a private git
+	// repository.
+	CloneCredentialsDirEnv = "CLONE_CREDENTIALS_DIR"
+
+	// CloneCredentialsMountPath specifies where the secret with credentials
+	// for cloning is mounted, unless the defaults specify another path.
+	CloneCredentialsMountPath = "/var/run/secrets/git"
+
+	// CloneCredentialsVolumeName is the name of the volume with the secret
+	// with credentials for cloning.
+	CloneCredentialsVolumeName = "clone-credentials"
+
+	// CloneDepthEnv specifies the name of the env variable that contains the
+	// number of commits to fetch when cloning a git repository. When it is
+	// not set, the full history is cloned.
+	CloneDepthEnv = "CLONE_DEPTH"
+
 	// CloneGitRefEnv specifies the name of the env variable that contains the
 	// commit, tag or branch to checkout after cloning a git repository.
 	CloneGitRefEnv = "CLONE_GIT_REF"
@@ -54,6 +86,11 @@ const (
 	// components with the same role.
 	ComponentNameLabel = "loadtest-component"
 
+	// DateAnnotation is the key of an annotation or label with the date of a
+	// test, formatted as YYYYMMDD. It is used by the {{.Date}} template in
+	// the results fields of a test.
+	DateAnnotation = "date"
+
 	// DriverRole is the value the controller expects for the RoleLabel
 	// on a driver component.
 	DriverRole = "driver"
@@ -63,9 +100,31 @@ const (
 	// instructions and receive results from the servers and clients.
 	DriverPort = 10000
 
-	// LoadTestLabel is a label which contains the test's unique name.
+	// GCSBucketEnv specifies the name of the env variable that holds the name
+	// of the Cloud Storage bucket where result artifacts should be uploaded.
+	GCSBucketEnv = "GCS_RESULT_BUCKET"
+
+	// GCSPrefixEnv specifies the name of the env variable that holds the
+	// prefix of the names of result artifacts uploaded to Cloud Storage.
+	GCSPrefixEnv = "GCS_RESULT_PREFIX"
+
+	// GitRefAnnotation is the key of an annotation or label with the git ref
+	// of the code under test. It is used by the {{.GitRef}} template in the
+	// results fields of a test.
+	GitRefAnnotation = "gitRef"
+
+	// LoadTestLabel is a label which contains the test's unique name or UID.
+	// See the LoadTestLabelValue field of Defaults.
 	LoadTestLabel = "loadtest"
 
+	// LoadTestLabelValueName selects the name of a test as the value of the
+	// LoadTestLabel on its pods.
+	LoadTestLabelValueName = "name"
+
+	// LoadTestLabelValueUID selects the UID of a test as the value of the
+	// LoadTestLabel on its pods.
+	LoadTestLabelValueUID = "uid"
+
 	// PoolLabel is the key for a label which will have the name of a pool as
 	// the value.
 	PoolLabel = "pool"
@@ -86,6 +145,22 @@ const (
 	// between the ready init container and the driver's run container.
 	ReadyVolumeName = "worker-addresses"
 
+	// ResultsTokenFileEnv specifies the name of an env variable that specifies
+	// the path to the service account token for uploading results.
+	ResultsTokenFileEnv = "RESULTS_TOKEN_FILE"
+
+	// ResultsTokenMountPath specifies where the service account token for
+	// uploading results should be mounted in the driver container.
+	ResultsTokenMountPath = "/var/run/secrets/results"
+
+	// ResultsTokenPath is the path of the service account token for uploading
+	// results, relative to the ResultsTokenMountPath.
+	ResultsTokenPath = "token"
+
+	// ResultsTokenVolumeName is the name of the projected volume with the
+	// service account token for uploading results.
+	ResultsTokenVolumeName = "results-token"
+
 	// RoleLabel is a label with the role  of a test component. For
 	// example, "loadtest-role=server" indicates a server component.
 	RoleLabel = "loadtest-role"
@@ -99,7 +174,8 @@ const (
 	ScenariosFileEnv = "SCENARIOS_FILE"
 
 	// ScenariosMountPath specifies where the JSON file with the scenario should
-	// be mounted in the driver container.
+	// be mounted in the driver container, unless the defaults specify another
+	// path.
 	ScenariosMountPath = "/src/scenarios"
 
 	// ServerRole is the value the controller expects for the RoleLabel
@@ -17,9 +17,18 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
 	"github.com/google/uuid"
 	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/kubehelpers"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // Defaults defines the default settings for the system.
@@ -37,6 +46,18 @@ type Defaults struct {
 	// cloning Git repositories at a specific snapshot.
 	CloneImage string `json:"cloneImage"`
 
+	// CloneCredentialsMountPath specifies where the secret with credentials
+	// for cloning a private repository is mounted in the clone init
+	// container. If unset, the config.CloneCredentialsMountPath constant is
+	// used.
+	CloneCredentialsMountPath string `json:"cloneCredentialsMountPath,omitempty"`
+
+	// ScenariosMountPath specifies where the ConfigMap with the scenarios of
+	// a test is mounted in the run container of the driver. The environment
+	// variable named by ScenariosFileEnv points to the scenarios file in this
+	// directory. If unset, the config.ScenariosMountPath constant is used.
+	ScenariosMountPath string `json:"scenariosMountPath,omitempty"`
+
 	// ReadyImage specifies the container image to use to block the driver from
 	// starting before all worker pods are ready.
 	ReadyImage string `json:"readyImage"`
@@ -48,41 +69,161 @@ type Defaults struct {
 	// Languages specifies the default build and run container images
 	// for each known language.
 	Languages []LanguageDefault `json:"languages,omitempty"`
+
+	// LoadTestLabelValue selects the value of the LoadTestLabel on the pods
+	// of a test. It may be LoadTestLabelValueName to use the name of the
+	// test or LoadTestLabelValueUID to use its UID, which avoids ambiguity
+	// when names are long. If unset, the name of the test is used.
+	LoadTestLabelValue string `json:"loadTestLabelValue,omitempty"`
+
+	// WorkerResources are the default compute resources for the run
+	// container of clients and servers that do not specify their own.
+	WorkerResources *corev1.ResourceRequirements `json:"workerResources,omitempty"`
+
+	// DriverResources are the default compute resources for the run
+	// container of drivers that do not specify their own.
+	DriverResources *corev1.ResourceRequirements `json:"driverResources,omitempty"`
+
+	// ImagePullSecrets are the names of secrets used to pull the images of
+	// the pods of tests that do not specify their own. The secrets must
+	// exist in the namespace of each test.
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+
+	// DefaultTolerations are added to the pods of components that do not
+	// specify their own tolerations. They allow tests to run on nodes with
+	// taints, such as preemptible nodes that are used to reduce costs.
+	//
+	// Tolerations of a component replace the defaults rather than merging
+	// with them, so a component that lists its own tolerations does not
+	// tolerate the default taints unless it lists them, too. An empty list
+	// of tolerations on a component is the same as no list, and uses the
+	// defaults. When there are no default tolerations, pods of components
+	// without tolerations have none.
+	DefaultTolerations []corev1.Toleration `json:"defaultTolerations,omitempty"`
+
+	// ValidateScenarios enables the validation of the scenarios of each test
+	// against ScenariosSchema when defaults are set. Tests with scenarios
+	// that do not match the schema are rejected before any pods are created.
+	ValidateScenarios bool `json:"validateScenarios,omitempty"`
+
+	// ScenariosSchema is a JSON Schema for the Scenarios messages of tests.
+	// Only the type, required, properties, additionalProperties, items, enum,
+	// minimum and maximum keywords are supported. If unset,
+	// DefaultScenariosSchema is used.
+	ScenariosSchema string `json:"scenariosSchema,omitempty"`
+}
+
+// ValidationError lists every problem found when validating defaults. Each
+// problem names the offending field, using the field names of the YAML
+// configuration.
+type ValidationError struct {
+	// Problems describe each invalid field.
+	Problems []string
+}
+
+// Error returns all problems on a single line.
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("invalid defaults: %s", e.Problems[0])
+	}
+	return fmt.Sprintf("invalid defaults (%d problems): %s", len(e.Problems), strings.Join(e.Problems, "; "))
 }
 
 // Validate ensures that the required fields are present and an acceptable
-// value. If an issue is encountered, an error is returned. If the defaults are
-// valid, nil is returned.
+// value. If any issues are encountered, a *ValidationError listing all of them
+// is returned. If the defaults are valid, nil is returned.
 func (d *Defaults) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
 	if d.CloneImage == "" {
-		return errors.New("missing image for clone init container")
+		addProblem("cloneImage: missing image for clone init container")
 	}
 
 	if d.ReadyImage == "" {
-		return errors.New("missing image for ready init container")
+		addProblem("readyImage: missing image for ready init container")
 	}
 
 	if d.DriverImage == "" {
-		return errors.New("missing image for driver container")
+		addProblem("driverImage: missing image for driver container")
 	}
 
+	if d.CloneCredentialsMountPath != "" && !path.IsAbs(d.CloneCredentialsMountPath) {
+		addProblem("cloneCredentialsMountPath: path %q is not absolute", d.CloneCredentialsMountPath)
+	}
+
+	if d.ScenariosMountPath != "" && !path.IsAbs(d.ScenariosMountPath) {
+		addProblem("scenariosMountPath: path %q is not absolute", d.ScenariosMountPath)
+	}
+
+	if d.DefaultPoolLabels != nil {
+		if d.DefaultPoolLabels.Client == "" {
+			addProblem("defaultPoolLabels.client: missing label for default client pool")
+		}
+		if d.DefaultPoolLabels.Driver == "" {
+			addProblem("defaultPoolLabels.driver: missing label for default driver pool")
+		}
+		if d.DefaultPoolLabels.Server == "" {
+			addProblem("defaultPoolLabels.server: missing label for default server pool")
+		}
+	}
+
+	switch d.LoadTestLabelValue {
+	case "", LoadTestLabelValueName, LoadTestLabelValueUID:
+	default:
+		addProblem("loadTestLabelValue: unknown load test label value %q", d.LoadTestLabelValue)
+	}
+
+	if err := kubehelpers.CheckRequestsWithinLimits(d.WorkerResources); err != nil {
+		addProblem("workerResources: %v", err)
+	}
+
+	if err := kubehelpers.CheckRequestsWithinLimits(d.DriverResources); err != nil {
+		addProblem("driverResources: %v", err)
+	}
+
+	if d.ScenariosSchema != "" {
+		if _, err := parseScenariosSchema(d.ScenariosSchema); err != nil {
+			addProblem("scenariosSchema: %v", err)
+		}
+	}
+
+	seen := make(map[string]int)
 	for i, ld := range d.Languages {
 		if ld.Language == "" {
-			return errors.Errorf("language (index %d) unnamed", i)
+			addProblem("languages[%d].language: language unnamed", i)
+		} else if j, ok := seen[ld.Language]; ok {
+			addProblem("languages[%d].language: language %q already defined at index %d", i, ld.Language, j)
+		} else {
+			seen[ld.Language] = i
 		}
 
 		if ld.BuildImage == "" {
-			return errors.Errorf("language %q (index %d) missing image for build init container", ld.Language, i)
+			addProblem("languages[%d].buildImage: language %q missing image for build init container", i, ld.Language)
 		}
 
 		if ld.RunImage == "" {
-			return errors.Errorf("language %q (index %d) missing image for run container", ld.Language, i)
+			addProblem("languages[%d].runImage: language %q missing image for run container", i, ld.Language)
 		}
 	}
 
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
 	return nil
 }
 
+// LoadTestLabelValueFor returns the value of the LoadTestLabel on the pods of
+// a test, based on the LoadTestLabelValue setting.
+func (d *Defaults) LoadTestLabelValueFor(test *grpcv1.LoadTest) string {
+	if d != nil && d.LoadTestLabelValue == LoadTestLabelValueUID {
+		return string(test.UID)
+	}
+	return test.Name
+}
+
 // SetLoadTestDefaults applies default values for missing fields that are
 // required to reconcile a load test.
 //
@@ -97,6 +238,12 @@ func (d *Defaults) SetLoadTestDefaults(test *grpcv1.LoadTest) error {
 		test.Namespace = d.ComponentNamespace
 	}
 
+	if d.ValidateScenarios {
+		if err := d.validateScenarios(test); err != nil {
+			return err
+		}
+	}
+
 	if err := d.setDriverDefaults(im, testSpec); err != nil {
 		return errors.Wrap(err, "could not set defaults for driver")
 	}
@@ -113,9 +260,125 @@ func (d *Defaults) SetLoadTestDefaults(test *grpcv1.LoadTest) error {
 		}
 	}
 
+	if err := d.setResultsDefaults(test); err != nil {
+		return errors.Wrap(err, "could not set defaults for results")
+	}
+
+	return nil
+}
+
+// setResultsDefaults renders any templates in the fields of the results. See
+// the resultsTemplateData type for the values that templates may use. It also
+// validates the partitioning of the BigQuery table, defaulting the partition
+// type to DAY when only a partition field is set.
+func (d *Defaults) setResultsDefaults(test *grpcv1.LoadTest) error {
+	results := test.Spec.Results
+	if results == nil {
+		return nil
+	}
+
+	if results.BigQueryTable != nil {
+		table, err := renderResultsTemplate(*results.BigQueryTable, newResultsTemplateData(test))
+		if err != nil {
+			return errors.Wrap(err, "invalid template for BigQuery table")
+		}
+		results.BigQueryTable = &table
+	}
+
+	if results.PartitionField == nil && results.PartitionType == "" {
+		return nil
+	}
+	if results.BigQueryTable == nil {
+		return errors.New("partitioning requires a BigQuery table")
+	}
+	if results.PartitionField != nil && !partitionFieldPattern.MatchString(*results.PartitionField) {
+		return errors.Errorf("invalid partition field %q: must contain only letters, numbers and underscores, and must not start with a number", *results.PartitionField)
+	}
+	switch results.PartitionType {
+	case "":
+		results.PartitionType = grpcv1.DayPartition
+	case grpcv1.HourPartition, grpcv1.DayPartition, grpcv1.MonthPartition, grpcv1.YearPartition:
+	default:
+		return errors.Errorf("invalid partition type %q: must be HOUR, DAY, MONTH or YEAR", results.PartitionType)
+	}
+
 	return nil
 }
 
+// partitionFieldPattern matches the names of columns that BigQuery accepts.
+var partitionFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,299}$`)
+
+// renderResultsTemplate parses and executes a template for a results field.
+// A value without template actions is returned unchanged.
+func renderResultsTemplate(text string, data *resultsTemplateData) (string, error) {
+	tmpl, err := template.New("results").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not parse template %q", text)
+	}
+
+	b := &strings.Builder{}
+	if err = tmpl.Execute(b, data); err != nil {
+		return "", errors.Wrapf(err, "could not execute template %q", text)
+	}
+
+	return b.String(), nil
+}
+
+// resultsTemplateData provides the values for templates in the results fields
+// of a load test. Values are read from the annotations of the test, falling
+// back to its labels.
+type resultsTemplateData struct {
+	test *grpcv1.LoadTest
+
+	// Labels are the labels on the load test.
+	Labels map[string]string
+
+	// Annotations are the annotations on the load test.
+	Annotations map[string]string
+}
+
+// newResultsTemplateData creates the template data for a load test.
+func newResultsTemplateData(test *grpcv1.LoadTest) *resultsTemplateData {
+	return &resultsTemplateData{
+		test:        test,
+		Labels:      test.Labels,
+		Annotations: test.Annotations,
+	}
+}
+
+// lookup returns the value of an annotation or label on the load test.
+func (data *resultsTemplateData) lookup(key string) (string, bool) {
+	if value, ok := data.test.Annotations[key]; ok {
+		return value, true
+	}
+	value, ok := data.test.Labels[key]
+	return value, ok
+}
+
+// Date returns the date of the test, formatted as YYYYMMDD. The value is read
+// from the DateAnnotation key. If it is not set, the date is derived from the
+// creation time of the test or, if that is unknown, the current time.
+func (data *resultsTemplateData) Date() string {
+	if date, ok := data.lookup(DateAnnotation); ok {
+		return date
+	}
+
+	t := data.test.CreationTimestamp.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format("20060102")
+}
+
+// GitRef returns the git ref of the code under test, which is read from the
+// GitRefAnnotation key. An error is returned if the key is not set.
+func (data *resultsTemplateData) GitRef() (string, error) {
+	if gitRef, ok := data.lookup(GitRefAnnotation); ok {
+		return gitRef, nil
+	}
+	return "", errors.Errorf("no annotation or label %q for git ref", GitRefAnnotation)
+}
+
 // setCloneOrDefault sets the default clone image if it is unset.
 func (d *Defaults) setCloneOrDefault(clone *grpcv1.Clone) {
 	if clone != nil && clone.Image == nil {
@@ -153,6 +416,37 @@ func (d *Defaults) setRunOrDefault(im *imageMap, language string, run *grpcv1.Ru
 	return nil
 }
 
+// setRunCommandOrDefault sets the default command and arguments of a language
+// on the run instructions of a worker, if they are unset. Arguments taken from
+// a ConfigMap count as set. The defaults are copied, so tests do not share
+// them.
+func setRunCommandOrDefault(im *imageMap, language string, run *grpcv1.Run) {
+	command, args := im.runCommand(language)
+	if len(run.Command) == 0 && len(command) > 0 {
+		run.Command = append([]string(nil), command...)
+	}
+	if len(run.Args) == 0 && run.ArgsFrom == nil && len(args) > 0 {
+		run.Args = append([]string(nil), args...)
+	}
+}
+
+// setBuildCommandOrDefault sets the default command and arguments of a
+// language on the build instructions of a worker, if they are unset. Workers
+// without build instructions are left alone. The defaults are copied, so tests
+// do not share them.
+func setBuildCommandOrDefault(im *imageMap, language string, build *grpcv1.Build) {
+	if build == nil {
+		return
+	}
+	command, args := im.buildCommand(language)
+	if len(build.Command) == 0 && len(command) > 0 {
+		build.Command = append([]string(nil), command...)
+	}
+	if len(build.Args) == 0 && len(args) > 0 {
+		build.Args = append([]string(nil), args...)
+	}
+}
+
 // setDriverDefaults sets default name, pool and container images for a driver.
 // An error is returned if a default could not be inferred for a field.
 func (d *Defaults) setDriverDefaults(im *imageMap, testSpec *grpcv1.LoadTestSpec) error {
@@ -180,6 +474,7 @@ func (d *Defaults) setDriverDefaults(im *imageMap, testSpec *grpcv1.LoadTestSpec
 	if err := d.setRunOrDefault(im, driver.Language, &driver.Run); err != nil {
 		return errors.Wrap(err, "failed to set defaults on instructions to run the driver")
 	}
+	setResourcesOrDefault(&driver.Run, d.DriverResources)
 
 	return nil
 }
@@ -197,10 +492,13 @@ func (d *Defaults) setClientDefaults(im *imageMap, client *grpcv1.Client) error
 	if err := d.setBuildOrDefault(im, client.Language, client.Build); err != nil {
 		return errors.Wrap(err, "failed to set defaults on instructions to build the client")
 	}
+	setBuildCommandOrDefault(im, client.Language, client.Build)
 
 	if err := d.setRunOrDefault(im, client.Language, &client.Run); err != nil {
 		return errors.Wrap(err, "failed to set defaults on instructions to run the client")
 	}
+	setRunCommandOrDefault(im, client.Language, &client.Run)
+	setResourcesOrDefault(&client.Run, d.WorkerResources)
 
 	return nil
 }
@@ -218,14 +516,25 @@ func (d *Defaults) setServerDefaults(im *imageMap, server *grpcv1.Server) error
 	if err := d.setBuildOrDefault(im, server.Language, server.Build); err != nil {
 		return errors.Wrap(err, "failed to set defaults on instructions to build the server")
 	}
+	setBuildCommandOrDefault(im, server.Language, server.Build)
 
 	if err := d.setRunOrDefault(im, server.Language, &server.Run); err != nil {
 		return errors.Wrap(err, "failed to set defaults on instructions to run the server")
 	}
+	setRunCommandOrDefault(im, server.Language, &server.Run)
+	setResourcesOrDefault(&server.Run, d.WorkerResources)
 
 	return nil
 }
 
+// setResourcesOrDefault sets a copy of the default resources on a run
+// container if it does not specify its own.
+func setResourcesOrDefault(run *grpcv1.Run, defaultResources *corev1.ResourceRequirements) {
+	if run.Resources == nil && defaultResources != nil {
+		run.Resources = defaultResources.DeepCopy()
+	}
+}
+
 // unwrapStrOrUUID returns the string pointer if the pointer is not nil;
 // otherwise, it returns a pointer to a UUID string. This method can be used to
 // assign a unique name to a client, driver or server if one is not already set.
@@ -257,6 +566,26 @@ type LanguageDefault struct {
 	// necessary interpreters or dependencies to run or use the output
 	// of the build image.
 	RunImage string `json:"runImage"`
+
+	// RunCommand specifies the default command of the run container of
+	// clients and servers in this language, such as the path of the worker
+	// binary. It is used when the run instructions have no command.
+	RunCommand []string `json:"runCommand,omitempty"`
+
+	// RunArgs specifies the default arguments of the run container of
+	// clients and servers in this language. They are used when the run
+	// instructions have no arguments and do not take them from a ConfigMap.
+	RunArgs []string `json:"runArgs,omitempty"`
+
+	// BuildCommand specifies the default command of the build container of
+	// clients and servers in this language, such as a bazel invocation. It
+	// is used when the build instructions have no command.
+	BuildCommand []string `json:"buildCommand,omitempty"`
+
+	// BuildArgs specifies the default arguments of the build container of
+	// clients and servers in this language. They are used when the build
+	// instructions have no arguments.
+	BuildArgs []string `json:"buildArgs,omitempty"`
 }
 
 // PoolLabelMap maps a client, driver or server to a string. This string should
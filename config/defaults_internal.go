@@ -60,3 +60,25 @@ func (im *imageMap) runImage(language string) (string, error) {
 
 	return ld.RunImage, nil
 }
+
+// runCommand returns the default command and arguments of the run container
+// for a language. They are nil if the language has no defaults.
+func (im *imageMap) runCommand(language string) ([]string, []string) {
+	ld, ok := im.m[language]
+	if !ok {
+		return nil, nil
+	}
+
+	return ld.RunCommand, ld.RunArgs
+}
+
+// buildCommand returns the default command and arguments of the build
+// container for a language. They are nil if the language has no defaults.
+func (im *imageMap) buildCommand(language string) ([]string, []string) {
+	ld, ok := im.m[language]
+	if !ok {
+		return nil, nil
+	}
+
+	return ld.BuildCommand, ld.BuildArgs
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package config
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 )
@@ -94,12 +99,96 @@ var _ = Describe("Defaults", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("returns an error when the load test label value is unknown", func() {
+			defaults.LoadTestLabelValue = "hash"
+			err := defaults.Validate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns an error when a default pool label is empty", func() {
+			defaults.DefaultPoolLabels.Server = ""
+			err := defaults.Validate()
+			Expect(err).To(MatchError(ContainSubstring("defaultPoolLabels.server")))
+		})
+
+		It("returns an error when default requests exceed limits", func() {
+			defaults.WorkerResources = &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			}
+			err := defaults.Validate()
+			Expect(err).To(MatchError(ContainSubstring("workerResources: resource requests exceed limits")))
+		})
+
+		It("returns an error when a language is defined twice", func() {
+			defaults.Languages[2].Language = "go"
+			err := defaults.Validate()
+			Expect(err).To(MatchError(ContainSubstring(`languages[2].language: language "go" already defined at index 1`)))
+		})
+
+		It("names the field and language of each problem", func() {
+			defaults.DriverImage = ""
+			defaults.Languages[1].RunImage = ""
+			defaults.Languages[2].BuildImage = ""
+			err := defaults.Validate()
+
+			validationErr, ok := err.(*ValidationError)
+			Expect(ok).To(BeTrue())
+			Expect(validationErr.Problems).To(HaveLen(3))
+			Expect(validationErr.Problems[0]).To(HavePrefix("driverImage:"))
+			Expect(validationErr.Problems[1]).To(HavePrefix("languages[1].runImage:"))
+			Expect(validationErr.Problems[1]).To(ContainSubstring(`"go"`))
+			Expect(validationErr.Problems[2]).To(HavePrefix("languages[2].buildImage:"))
+			Expect(validationErr.Problems[2]).To(ContainSubstring(`"java"`))
+			Expect(err.Error()).To(HavePrefix("invalid defaults (3 problems): "))
+		})
+
+		It("returns an error when the clone credentials mount path is relative", func() {
+			defaults.CloneCredentialsMountPath = "secrets/git"
+			err := defaults.Validate()
+			Expect(err).To(MatchError(ContainSubstring("cloneCredentialsMountPath:")))
+		})
+
+		It("returns an error when the scenarios mount path is relative", func() {
+			defaults.ScenariosMountPath = "scenarios"
+			err := defaults.Validate()
+			Expect(err).To(MatchError(ContainSubstring("scenariosMountPath:")))
+		})
+
 		It("returns nil for valid defaults", func() {
 			err := defaults.Validate()
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
 
+	Describe("LoadTestLabelValueFor", func() {
+		var test *grpcv1.LoadTest
+
+		BeforeEach(func() {
+			test = completeLoadTest.DeepCopy()
+			test.Name = "label-value-loadtest"
+			test.UID = "6f1c8f2e-3f8e-4c1a-9f0e-0d6d2b1b7a54"
+		})
+
+		It("returns the name of the test by default", func() {
+			Expect(defaults.LoadTestLabelValueFor(test)).To(Equal(test.Name))
+		})
+
+		It("returns the name of the test when selected", func() {
+			defaults.LoadTestLabelValue = LoadTestLabelValueName
+			Expect(defaults.LoadTestLabelValueFor(test)).To(Equal(test.Name))
+		})
+
+		It("returns the UID of the test when selected", func() {
+			defaults.LoadTestLabelValue = LoadTestLabelValueUID
+			Expect(defaults.LoadTestLabelValueFor(test)).To(Equal(string(test.UID)))
+		})
+	})
+
 	Describe("SetLoadTestDefaults", func() {
 		var loadtest *grpcv1.LoadTest
 		var defaultImageMap *imageMap
@@ -109,6 +198,20 @@ var _ = Describe("Defaults", func() {
 			defaultImageMap = newImageMap(defaults.Languages)
 		})
 
+		It("does not change a test that already has defaults", func() {
+			loadtest.Spec.Driver = nil
+			loadtest.Spec.Servers[0].Name = nil
+			loadtest.Spec.Clients[0].Run.Image = nil
+
+			Expect(defaults.SetLoadTestDefaults(loadtest)).To(Succeed())
+			defaulted := loadtest.DeepCopy()
+
+			// The mutating webhook applies the defaults again when a
+			// test is updated, so they must not change what is stored.
+			Expect(defaults.SetLoadTestDefaults(loadtest)).To(Succeed())
+			Expect(loadtest).To(Equal(defaulted))
+		})
+
 		Context("metadata", func() {
 			It("sets default namespace when unset", func() {
 				loadtest.Namespace = ""
@@ -247,6 +350,21 @@ var _ = Describe("Defaults", func() {
 				Expect(*driver.Run.Image).To(Equal(defaults.DriverImage))
 			})
 
+			It("sets missing resources for run container", func() {
+				defaults.DriverResources = &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("1"),
+					},
+				}
+				driver.Run.Resources = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(driver.Run.Resources).To(Equal(defaults.DriverResources))
+				Expect(driver.Run.Resources).ToNot(BeIdenticalTo(defaults.DriverResources))
+			})
+
 			It("does not error if run container image cannot be inferred but is set", func() {
 				image := "example-image"
 
@@ -482,6 +600,39 @@ var _ = Describe("Defaults", func() {
 				Expect(*client.Run.Image).To(Equal(expectedRunImage))
 			})
 
+			It("sets missing resources for run container", func() {
+				defaults.WorkerResources = &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				}
+				client.Run.Resources = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.Run.Resources).To(Equal(defaults.WorkerResources))
+			})
+
+			It("does not override resources for run container", func() {
+				defaults.WorkerResources = &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				}
+				resources := &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("8"),
+					},
+				}
+				client.Run.Resources = resources
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(client.Run.Resources).To(BeIdenticalTo(resources))
+			})
+
 			It("errors if image for run container cannot be inferred", func() {
 				client.Language = "fortran" // unknown language
 				client.Run.Image = nil      // no explicit image
@@ -502,6 +653,285 @@ var _ = Describe("Defaults", func() {
 				Expect(err).ToNot(HaveOccurred())
 			})
 		})
+
+		Context("run command", func() {
+			BeforeEach(func() {
+				for i := range defaults.Languages {
+					ld := &defaults.Languages[i]
+					switch ld.Language {
+					case "cxx":
+						ld.RunCommand = []string{"bazel-bin/test/cpp/qps/qps_worker"}
+						ld.RunArgs = []string{"--server_port=10010"}
+					case "go":
+						ld.RunCommand = []string{"/executables/go/worker"}
+					}
+				}
+			})
+
+			It("sets the default command and args of the language of clients and servers", func() {
+				client := &loadtest.Spec.Clients[0]
+				client.Language = "cxx"
+				client.Run.Command = nil
+				client.Run.Args = nil
+				server := &loadtest.Spec.Servers[0]
+				server.Language = "go"
+				server.Run.Command = nil
+				server.Run.Args = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.Run.Command).To(Equal([]string{"bazel-bin/test/cpp/qps/qps_worker"}))
+				Expect(client.Run.Args).To(Equal([]string{"--server_port=10010"}))
+				Expect(server.Run.Command).To(Equal([]string{"/executables/go/worker"}))
+				Expect(server.Run.Args).To(BeEmpty())
+			})
+
+			It("does not override an explicit command or args", func() {
+				client := &loadtest.Spec.Clients[0]
+				client.Language = "cxx"
+				client.Run.Command = []string{"custom_worker"}
+				client.Run.Args = []string{"--verbose"}
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.Run.Command).To(Equal([]string{"custom_worker"}))
+				Expect(client.Run.Args).To(Equal([]string{"--verbose"}))
+			})
+
+			It("does not set args when they are taken from a ConfigMap", func() {
+				server := &loadtest.Spec.Servers[0]
+				server.Language = "cxx"
+				server.Run.Args = nil
+				server.Run.ArgsFrom = &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "server-args"},
+					Key:                  "args",
+				}
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(server.Run.Args).To(BeEmpty())
+			})
+
+			It("copies the defaults for each component", func() {
+				client := &loadtest.Spec.Clients[0]
+				client.Language = "cxx"
+				client.Run.Command = nil
+				client.Run.Args = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				client.Run.Args[0] = "--server_port=1"
+				Expect(defaults.Languages[0].RunArgs).To(Equal([]string{"--server_port=10010"}))
+			})
+
+			It("does not set the command or args of the driver", func() {
+				loadtest.Spec.Driver.Language = "cxx"
+				loadtest.Spec.Driver.Run.Command = nil
+				loadtest.Spec.Driver.Run.Args = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loadtest.Spec.Driver.Run.Command).To(BeEmpty())
+				Expect(loadtest.Spec.Driver.Run.Args).To(BeEmpty())
+			})
+		})
+
+		Context("build command", func() {
+			BeforeEach(func() {
+				for i := range defaults.Languages {
+					ld := &defaults.Languages[i]
+					switch ld.Language {
+					case "cxx":
+						ld.BuildCommand = []string{"bazel"}
+						ld.BuildArgs = []string{"build", "//test/cpp/qps:qps_worker"}
+					case "go":
+						ld.BuildCommand = []string{"go"}
+					}
+				}
+			})
+
+			It("sets the default command and args of the language of clients and servers", func() {
+				client := &loadtest.Spec.Clients[0]
+				client.Language = "cxx"
+				client.Build.Command = nil
+				client.Build.Args = nil
+				server := &loadtest.Spec.Servers[0]
+				server.Language = "go"
+				server.Build.Command = nil
+				server.Build.Args = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.Build.Command).To(Equal([]string{"bazel"}))
+				Expect(client.Build.Args).To(Equal([]string{"build", "//test/cpp/qps:qps_worker"}))
+				Expect(server.Build.Command).To(Equal([]string{"go"}))
+				Expect(server.Build.Args).To(BeEmpty())
+			})
+
+			It("does not override an explicit command or args", func() {
+				client := &loadtest.Spec.Clients[0]
+				client.Language = "cxx"
+				client.Build.Command = []string{"make"}
+				client.Build.Args = []string{"worker"}
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(client.Build.Command).To(Equal([]string{"make"}))
+				Expect(client.Build.Args).To(Equal([]string{"worker"}))
+			})
+
+			It("does not add build instructions to components without them", func() {
+				server := &loadtest.Spec.Servers[0]
+				server.Language = "cxx"
+				server.Build = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(server.Build).To(BeNil())
+			})
+
+			It("copies the defaults for each component", func() {
+				client := &loadtest.Spec.Clients[0]
+				client.Language = "cxx"
+				client.Build.Command = nil
+				client.Build.Args = nil
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				client.Build.Args[0] = "test"
+				Expect(defaults.Languages[0].BuildArgs).To(Equal([]string{"build", "//test/cpp/qps:qps_worker"}))
+			})
+
+			It("does not set the command or args of the driver", func() {
+				loadtest.Spec.Driver.Language = "cxx"
+				loadtest.Spec.Driver.Build = &grpcv1.Build{}
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loadtest.Spec.Driver.Build.Command).To(BeEmpty())
+				Expect(loadtest.Spec.Driver.Build.Args).To(BeEmpty())
+			})
+		})
+
+		Context("results", func() {
+			It("does not change a table name without templates", func() {
+				table := "grpc-testing.e2e_benchmark.foobarbuzz"
+				loadtest.Spec.Results.BigQueryTable = &table
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(*loadtest.Spec.Results.BigQueryTable).To(Equal(table))
+			})
+
+			It("renders the date and git ref from annotations", func() {
+				table := "grpc-testing.e2e_benchmark.{{.GitRef}}_{{.Date}}"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.Annotations = map[string]string{
+					DateAnnotation:   "20200901",
+					GitRefAnnotation: "v1_32_0",
+				}
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(*loadtest.Spec.Results.BigQueryTable).To(Equal("grpc-testing.e2e_benchmark.v1_32_0_20200901"))
+			})
+
+			It("renders the git ref from labels when no annotation is set", func() {
+				table := "grpc-testing.e2e_benchmark.{{.GitRef}}"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.Labels = map[string]string{
+					GitRefAnnotation: "master",
+				}
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(*loadtest.Spec.Results.BigQueryTable).To(Equal("grpc-testing.e2e_benchmark.master"))
+			})
+
+			It("renders the date from the creation time when no annotation is set", func() {
+				table := "grpc-testing.e2e_benchmark.results_{{.Date}}"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.CreationTimestamp = metav1.NewTime(time.Date(2020, time.August, 7, 23, 0, 0, 0, time.UTC))
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(*loadtest.Spec.Results.BigQueryTable).To(Equal("grpc-testing.e2e_benchmark.results_20200807"))
+			})
+
+			It("errors when the git ref is used but not set", func() {
+				table := "grpc-testing.e2e_benchmark.{{.GitRef}}"
+				loadtest.Spec.Results.BigQueryTable = &table
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("errors when the template is malformed", func() {
+				table := "grpc-testing.e2e_benchmark.{{.Date"
+				loadtest.Spec.Results.BigQueryTable = &table
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("errors when the template uses an unknown field", func() {
+				table := "grpc-testing.e2e_benchmark.{{.Branch}}"
+				loadtest.Spec.Results.BigQueryTable = &table
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("defaults the partition type to DAY when a partition field is set", func() {
+				table := "grpc-testing.e2e_benchmark.results"
+				field := "start_time"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.Spec.Results.PartitionField = &field
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loadtest.Spec.Results.PartitionType).To(Equal(grpcv1.DayPartition))
+			})
+
+			It("does not change a partition type that is set", func() {
+				table := "grpc-testing.e2e_benchmark.results"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.Spec.Results.PartitionType = grpcv1.HourPartition
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(loadtest.Spec.Results.PartitionField).To(BeNil())
+				Expect(loadtest.Spec.Results.PartitionType).To(Equal(grpcv1.HourPartition))
+			})
+
+			It("errors when partitioning is set without a table", func() {
+				field := "start_time"
+				loadtest.Spec.Results.BigQueryTable = nil
+				loadtest.Spec.Results.PartitionField = &field
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).To(MatchError(ContainSubstring("partitioning requires a BigQuery table")))
+			})
+
+			It("errors when the partition field is not a column name", func() {
+				table := "grpc-testing.e2e_benchmark.results"
+				field := "start-time"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.Spec.Results.PartitionField = &field
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).To(MatchError(ContainSubstring("invalid partition field")))
+			})
+
+			It("errors when the partition type is unknown", func() {
+				table := "grpc-testing.e2e_benchmark.results"
+				loadtest.Spec.Results.BigQueryTable = &table
+				loadtest.Spec.Results.PartitionType = "WEEK"
+
+				err := defaults.SetLoadTestDefaults(loadtest)
+				Expect(err).To(MatchError(ContainSubstring("invalid partition type")))
+			})
+		})
 	})
 })
 
@@ -0,0 +1,261 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// DefaultScenariosSchema is the JSON Schema used to validate scenarios when
+// the defaults do not specify one. It only checks the fields that every
+// driver requires, and the types of a few common fields.
+const DefaultScenariosSchema = `{
+  "type": "object",
+  "required": ["scenarios"],
+  "properties": {
+    "scenarios": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "required": ["name", "client_config", "server_config"],
+        "properties": {
+          "name": {"type": "string"},
+          "client_config": {"type": "object"},
+          "server_config": {"type": "object"},
+          "num_clients": {"type": "integer", "minimum": 0},
+          "num_servers": {"type": "integer", "minimum": 0},
+          "warmup_seconds": {"type": "integer", "minimum": 0},
+          "benchmark_seconds": {"type": "integer", "minimum": 0}
+        }
+      }
+    }
+  }
+}`
+
+// ScenariosError lists every problem found when validating the scenarios of a
+// test against a schema. Each problem names the document and the path of the
+// offending value.
+type ScenariosError struct {
+	// Problems describe each invalid value.
+	Problems []string
+}
+
+// Error returns all problems on a single line.
+func (e *ScenariosError) Error() string {
+	if len(e.Problems) == 1 {
+		return fmt.Sprintf("invalid scenarios: %s", e.Problems[0])
+	}
+	return fmt.Sprintf("invalid scenarios (%d problems): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// jsonSchema is the subset of JSON Schema that is supported for validating
+// scenarios. Keywords that are not listed here are ignored.
+type jsonSchema struct {
+	Type                 string                 `json:"type,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+}
+
+// parseScenariosSchema parses a JSON Schema, returning an error if it is not
+// valid JSON or uses an unknown type.
+func parseScenariosSchema(text string) (*jsonSchema, error) {
+	schema := new(jsonSchema)
+	if err := json.Unmarshal([]byte(text), schema); err != nil {
+		return nil, fmt.Errorf("could not parse schema: %v", err)
+	}
+	if err := schema.check("$"); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// check ensures that the types named in a schema and its subschemas are
+// known.
+func (s *jsonSchema) check(path string) error {
+	switch s.Type {
+	case "", "object", "array", "string", "integer", "number", "boolean", "null":
+	default:
+		return fmt.Errorf("%s: unknown type %q", path, s.Type)
+	}
+	for name, property := range s.Properties {
+		if err := property.check(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.check(path + "[]")
+	}
+	return nil
+}
+
+// validate appends a problem for each part of value that does not match the
+// schema. The path names value in each problem.
+func (s *jsonSchema) validate(path string, value interface{}, problems []string) []string {
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if s.Type != "" && !matchesType(s.Type, value) {
+		addProblem("expected %s, found %s", s.Type, jsonTypeName(value))
+		return problems
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, allowed := range s.Enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addProblem("value %v is not one of %v", value, s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			addProblem("value %v is less than the minimum of %v", v, *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			addProblem("value %v is greater than the maximum of %v", v, *s.Maximum)
+		}
+
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				problems = s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				addProblem("missing required field %q", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					addProblem("unknown field %q", name)
+				}
+				continue
+			}
+			problems = property.validate(path+"."+name, v[name], problems)
+		}
+	}
+
+	return problems
+}
+
+// matchesType returns true if a decoded JSON value has the named type.
+func matchesType(typeName string, value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return typeName == "null"
+	case bool:
+		return typeName == "boolean"
+	case string:
+		return typeName == "string"
+	case float64:
+		return typeName == "number" || (typeName == "integer" && v == math.Trunc(v))
+	case []interface{}:
+		return typeName == "array"
+	case map[string]interface{}:
+		return typeName == "object"
+	}
+	return false
+}
+
+// jsonTypeName returns the name of the type of a decoded JSON value.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// validateScenarios checks ScenariosJSON and each entry of Scenarios against
+// the scenarios schema. If any value does not match, a *ScenariosError listing
+// all problems is returned.
+func (d *Defaults) validateScenarios(test *grpcv1.LoadTest) error {
+	text := d.ScenariosSchema
+	if text == "" {
+		text = DefaultScenariosSchema
+	}
+	schema, err := parseScenariosSchema(text)
+	if err != nil {
+		return err
+	}
+
+	type document struct {
+		path string
+		text string
+	}
+	var documents []document
+	if test.Spec.ScenariosJSON != "" {
+		documents = append(documents, document{"scenariosJSON", test.Spec.ScenariosJSON})
+	}
+	for i, text := range test.Spec.Scenarios {
+		documents = append(documents, document{fmt.Sprintf("scenarios[%d]", i), text})
+	}
+
+	var problems []string
+	for _, doc := range documents {
+		var value interface{}
+		if err := json.Unmarshal([]byte(doc.text), &value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: could not parse JSON: %v", doc.path, err))
+			continue
+		}
+		problems = schema.validate(doc.path, value, problems)
+	}
+
+	if len(problems) > 0 {
+		return &ScenariosError{Problems: problems}
+	}
+	return nil
+}
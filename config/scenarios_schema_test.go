@@ -0,0 +1,150 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const validScenariosJSON = `{
+  "scenarios": [
+    {
+      "name": "cpp_generic_async_streaming_ping_pong_secure",
+      "num_clients": 1,
+      "num_servers": 1,
+      "client_config": {"client_type": "ASYNC_CLIENT"},
+      "server_config": {"server_type": "ASYNC_SERVER"},
+      "warmup_seconds": 5,
+      "benchmark_seconds": 30
+    }
+  ]
+}`
+
+var _ = Describe("Scenarios schema", func() {
+	var defaults *Defaults
+
+	BeforeEach(func() {
+		defaults = &Defaults{ValidateScenarios: true}
+	})
+
+	problemsFor := func(scenariosJSON string, scenarios ...string) []string {
+		test := completeLoadTest.DeepCopy()
+		test.Spec.ScenariosJSON = scenariosJSON
+		test.Spec.Scenarios = scenarios
+		err := defaults.validateScenarios(test)
+		if err == nil {
+			return nil
+		}
+		scenariosErr, ok := err.(*ScenariosError)
+		Expect(ok).To(BeTrue())
+		return scenariosErr.Problems
+	}
+
+	It("accepts valid scenarios", func() {
+		Expect(problemsFor(validScenariosJSON, validScenariosJSON)).To(BeEmpty())
+	})
+
+	It("rejects a document without scenarios", func() {
+		Expect(problemsFor(`{}`)).To(Equal([]string{
+			`scenariosJSON: missing required field "scenarios"`,
+		}))
+	})
+
+	It("rejects scenarios with missing fields", func() {
+		Expect(problemsFor(`{"scenarios": [{"name": "test"}]}`)).To(Equal([]string{
+			`scenariosJSON.scenarios[0]: missing required field "client_config"`,
+			`scenariosJSON.scenarios[0]: missing required field "server_config"`,
+		}))
+	})
+
+	It("rejects fields with the wrong type", func() {
+		Expect(problemsFor(`{"scenarios": [{"name": "test", "client_config": {}, "server_config": [], "num_clients": "1"}]}`)).To(Equal([]string{
+			`scenariosJSON.scenarios[0].num_clients: expected integer, found string`,
+			`scenariosJSON.scenarios[0].server_config: expected object, found array`,
+		}))
+	})
+
+	It("rejects numbers out of range", func() {
+		Expect(problemsFor(`{"scenarios": [{"name": "test", "client_config": {}, "server_config": {}, "warmup_seconds": -1, "num_servers": 1.5}]}`)).To(Equal([]string{
+			`scenariosJSON.scenarios[0].num_servers: expected integer, found number`,
+			`scenariosJSON.scenarios[0].warmup_seconds: value -1 is less than the minimum of 0`,
+		}))
+	})
+
+	It("names the entry of scenarios that is invalid", func() {
+		Expect(problemsFor("", validScenariosJSON, `{"scenarios": "none"}`)).To(Equal([]string{
+			`scenarios[1].scenarios: expected array, found string`,
+		}))
+	})
+
+	It("rejects documents that are not JSON", func() {
+		problems := problemsFor(`{"scenarios": [`)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0]).To(HavePrefix("scenariosJSON: could not parse JSON:"))
+	})
+
+	It("uses a custom schema when set", func() {
+		defaults.ScenariosSchema = `{
+			"type": "object",
+			"properties": {
+				"scenarios": {
+					"type": "array",
+					"items": {
+						"type": "object",
+						"additionalProperties": false,
+						"properties": {
+							"name": {"type": "string", "enum": ["allowed"]}
+						}
+					}
+				}
+			}
+		}`
+		Expect(problemsFor(`{"scenarios": [{"name": "allowed"}]}`)).To(BeEmpty())
+		Expect(problemsFor(`{"scenarios": [{"name": "other", "extra": 1}]}`)).To(Equal([]string{
+			`scenariosJSON.scenarios[0]: unknown field "extra"`,
+			`scenariosJSON.scenarios[0].name: value other is not one of [allowed]`,
+		}))
+	})
+
+	Describe("SetLoadTestDefaults", func() {
+		It("rejects invalid scenarios when validation is enabled", func() {
+			test := completeLoadTest.DeepCopy()
+			test.Spec.ScenariosJSON = `{"scenarios": [{}]}`
+			err := defaults.SetLoadTestDefaults(test)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("invalid scenarios (3 problems): "))
+		})
+
+		It("does not validate scenarios when validation is disabled", func() {
+			defaults.ValidateScenarios = false
+			test := completeLoadTest.DeepCopy()
+			test.Spec.ScenariosJSON = `{"scenarios": [{}]}`
+			Expect(defaults.validateScenarios(test)).ToNot(Succeed())
+			Expect(defaults.SetLoadTestDefaults(test)).To(Succeed())
+		})
+	})
+
+	Describe("Validate", func() {
+		It("reports a schema that cannot be parsed", func() {
+			defaults.ScenariosSchema = `{"type": "object", "properties": {"scenarios": {"type": "list"}}}`
+			err := defaults.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`scenariosSchema: $.scenarios: unknown type "list"`))
+		})
+	})
+})
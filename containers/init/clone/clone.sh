@@ -22,11 +22,31 @@ ls -A | xargs -r rm -fr
 # submodules. This prevents the unnecessary checkout of the master branch.
 # This process is similar to other CI systems, including GitHub actions. See:
 # https://stackoverflow.com/questions/3489173.
+#
+# When $CLONE_DEPTH is set, only the $CLONE_GIT_REF is fetched, with that
+# number of commits of history.
+
+# When $CLONE_CREDENTIALS_DIR is set, it contains a secret with either an SSH
+# key (ssh-privatekey) or a git credentials file (.git-credentials) for
+# cloning a private repository.
 
 git init
+if [ -n "$CLONE_CREDENTIALS_DIR" ]; then
+  if [ -f "$CLONE_CREDENTIALS_DIR/ssh-privatekey" ]; then
+    export GIT_SSH_COMMAND="ssh -i $CLONE_CREDENTIALS_DIR/ssh-privatekey -o StrictHostKeyChecking=accept-new"
+  fi
+  if [ -f "$CLONE_CREDENTIALS_DIR/.git-credentials" ]; then
+    git config credential.helper "store --file=$CLONE_CREDENTIALS_DIR/.git-credentials"
+  fi
+fi
 git remote add origin $CLONE_REPO
-git fetch origin
-git checkout $CLONE_GIT_REF
+if [ -n "$CLONE_DEPTH" ]; then
+  git fetch --depth "$CLONE_DEPTH" origin "$CLONE_GIT_REF"
+  git checkout FETCH_HEAD
+else
+  git fetch origin
+  git checkout $CLONE_GIT_REF
+fi
 git submodule update --init --recursive
 
 # At this point, the files and the directory are read-only when used with a
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+	"github.com/grpc/test-infra/optional"
+	"github.com/grpc/test-infra/status"
+)
+
+// cancelRequested returns true if the cancel annotation of a test is set to a
+// true value. Values that are not booleans are ignored.
+func cancelRequested(test *grpcv1.LoadTest) bool {
+	value, ok := test.Annotations[config.CancelAnnotation]
+	if !ok {
+		return false
+	}
+	cancel, err := strconv.ParseBool(value)
+	return err == nil && cancel
+}
+
+// cancelTest deletes the pods of a test and marks it Cancelled. The test is
+// not deleted, so its status remains available until its time-to-live has
+// passed. Since the Cancelled state is terminal, later reconciles leave the
+// test alone.
+func (r *LoadTestReconciler) cancelTest(ctx context.Context, test *grpcv1.LoadTest, log logr.Logger) error {
+	if err := r.deletePods(ctx, test.Namespace, r.Defaults.LoadTestLabelValueFor(test), log); err != nil {
+		return err
+	}
+
+	test.Status.State = grpcv1.Cancelled
+	test.Status.Reason = grpcv1.CancelRequested
+	test.Status.Message = fmt.Sprintf("load test was cancelled by the %q annotation", config.CancelAnnotation)
+	test.Status.StopTime = optional.CurrentTimePtr()
+	test.Status.Conditions = status.AppendCondition(test.Status.Conditions, grpcv1.Cancelled, grpcv1.CancelRequested)
+	if err := r.Status().Update(ctx, test); err != nil {
+		log.Error(err, "failed to update test status to cancel test")
+		return newControllerError(StatusUpdateFailed, err)
+	}
+	r.recordStatusEvent(test)
+	return nil
+}
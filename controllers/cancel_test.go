@@ -0,0 +1,75 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+)
+
+var _ = Describe("cancelRequested", func() {
+	var test *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		test = newLoadTest()
+	})
+
+	It("returns true when the cancel annotation is true", func() {
+		test.Annotations = map[string]string{config.CancelAnnotation: "true"}
+		Expect(cancelRequested(test)).To(BeTrue())
+	})
+
+	It("returns false without the cancel annotation", func() {
+		test.Annotations = nil
+		Expect(cancelRequested(test)).To(BeFalse())
+	})
+
+	It("returns false when the cancel annotation is false or not a boolean", func() {
+		test.Annotations = map[string]string{config.CancelAnnotation: "false"}
+		Expect(cancelRequested(test)).To(BeFalse())
+		test.Annotations[config.CancelAnnotation] = "yes please"
+		Expect(cancelRequested(test)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Reconcile of a terminated test with the cancel annotation", func() {
+	It("leaves the test alone", func() {
+		test := newLoadTest()
+		test.CreationTimestamp = metav1.Now()
+		test.Annotations = map[string]string{config.CancelAnnotation: "true"}
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Succeeded}
+		fakeClient := &storedTestClient{test: test}
+		reconciler := &LoadTestReconciler{
+			Client: fakeClient,
+			Log:    ctrl.Log.WithName("test"),
+		}
+
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: test.Namespace, Name: test.Name}}
+		Expect(func() {
+			_, err := reconciler.Reconcile(req)
+			Expect(err).ToNot(HaveOccurred())
+		}).ToNot(Panic())
+		Expect(fakeClient.deleted).To(BeEmpty())
+	})
+})
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ControllerErrorReason is a camel-case code that identifies the cause of a
+// ControllerError. There is a fixed set of reasons, so they can be used as
+// labels on metrics.
+type ControllerErrorReason string
+
+const (
+	// UnknownReason is the reason for errors that are not a ControllerError.
+	UnknownReason ControllerErrorReason = "Unknown"
+
+	// TestGetFailed indicates the load test could not be fetched.
+	TestGetFailed ControllerErrorReason = "TestGetFailed"
+
+	// TestUpdateFailed indicates the load test could not be updated with
+	// its defaults.
+	TestUpdateFailed ControllerErrorReason = "TestUpdateFailed"
+
+	// TestDeleteFailed indicates an expired load test could not be deleted.
+	TestDeleteFailed ControllerErrorReason = "TestDeleteFailed"
+
+	// TestListFailed indicates the load tests in a namespace could not be
+	// listed to reserve nodes for preceding tests.
+	TestListFailed ControllerErrorReason = "TestListFailed"
+
+	// StatusUpdateFailed indicates the status of the load test could not be
+	// updated.
+	StatusUpdateFailed ControllerErrorReason = "StatusUpdateFailed"
+
+	// ConfigMapGetFailed indicates the scenarios ConfigMap could not be
+	// fetched for a reason other than its absence.
+	ConfigMapGetFailed ControllerErrorReason = "ConfigMapGetFailed"
+
+	// ReferenceGetFailed indicates a ConfigMap or Secret referenced by a
+	// component could not be fetched for a reason other than its absence,
+	// so its pod could not be built.
+	ReferenceGetFailed ControllerErrorReason = "ReferenceGetFailed"
+
+	// ConfigMapCreateFailed indicates the scenarios ConfigMap could not be
+	// created.
+	ConfigMapCreateFailed ControllerErrorReason = "ConfigMapCreateFailed"
+
+	// ControllerReferenceFailed indicates a controller reference could not
+	// be set on a ConfigMap or pod, which is required for garbage
+	// collection.
+	ControllerReferenceFailed ControllerErrorReason = "ControllerReferenceFailed"
+
+	// PodListFailed indicates pods could not be listed.
+	PodListFailed ControllerErrorReason = "PodListFailed"
+
+	// PodCreateFailed indicates a pod for a component could not be created,
+	// including when its controller reference could not be set.
+	PodCreateFailed ControllerErrorReason = "PodCreateFailed"
+
+	// PodDeleteFailed indicates a pod of a deleted test could not be deleted.
+	PodDeleteFailed ControllerErrorReason = "PodDeleteFailed"
+
+	// PodAdoptFailed indicates a pod of a test that lacked a controller
+	// reference could not be updated to reference the test.
+	PodAdoptFailed ControllerErrorReason = "PodAdoptFailed"
+
+	// NodeListFailed indicates the nodes of the cluster could not be listed.
+	NodeListFailed ControllerErrorReason = "NodeListFailed"
+
+	// CacheSyncFailed indicates the cache could not be synced before
+	// scheduling.
+	CacheSyncFailed ControllerErrorReason = "CacheSyncFailed"
+
+	// ReconcileTimedOut indicates the reconcile did not complete within the
+	// timeout of the reconciler. It takes precedence over the reason of the
+	// step that was interrupted.
+	ReconcileTimedOut ControllerErrorReason = "ReconcileTimedOut"
+)
+
+// ControllerError is an error that prevented the controller from reconciling
+// a load test, which is retried. Unlike problems with the load test itself,
+// which are reported in its status, these errors are returned from Reconcile.
+type ControllerError struct {
+	// Reason identifies the cause of the error.
+	Reason ControllerErrorReason
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the reason and the message of the underlying error.
+func (e *ControllerError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ControllerError) Unwrap() error {
+	return e.Err
+}
+
+// newControllerError wraps an error with a reason. It returns nil if the
+// error is nil.
+func newControllerError(reason ControllerErrorReason, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ControllerError{Reason: reason, Err: err}
+}
+
+// classifyTimeout wraps an error with the ReconcileTimedOut reason if the
+// deadline of the reconcile context has passed. Other errors are returned
+// unchanged.
+func classifyTimeout(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	return newControllerError(ReconcileTimedOut, err)
+}
+
+// ReasonForError returns the reason of a ControllerError in the chain of an
+// error, or UnknownReason if there is none.
+func ReasonForError(err error) ControllerErrorReason {
+	var controllerErr *ControllerError
+	if errors.As(err, &controllerErr) {
+		return controllerErr.Reason
+	}
+	return UnknownReason
+}
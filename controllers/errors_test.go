@@ -0,0 +1,178 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// blockingClient is a client that returns its test, if it has one, and
+// blocks on any other Get until its context is done.
+type blockingClient struct {
+	client.Client
+	test *grpcv1.LoadTest
+}
+
+func (c blockingClient) Get(ctx context.Context, key types.NamespacedName, obj runtime.Object) error {
+	if test, ok := obj.(*grpcv1.LoadTest); ok && c.test != nil {
+		c.test.DeepCopyInto(test)
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// objectRecorder is an event recorder that keeps the objects of the events
+// along with their messages.
+type objectRecorder struct {
+	*record.FakeRecorder
+	objects []runtime.Object
+}
+
+func (r *objectRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	r.objects = append(r.objects, object)
+	r.FakeRecorder.Eventf(object, eventType, reason, messageFmt, args...)
+}
+
+var _ = Describe("ControllerError", func() {
+	It("includes the reason and the underlying error in its message", func() {
+		err := newControllerError(NodeListFailed, errors.New("connection refused"))
+		Expect(err).To(MatchError("NodeListFailed: connection refused"))
+	})
+
+	It("unwraps to the underlying error", func() {
+		err := newControllerError(CacheSyncFailed, errCacheSync)
+		Expect(errors.Is(err, errCacheSync)).To(BeTrue())
+	})
+
+	It("is nil when there is no underlying error", func() {
+		Expect(newControllerError(PodListFailed, nil)).To(BeNil())
+	})
+})
+
+var _ = Describe("ReasonForError", func() {
+	It("returns the reason of a controller error", func() {
+		err := newControllerError(ConfigMapCreateFailed, errors.New("forbidden"))
+		Expect(ReasonForError(err)).To(Equal(ConfigMapCreateFailed))
+	})
+
+	It("returns the reason of a wrapped controller error", func() {
+		err := fmt.Errorf("reconcile failed: %w", newControllerError(PodDeleteFailed, errors.New("timeout")))
+		Expect(ReasonForError(err)).To(Equal(PodDeleteFailed))
+	})
+
+	It("returns the unknown reason for other errors", func() {
+		Expect(ReasonForError(errors.New("unexpected"))).To(Equal(UnknownReason))
+	})
+})
+
+var _ = Describe("classifyTimeout", func() {
+	It("returns the ReconcileTimedOut reason when the deadline has passed", func() {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		err := classifyTimeout(ctx, newControllerError(PodCreateFailed, ctx.Err()))
+		Expect(ReasonForError(err)).To(Equal(ReconcileTimedOut))
+		Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+	})
+
+	It("returns other errors unchanged", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := newControllerError(PodCreateFailed, errors.New("forbidden"))
+		Expect(classifyTimeout(ctx, err)).To(Equal(err))
+		Expect(classifyTimeout(context.Background(), err)).To(Equal(err))
+	})
+
+	It("returns nil when there is no error", func() {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		Expect(classifyTimeout(ctx, nil)).To(BeNil())
+	})
+})
+
+var _ = Describe("Reconcile timeout", func() {
+	var test *grpcv1.LoadTest
+	var recorder *objectRecorder
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.UID = types.UID("slow-uid")
+		recorder = &objectRecorder{FakeRecorder: record.NewFakeRecorder(1)}
+	})
+
+	reconcile := func(c client.Client) error {
+		reconciler := &LoadTestReconciler{
+			Client:              c,
+			Defaults:            newDefaults(),
+			Log:                 ctrl.Log.WithName("test"),
+			Timeout:             10 * time.Millisecond,
+			Recorder:            recorder,
+			DefaultsAtAdmission: true,
+		}
+		_, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: test.Namespace, Name: test.Name}})
+		return err
+	}
+
+	It("classifies a reconcile that times out and records an event on the test", func() {
+		timeouts := reconcileErrors.WithLabelValues(string(ReconcileTimedOut))
+		before := testutil.ToFloat64(timeouts)
+
+		err := reconcile(blockingClient{test: test})
+		Expect(ReasonForError(err)).To(Equal(ReconcileTimedOut))
+		Expect(testutil.ToFloat64(timeouts)).To(Equal(before + 1))
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning ReconcileTimedOut reconcile did not complete within 10ms")))
+		Expect(recorder.objects).To(HaveLen(1))
+		Expect(recorder.objects[0].(*grpcv1.LoadTest).UID).To(Equal(test.UID))
+	})
+
+	It("does not record an event when the test was not fetched", func() {
+		err := reconcile(blockingClient{})
+		Expect(ReasonForError(err)).To(Equal(ReconcileTimedOut))
+		Expect(recorder.objects).To(BeEmpty())
+	})
+})
+
+var _ = Describe("recordReconcileError", func() {
+	It("counts errors by reason", func() {
+		nodeListFailures := reconcileErrors.WithLabelValues(string(NodeListFailed))
+		unknownFailures := reconcileErrors.WithLabelValues(string(UnknownReason))
+		nodeListBefore := testutil.ToFloat64(nodeListFailures)
+		unknownBefore := testutil.ToFloat64(unknownFailures)
+
+		recordReconcileError(newControllerError(NodeListFailed, errors.New("connection refused")))
+		recordReconcileError(newControllerError(NodeListFailed, errors.New("connection refused")))
+		recordReconcileError(errors.New("unexpected"))
+
+		Expect(testutil.ToFloat64(nodeListFailures)).To(Equal(nodeListBefore + 2))
+		Expect(testutil.ToFloat64(unknownFailures)).To(Equal(unknownBefore + 1))
+	})
+})
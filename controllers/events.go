@@ -0,0 +1,98 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// Reasons for events about steps of the reconciler that do not change the
+// status of a test. Events that report a change in status use the reason in
+// the status instead.
+const (
+	// SchedulingDeferred is the reason for an event when a test cannot be
+	// scheduled yet, because its pools do not have enough available nodes.
+	SchedulingDeferred = "SchedulingDeferred"
+
+	// PodsCreated is the reason for an event when the reconciler created
+	// the missing pods of a test.
+	PodsCreated = "PodsCreated"
+
+	// MalformedResults is the reason for an event when the driver of a test
+	// succeeded, but the results in its termination message could not be
+	// parsed. The test still succeeds, but its status has no results.
+	MalformedResults = "MalformedResults"
+
+	// TestExpired is the reason for an event when a terminated test is
+	// deleted, because its time-to-live has passed.
+	TestExpired = "TestExpired"
+)
+
+// recordEvent records an event on a test. It does nothing if the reconciler
+// has no event recorder.
+func (r *LoadTestReconciler) recordEvent(test *grpcv1.LoadTest, eventType, reason, messageFmt string, args ...interface{}) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Eventf(test, eventType, reason, messageFmt, args...)
+}
+
+// recordStatusEvent records an event with the state, reason and message in the
+// status of a test. Errored and timed out tests are recorded as warnings. When the status
+// has no reason, the state is used as the reason. Errored tests are also
+// counted in the errored tests metric.
+func (r *LoadTestReconciler) recordStatusEvent(test *grpcv1.LoadTest) {
+	eventType := corev1.EventTypeNormal
+	if test.Status.State == grpcv1.Errored || test.Status.State == grpcv1.TimedOut {
+		eventType = corev1.EventTypeWarning
+	}
+
+	reason := test.Status.Reason
+	if reason == "" {
+		reason = string(test.Status.State)
+	}
+
+	if test.Status.State == grpcv1.Errored {
+		testsErrored.WithLabelValues(reason).Inc()
+	}
+
+	message := test.Status.Message
+	if message == "" {
+		message = fmt.Sprintf("load test is %s", strings.ToLower(string(test.Status.State)))
+	}
+
+	r.recordEvent(test, eventType, reason, "%s", message)
+}
+
+// recordStateTransition records an event when a test starts running,
+// succeeds, errors or times out, given its status before the reconciler updated it.
+// Other changes are not recorded, since they happen on most reconciles.
+func (r *LoadTestReconciler) recordStateTransition(test *grpcv1.LoadTest, previousStatus grpcv1.LoadTestStatus) {
+	if test.Status.State == previousStatus.State {
+		return
+	}
+
+	switch test.Status.State {
+	case grpcv1.Running, grpcv1.Succeeded, grpcv1.Errored, grpcv1.TimedOut:
+		r.recordStatusEvent(test)
+	}
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("Events", func() {
+	var recorder *record.FakeRecorder
+	var reconciler *LoadTestReconciler
+	var test *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &LoadTestReconciler{Recorder: recorder}
+		test = &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "default",
+			},
+		}
+	})
+
+	Describe("recordStatusEvent", func() {
+		It("records errored tests as warnings with the status reason", func() {
+			test.Status = grpcv1.LoadTestStatus{
+				State:   grpcv1.Errored,
+				Reason:  grpcv1.PoolError,
+				Message: "pool does not exist",
+			}
+			reconciler.recordStatusEvent(test)
+			Expect(recorder.Events).To(Receive(Equal("Warning PoolError pool does not exist")))
+		})
+
+		It("uses the state when the status has no reason or message", func() {
+			test.Status = grpcv1.LoadTestStatus{State: grpcv1.Succeeded}
+			reconciler.recordStatusEvent(test)
+			Expect(recorder.Events).To(Receive(Equal("Normal Succeeded load test is succeeded")))
+		})
+
+		It("does not format the message", func() {
+			test.Status = grpcv1.LoadTestStatus{
+				State:   grpcv1.Errored,
+				Reason:  grpcv1.ConfigurationError,
+				Message: "invalid value 100%",
+			}
+			reconciler.recordStatusEvent(test)
+			Expect(recorder.Events).To(Receive(Equal("Warning ConfigurationError invalid value 100%")))
+		})
+
+		It("does nothing without a recorder", func() {
+			reconciler.Recorder = nil
+			test.Status = grpcv1.LoadTestStatus{State: grpcv1.Errored}
+			Expect(func() { reconciler.recordStatusEvent(test) }).ToNot(Panic())
+		})
+	})
+
+	Describe("recordStateTransition", func() {
+		It("records a test that started running", func() {
+			test.Status = grpcv1.LoadTestStatus{State: grpcv1.Running}
+			reconciler.recordStateTransition(test, grpcv1.LoadTestStatus{
+				State:  grpcv1.Initializing,
+				Reason: grpcv1.PodsMissing,
+			})
+			Expect(recorder.Events).To(Receive(HavePrefix("Normal Running ")))
+		})
+
+		It("records a test that errored", func() {
+			test.Status = grpcv1.LoadTestStatus{
+				State:   grpcv1.Errored,
+				Reason:  grpcv1.ContainerError,
+				Message: "driver exited with code 1",
+			}
+			reconciler.recordStateTransition(test, grpcv1.LoadTestStatus{State: grpcv1.Running})
+			Expect(recorder.Events).To(Receive(Equal("Warning ContainerError driver exited with code 1")))
+		})
+
+		It("records a test that timed out as a warning", func() {
+			test.Status = grpcv1.LoadTestStatus{
+				State:   grpcv1.TimedOut,
+				Reason:  grpcv1.TimeoutExceeded,
+				Message: "test timed out",
+			}
+			reconciler.recordStateTransition(test, grpcv1.LoadTestStatus{State: grpcv1.Running})
+			Expect(recorder.Events).To(Receive(Equal("Warning TimeoutExceeded test timed out")))
+		})
+
+		It("does not record a test that stays in the same state", func() {
+			test.Status = grpcv1.LoadTestStatus{State: grpcv1.Running}
+			reconciler.recordStateTransition(test, grpcv1.LoadTestStatus{State: grpcv1.Running})
+			Expect(recorder.Events).ToNot(Receive())
+		})
+
+		It("does not record transitions to intermediate states", func() {
+			test.Status = grpcv1.LoadTestStatus{
+				State:  grpcv1.Initializing,
+				Reason: grpcv1.PodsMissing,
+			}
+			reconciler.recordStateTransition(test, grpcv1.LoadTestStatus{})
+			Expect(recorder.Events).ToNot(Receive())
+		})
+	})
+})
@@ -28,6 +28,8 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -50,8 +52,46 @@ type LoadTestReconciler struct {
 	Log      logr.Logger
 	Scheme   *runtime.Scheme
 	Timeout  time.Duration
+
+	// Recorder records events for state transitions of load tests, so they
+	// appear when describing a test. If nil, SetupWithManager sets a
+	// recorder from the manager.
+	Recorder record.EventRecorder
+
+	// PodDeletionGracePeriod is the grace period for pods that are deleted
+	// because their test was deleted or retried. When zero, each pod's own termination
+	// grace period is used.
+	PodDeletionGracePeriod time.Duration
+
+	// CapacityRequeueInterval is how long a test waits before it is
+	// reconciled again, when its pods cannot be scheduled because pools lack
+	// available nodes. When zero, DefaultCapacityRequeueInterval is used.
+	CapacityRequeueInterval time.Duration
+
+	// MaxCapacityRequeueInterval enables a backoff for tests that have
+	// waited for nodes for a long time. When set, the interval grows with the
+	// time a test has waited, up to this maximum. When zero, tests are always
+	// requeued at the CapacityRequeueInterval.
+	MaxCapacityRequeueInterval time.Duration
+
+	// DefaultsAtAdmission indicates that the mutating admission webhook sets
+	// the defaults of each test before it is stored. When true, the
+	// reconciler never updates tests with their defaults. It only applies
+	// them to its copy of tests that were stored before the webhook was
+	// enabled. When false, the reconciler sets the defaults and updates each
+	// test that lacks them.
+	DefaultsAtAdmission bool
 }
 
+// DefaultCapacityRequeueInterval is the interval at which tests that wait for
+// nodes are reconciled, unless the reconciler sets another interval.
+const DefaultCapacityRequeueInterval = 5 * time.Second
+
+// capacityRequeueBackoffDivisor divides the time that a test has waited for
+// nodes, to compute its requeue interval when backoff is enabled. For
+// example, a test that has waited for 10 minutes is requeued every minute.
+const capacityRequeueBackoffDivisor = 10
+
 // +kubebuilder:rbac:groups=e2etest.grpc.io,resources=loadtests,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=e2etest.grpc.io,resources=loadtests/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
@@ -59,16 +99,18 @@ type LoadTestReconciler struct {
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=nodes/status,verbs=get
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
 // Reconcile attempts to bring the current state of the load test into agreement
 // with its declared spec. This may mean provisioning resources, doing nothing
-// or handling the termination of its pods.
+// or handling the termination of its pods. Returned errors are counted in the
+// reconcile error metric by their ControllerErrorReason. Errors caused by the
+// reconcile exceeding the Timeout have the ReconcileTimedOut reason, and are
+// also recorded as an event on the test, if it was fetched before the deadline.
 func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	var ctx context.Context
 	var cancel context.CancelFunc
-	var err error
-	log := r.Log.WithValues("loadtest", req.NamespacedName)
-
 	if r.Timeout == 0 {
 		ctx, cancel = context.WithCancel(context.Background())
 	} else {
@@ -76,11 +118,51 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 	defer cancel()
 
-	rawTest := new(grpcv1.LoadTest)
+	test := new(grpcv1.LoadTest)
+	result, err := r.reconcile(ctx, req, test)
+	err = classifyTimeout(ctx, err)
+	if err != nil {
+		recordReconcileError(err)
+	}
+	if ReasonForError(err) == ReconcileTimedOut {
+		r.Log.Error(err, "reconcile timed out", "loadtest", req.NamespacedName, "timeout", r.Timeout)
+		// The event is recorded on the test that reconcile fetched, so it
+		// refers to the test by its UID. When the deadline passed before the
+		// test was fetched, there is no test to record it on.
+		if test.UID != "" {
+			r.recordEvent(test, corev1.EventTypeWarning, string(ReconcileTimedOut), "reconcile did not complete within %v: %v", r.Timeout, err)
+		}
+	}
+	return result, err
+}
+
+// reconcile implements Reconcile, within the deadline of the context. It
+// fetches the test into rawTest.
+func (r *LoadTestReconciler) reconcile(ctx context.Context, req ctrl.Request, rawTest *grpcv1.LoadTest) (ctrl.Result, error) {
+	var err error
+	log := r.Log.WithValues("loadtest", req.NamespacedName)
+
 	if err = r.Get(ctx, req.NamespacedName, rawTest); err != nil {
+		if kerrors.IsNotFound(err) {
+			// The test was deleted. Its pods will eventually be garbage
+			// collected, but a pod that is still cloning or building code
+			// would hold its node until then. So, delete them right away.
+			// Only the name of the test is known at this point, so pods
+			// labeled with its UID are left to the garbage collector.
+			if err = r.deletePods(ctx, req.Namespace, req.Name, log); err != nil {
+				return ctrl.Result{Requeue: true}, err
+			}
+			return ctrl.Result{Requeue: false}, nil
+		}
 		log.Error(err, "failed to get test", "name", req.NamespacedName)
-		err = client.IgnoreNotFound(err)
-		return ctrl.Result{Requeue: err != nil}, err
+		return ctrl.Result{Requeue: true}, newControllerError(TestGetFailed, err)
+	}
+
+	if rawTest.DeletionTimestamp != nil {
+		if err = r.deletePods(ctx, req.Namespace, r.Defaults.LoadTestLabelValueFor(rawTest), log); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		return ctrl.Result{Requeue: false}, nil
 	}
 
 	testTTL := time.Duration(rawTest.Spec.TTLSeconds) * time.Second
@@ -91,17 +173,28 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	if rawTest.Status.State.IsTerminated() {
-		if time.Now().Sub(rawTest.Status.StartTime.Time) >= testTTL {
-			log.Info("test expired, deleting", "startTime", rawTest.Status.StartTime, "testTTL", testTTL)
+		if ttlStart := ttlStartTime(rawTest); time.Now().Sub(ttlStart) >= testTTL {
+			log.Info("test expired, deleting", "startTime", rawTest.Status.StartTime, "ttlStart", ttlStart, "testTTL", testTTL)
 			if err = r.Delete(ctx, rawTest); err != nil {
 				log.Error(err, "fail to delete test")
-				return ctrl.Result{Requeue: true}, err
+				return ctrl.Result{Requeue: true}, newControllerError(TestDeleteFailed, err)
 			}
+			r.recordEvent(rawTest, corev1.EventTypeNormal, TestExpired, "deleted test after its time-to-live of %v", testTTL)
+		}
+		return ctrl.Result{Requeue: false}, nil
+	}
+
+	if cancelRequested(rawTest) {
+		log.Info("cancel annotation is set, cancelling test")
+		if err = r.cancelTest(ctx, rawTest.DeepCopy(), log); err != nil {
+			return ctrl.Result{Requeue: true}, err
 		}
 		return ctrl.Result{Requeue: false}, nil
 	}
 
-	// TODO(codeblooded): Consider moving this to a mutating webhook
+	// With the mutating admission webhook, stored tests already have their
+	// defaults, so this only fills in tests stored before the webhook was
+	// enabled, without writing them back.
 	test := rawTest.DeepCopy()
 	if err = r.Defaults.SetLoadTestDefaults(test); err != nil {
 		log.Error(err, "failed to clone test with defaults")
@@ -111,12 +204,13 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		if err = r.Status().Update(ctx, test); err != nil {
 			log.Error(err, "failed to update test status when setting defaults failed")
 		}
+		r.recordStatusEvent(test)
 		return ctrl.Result{Requeue: false}, nil
 	}
-	if !reflect.DeepEqual(rawTest, test) {
+	if !r.DefaultsAtAdmission && !reflect.DeepEqual(rawTest, test) {
 		if err = r.Update(ctx, test); err != nil {
 			log.Error(err, "failed to update test with defaults")
-			return ctrl.Result{Requeue: true}, err
+			return ctrl.Result{Requeue: true}, newControllerError(TestUpdateFailed, err)
 		}
 	}
 
@@ -134,7 +228,20 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 				log.Error(updateErr, "failed to update status after failure to get scenarios ConfigMap: %v", err)
 			}
-			return ctrl.Result{Requeue: true}, err
+			return ctrl.Result{Requeue: true}, newControllerError(ConfigMapGetFailed, err)
+		}
+
+		scenariosJSON, scenariosErr := scenariosJSONForLoadTest(test)
+		if scenariosErr != nil {
+			log.Error(scenariosErr, "failed to combine scenarios")
+			test.Status.State = grpcv1.Errored
+			test.Status.Reason = grpcv1.ConfigurationError
+			test.Status.Message = fmt.Sprintf("failed to combine scenarios: %v", scenariosErr)
+			if updateErr := r.Status().Update(ctx, test); updateErr != nil {
+				log.Error(updateErr, "failed to update status after failure to combine scenarios")
+			}
+			r.recordStatusEvent(test)
+			return ctrl.Result{Requeue: false}, nil
 		}
 
 		cfgMap = &corev1.ConfigMap{
@@ -143,7 +250,7 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				Namespace: req.Namespace,
 			},
 			Data: map[string]string{
-				"scenarios.json": test.Spec.ScenariosJSON,
+				"scenarios.json": scenariosJSON,
 			},
 
 			// TODO: Enable ConfigMap immutability when it becomes available
@@ -162,24 +269,40 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 				log.Error(updateErr, "failed to update status after failure to get and create scenarios ConfigMap")
 			}
-			return ctrl.Result{Requeue: true}, refError
+			return ctrl.Result{Requeue: true}, newControllerError(ControllerReferenceFailed, refError)
 		}
 
 		if createErr := r.Create(ctx, cfgMap); createErr != nil {
 			log.Error(err, "failed to create scenarios ConfigMap")
-			return ctrl.Result{Requeue: true}, createErr
+			return ctrl.Result{Requeue: true}, newControllerError(ConfigMapCreateFailed, createErr)
 		}
 	}
 
-	pods := new(corev1.PodList)
-	if err = r.List(ctx, pods, client.InNamespace(req.Namespace)); err != nil {
-		log.Error(err, "failed to list pods", "namespace", req.Namespace)
+	testPods, err := r.listPodsForLoadTest(ctx, req.Namespace, r.Defaults.LoadTestLabelValueFor(test))
+	if err != nil {
+		log.Error(err, "failed to list pods for test", "namespace", req.Namespace)
+		return ctrl.Result{Requeue: true}, newControllerError(PodListFailed, err)
+	}
+	ownedPods := status.PodsForLoadTest(test, testPods.Items)
+	if err = r.adoptOrphanedPods(ctx, test, ownedPods, log); err != nil {
 		return ctrl.Result{Requeue: true}, err
 	}
-	ownedPods := status.PodsForLoadTest(test, pods.Items)
+	if test.Status.Retries > 0 {
+		ownedPods = withoutDeletedPods(ownedPods)
+	}
 
 	previousStatus := test.Status
 	test.Status = status.ForLoadTest(test, ownedPods)
+	if test.Status.State == grpcv1.Succeeded {
+		r.setDriverResults(test, ownedPods, log)
+	}
+	if shouldRetry(test) {
+		log.Info("worker failed, retrying test", "retries", test.Status.Retries, "maxRetries", test.Spec.MaxRetries, "reason", test.Status.Reason)
+		if err = r.retryTest(ctx, test, ownedPods, log); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		return ctrl.Result{Requeue: true}, nil
+	}
 	if err = r.Status().Update(ctx, test); err != nil {
 		// Racing conditions arises when multiple threads tried to update the status
 		// of the same object. Since Kubernetes' control loop is edge-triggered and
@@ -195,28 +318,30 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			return ctrl.Result{Requeue: false}, nil
 		}
 		log.Error(err, "failed to update test status")
-		return ctrl.Result{Requeue: true}, err
+		return ctrl.Result{Requeue: true}, newControllerError(StatusUpdateFailed, err)
 	}
+	r.recordStateTransition(test, previousStatus)
 
 	missingPods := status.CheckMissingPods(test, ownedPods)
 	if !missingPods.IsEmpty() {
 		if !r.mgr.GetCache().WaitForCacheSync(ctx.Done()) {
 			log.Error(errCacheSync, "could not invalidate the cache which is required to gang schedule")
-			return ctrl.Result{Requeue: true}, errCacheSync
+			return ctrl.Result{Requeue: true}, newControllerError(CacheSyncFailed, errCacheSync)
 		}
 
 		nodes := new(corev1.NodeList)
 		if err = r.List(ctx, nodes); err != nil {
 			log.Error(err, "failed to list nodes")
-			return ctrl.Result{Requeue: true}, err
+			return ctrl.Result{Requeue: true}, newControllerError(NodeListFailed, err)
 		}
 
 		// since we are attempting to schedule and have invalidated the cache,
-		// we need to reload the pods for any missed changes
-		pods = new(corev1.PodList)
+		// we need to reload the pods for any missed changes; all pods in the
+		// namespace are required to compute the availability of each pool
+		pods := new(corev1.PodList)
 		if err = r.List(ctx, pods, client.InNamespace(req.Namespace)); err != nil {
 			log.Error(err, "failed to list pods", "namespace", req.Namespace)
-			return ctrl.Result{Requeue: true}, err
+			return ctrl.Result{Requeue: true}, newControllerError(PodListFailed, err)
 		}
 
 		// perform one final check to make sure the pods are still missing
@@ -225,87 +350,55 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			goto setRequeueTime
 		}
 
-		var defaultClientPool string
-		var defaultDriverPool string
-		var defaultServerPool string
-		poolCapacities := make(map[string]int)
-		for _, node := range nodes.Items {
-			pool, ok := node.Labels[config.PoolLabel]
-			if !ok {
-				log.Info("encountered a node without a pool label", "nodeName", node.Name)
-				continue
-			}
-
-			if defaultPoolLabels := r.Defaults.DefaultPoolLabels; defaultPoolLabels != nil {
-				if defaultClientPool == "" {
-					if _, ok := node.Labels[defaultPoolLabels.Client]; ok {
-						defaultClientPool = pool
-					}
-				}
-				if defaultDriverPool == "" {
-					if _, ok := node.Labels[defaultPoolLabels.Driver]; ok {
-						defaultDriverPool = pool
-					}
-				}
-				if defaultServerPool == "" {
-					if _, ok := node.Labels[defaultPoolLabels.Server]; ok {
-						defaultServerPool = pool
-					}
-				}
-
-				if _, ok = poolCapacities[pool]; !ok {
-					poolCapacities[pool] = 0
-				}
-			}
-
-			poolCapacities[pool]++
+		clusterInfo := CurrentClusterInfo(nodes.Items, pods.Items, r.Defaults.DefaultPoolLabels, log)
+		recordClusterInfo(clusterInfo)
+		if err = r.reserveNodesForPrecedingTests(ctx, test, pods.Items, clusterInfo, log); err != nil {
+			log.Error(err, "failed to list tests", "namespace", req.Namespace)
+			return ctrl.Result{Requeue: true}, newControllerError(TestListFailed, err)
 		}
 
-		poolAvailabilities := make(map[string]int)
-		for pool, capacity := range poolCapacities {
-			poolAvailabilities[pool] = capacity
+		var canSchedule bool
+		canSchedule, err = clusterInfo.ClusterCanSchedule(missingPods, log)
+		var availabilityErr *InadequateAvailabilityError
+		if errors.As(err, &availabilityErr) {
+			log.Info("cannot schedule test, requeuing", "reason", availabilityErr.Error(), "shortfalls", availabilityErr.Shortfalls, "pools", clusterInfo.Snapshot())
+			r.recordEvent(test, corev1.EventTypeNormal, SchedulingDeferred, "%s", availabilityErr.Error())
+			testsDeferred.Inc()
+			return ctrl.Result{RequeueAfter: r.capacityRequeueTime(test)}, nil
 		}
-		for _, pod := range pods.Items {
-			pool, ok := pod.Labels[config.PoolLabel]
-			if !ok {
-				log.Info("encountered a pod without a pool label", "pod", pod)
-				continue
-			}
-			if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
-				poolAvailabilities[pool]--
+		if err != nil {
+			log.Error(err, "requested pool does not exist and cannot be considered when scheduling")
+			test.Status.State = grpcv1.Errored
+			test.Status.Reason = grpcv1.PoolError
+			test.Status.Message = err.Error()
+			if updateErr := r.Status().Update(ctx, test); updateErr != nil {
+				log.Error(updateErr, "failed to update status after failure due to requesting nodes from a nonexistent pool")
 			}
+			r.recordStatusEvent(test)
+			return ctrl.Result{Requeue: false}, nil
 		}
-
-		adjustAvailabilityForDefaults := func(defaultPoolKey, defaultPoolName string) {
-			if c, ok := missingPods.NodeCountByPool[defaultPoolKey]; ok {
-				missingPods.NodeCountByPool[defaultPoolName] += c
-			}
-			delete(missingPods.NodeCountByPool, defaultPoolKey)
+		if !canSchedule {
+			r.recordEvent(test, corev1.EventTypeNormal, SchedulingDeferred, "not enough nodes are available to schedule the test")
+			testsDeferred.Inc()
+			return ctrl.Result{RequeueAfter: r.capacityRequeueTime(test)}, nil
 		}
-		adjustAvailabilityForDefaults(status.DefaultClientPool, defaultClientPool)
-		adjustAvailabilityForDefaults(status.DefaultDriverPool, defaultDriverPool)
-		adjustAvailabilityForDefaults(status.DefaultServerPool, defaultServerPool)
-
-		for pool, requiredNodeCount := range missingPods.NodeCountByPool {
-			availableNodeCount, ok := poolAvailabilities[pool]
-			if !ok {
-				log.Error(errNonexistentPool, "requested pool does not exist and cannot be considered when scheduling", "requestedPool", pool)
-				test.Status.State = grpcv1.Errored
-				test.Status.Reason = grpcv1.PoolError
-				test.Status.Message = fmt.Sprintf("requested pool %q does not exist", pool)
-				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
-					log.Error(updateErr, "failed to update status after failure due to requesting nodes from a nonexistent pool")
-				}
-				return ctrl.Result{Requeue: false}, nil
-			}
 
-			if requiredNodeCount > availableNodeCount {
-				log.Info("cannot schedule test: inadequate availability for pool", "pool", pool, "requiredNodeCount", requiredNodeCount, "availableNodeCount", availableNodeCount)
-				return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
-			}
-		}
+		defaultClientPool := clusterInfo.DefaultClientPool
+		defaultDriverPool := clusterInfo.DefaultDriverPool
+		defaultServerPool := clusterInfo.DefaultServerPool
 
 		builder := podbuilder.New(r.Defaults, test)
+		builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+			argsCfgMap := new(corev1.ConfigMap)
+			err := r.Get(ctx, types.NamespacedName{Namespace: test.Namespace, Name: name}, argsCfgMap)
+			return argsCfgMap, err
+		})
+		builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+			secret := new(corev1.Secret)
+			err := r.Get(ctx, types.NamespacedName{Namespace: test.Namespace, Name: name}, secret)
+			return secret, err
+		})
+		var createdPods int
 		createPod := func(pod *corev1.Pod) (*ctrl.Result, error) {
 			if err = ctrl.SetControllerReference(test, pod, r.Scheme); err != nil {
 				log.Error(err, "could not set controller reference on pod, pod will not be garbage collected", "pod", pod)
@@ -317,6 +410,7 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				return &ctrl.Result{Requeue: true}, err
 			}
 
+			createdPods++
 			return nil, nil
 		}
 
@@ -326,14 +420,27 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			pod, err := builder.PodForServer(&missingPods.Servers[i])
 			if err != nil {
 				logWithServer.Error(err, "failed to construct a pod struct for supplied server struct")
+				if podbuilder.IsLookupError(err) {
+					test.Status.State = grpcv1.Unknown
+					test.Status.Reason = grpcv1.KubernetesError
+					test.Status.Message = fmt.Sprintf("kubernetes error (retrying): failed to construct a pod for server at index %d: %v", i, err)
+					if updateErr := r.Status().Update(ctx, test); updateErr != nil {
+						logWithServer.Error(updateErr, "failed to update status after failure to look up references for server")
+					}
+					return ctrl.Result{Requeue: true}, newControllerError(ReferenceGetFailed, err)
+				}
 				test.Status.State = grpcv1.Errored
 				test.Status.Reason = grpcv1.ConfigurationError
 				test.Status.Message = fmt.Sprintf("failed to construct a pod for server at index %d: %v", i, err)
 				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 					logWithServer.Error(updateErr, "failed to update status after failure to construct a pod for server")
 				}
+				r.recordStatusEvent(test)
 				return ctrl.Result{Requeue: false}, nil
 			}
+			for _, warning := range builder.Warnings() {
+				logWithServer.Info(warning)
+			}
 
 			if missingPods.Servers[i].Pool == nil {
 				pod.Labels[config.PoolLabel] = defaultServerPool
@@ -350,7 +457,8 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 					logWithServer.Error(updateErr, "failed to update status after failure to create pod for server")
 				}
-				return *result, err
+				r.recordStatusEvent(test)
+				return *result, newControllerError(PodCreateFailed, err)
 			}
 		}
 		for i := range missingPods.Clients {
@@ -359,14 +467,27 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			pod, err := builder.PodForClient(&missingPods.Clients[i])
 			if err != nil {
 				logWithClient.Error(err, "failed to construct a pod struct for supplied client struct")
+				if podbuilder.IsLookupError(err) {
+					test.Status.State = grpcv1.Unknown
+					test.Status.Reason = grpcv1.KubernetesError
+					test.Status.Message = fmt.Sprintf("kubernetes error (retrying): failed to construct a pod for client at index %d: %v", i, err)
+					if updateErr := r.Status().Update(ctx, test); updateErr != nil {
+						logWithClient.Error(updateErr, "failed to update status after failure to look up references for client")
+					}
+					return ctrl.Result{Requeue: true}, newControllerError(ReferenceGetFailed, err)
+				}
 				test.Status.State = grpcv1.Errored
 				test.Status.Reason = grpcv1.ConfigurationError
 				test.Status.Message = fmt.Sprintf("failed to construct a pod for client at index %d: %v", i, err)
 				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 					logWithClient.Error(updateErr, "failed to update status after failure to construct a pod for client")
 				}
+				r.recordStatusEvent(test)
 				return ctrl.Result{Requeue: false}, nil
 			}
+			for _, warning := range builder.Warnings() {
+				logWithClient.Info(warning)
+			}
 
 			if missingPods.Clients[i].Pool == nil {
 				pod.Labels[config.PoolLabel] = defaultClientPool
@@ -383,7 +504,8 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 					logWithClient.Error(updateErr, "failed to update status after failure to create pod for client")
 				}
-				return *result, err
+				r.recordStatusEvent(test)
+				return *result, newControllerError(PodCreateFailed, err)
 			}
 		}
 		if missingPods.Driver != nil {
@@ -392,14 +514,27 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 			pod, err := builder.PodForDriver(missingPods.Driver)
 			if err != nil {
 				logWithDriver.Error(err, "failed to construct a pod struct for supplied driver struct")
+				if podbuilder.IsLookupError(err) {
+					test.Status.State = grpcv1.Unknown
+					test.Status.Reason = grpcv1.KubernetesError
+					test.Status.Message = fmt.Sprintf("kubernetes error (retrying): failed to construct a pod for driver: %v", err)
+					if updateErr := r.Status().Update(ctx, test); updateErr != nil {
+						logWithDriver.Error(updateErr, "failed to update status after failure to look up references for driver")
+					}
+					return ctrl.Result{Requeue: true}, newControllerError(ReferenceGetFailed, err)
+				}
 				test.Status.State = grpcv1.Errored
 				test.Status.Reason = grpcv1.ConfigurationError
 				test.Status.Message = fmt.Sprintf("failed to construct a pod for driver: %v", err)
 				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 					logWithDriver.Error(updateErr, "failed to update status after failure to construct a pod for driver")
 				}
+				r.recordStatusEvent(test)
 				return ctrl.Result{Requeue: false}, nil
 			}
+			for _, warning := range builder.Warnings() {
+				logWithDriver.Info(warning)
+			}
 
 			if missingPods.Driver.Pool == nil {
 				pod.Labels[config.PoolLabel] = defaultDriverPool
@@ -416,9 +551,15 @@ func (r *LoadTestReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 				if updateErr := r.Status().Update(ctx, test); updateErr != nil {
 					logWithDriver.Error(updateErr, "failed to update status after failure to create pod for driver")
 				}
-				return *result, err
+				r.recordStatusEvent(test)
+				return *result, newControllerError(PodCreateFailed, err)
 			}
 		}
+
+		if createdPods > 0 {
+			r.recordEvent(test, corev1.EventTypeNormal, PodsCreated, "created %d pods", createdPods)
+			testsScheduled.Inc()
+		}
 	}
 
 setRequeueTime:
@@ -430,35 +571,228 @@ setRequeueTime:
 	return ctrl.Result{Requeue: false}, nil
 }
 
+// deletePods deletes all pods that belong to a test, given the namespace and
+// the value of the LoadTestLabel for the test. Pods are deleted with the
+// PodDeletionGracePeriod, if one is set. Pods that have already been deleted
+// are ignored.
+func (r *LoadTestReconciler) deletePods(ctx context.Context, namespace, labelValue string, log logr.Logger) error {
+	pods, err := r.listPodsForLoadTest(ctx, namespace, labelValue)
+	if err != nil {
+		log.Error(err, "failed to list pods for test", "namespace", namespace)
+		return newControllerError(PodListFailed, err)
+	}
+
+	opts := r.podDeleteOptions()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		log.Info("deleting pod for test", "pod", pod.Name)
+		if err := r.Delete(ctx, pod, opts...); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "failed to delete pod for test", "pod", pod.Name)
+			return newControllerError(PodDeleteFailed, err)
+		}
+	}
+
+	return nil
+}
+
+// setDriverResults sets the results that the driver of a succeeded test
+// reported in its termination message on the status of the test. Malformed
+// results are logged and recorded as a warning event, but they do not change
+// the state of the test, since the driver itself succeeded.
+func (r *LoadTestReconciler) setDriverResults(test *grpcv1.LoadTest, pods []*corev1.Pod, log logr.Logger) {
+	for _, pod := range pods {
+		if pod.Labels[config.RoleLabel] != config.DriverRole {
+			continue
+		}
+
+		results, err := status.ResultsForPod(pod)
+		if err != nil {
+			log.Info("ignoring malformed driver results", "pod", pod.Name, "error", err.Error())
+			r.recordEvent(test, corev1.EventTypeWarning, MalformedResults, "ignoring malformed driver results: %v", err)
+			return
+		}
+		if results != nil {
+			results.SetOn(&test.Status)
+		}
+		return
+	}
+}
+
+// adoptOrphanedPods sets a controller reference to a test on each of its pods
+// that lack one. Pods are found by their labels, so a pod whose reference
+// could not be set, such as when the controller restarted while creating it,
+// still belongs to the test but would not be garbage collected with it. Pods
+// that are being deleted are ignored.
+func (r *LoadTestReconciler) adoptOrphanedPods(ctx context.Context, test *grpcv1.LoadTest, pods []*corev1.Pod, log logr.Logger) error {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil || metav1.GetControllerOf(pod) != nil {
+			continue
+		}
+
+		log.Info("adopting pod without a controller reference", "pod", pod.Name)
+		if err := ctrl.SetControllerReference(test, pod, r.Scheme); err != nil {
+			log.Error(err, "could not set controller reference on orphaned pod", "pod", pod.Name)
+			return newControllerError(ControllerReferenceFailed, err)
+		}
+		if err := r.Update(ctx, pod); err != nil {
+			log.Error(err, "failed to update orphaned pod with controller reference", "pod", pod.Name)
+			return newControllerError(PodAdoptFailed, err)
+		}
+	}
+
+	return nil
+}
+
+// podDeleteOptions returns the options for deleting the pods of a test, which
+// set the PodDeletionGracePeriod if one is set.
+func (r *LoadTestReconciler) podDeleteOptions() []client.DeleteOption {
+	var opts []client.DeleteOption
+	if r.PodDeletionGracePeriod > 0 {
+		opts = append(opts, client.GracePeriodSeconds(int64(r.PodDeletionGracePeriod.Seconds())))
+	}
+	return opts
+}
+
+// reserveNodesForPrecedingTests holds the nodes that other unfinished tests in
+// the namespace still need, when those tests precede the test being scheduled.
+// This ensures a test only takes nodes that are left after all tests with a
+// higher priority, or with the same priority and an earlier creation time,
+// are scheduled.
+func (r *LoadTestReconciler) reserveNodesForPrecedingTests(ctx context.Context, test *grpcv1.LoadTest, pods []corev1.Pod, clusterInfo *ClusterInfo, log logr.Logger) error {
+	tests := new(grpcv1.LoadTestList)
+	if err := r.List(ctx, tests, client.InNamespace(test.Namespace)); err != nil {
+		return err
+	}
+
+	for i := range tests.Items {
+		other := tests.Items[i].DeepCopy()
+		if other.UID == test.UID || other.DeletionTimestamp != nil || other.Status.State.IsTerminated() || !Precedes(other, test) {
+			continue
+		}
+
+		// The test may not have been reconciled yet, so its component names
+		// and pools may still need to be set.
+		if err := r.Defaults.SetLoadTestDefaults(other); err != nil {
+			log.Info("ignoring preceding test with invalid defaults", "precedingTest", other.Name, "error", err.Error())
+			continue
+		}
+
+		clusterInfo.ReserveNodes(status.CheckMissingPods(other, status.PodsForLoadTest(other, pods)))
+	}
+
+	return nil
+}
+
+// listPodsForLoadTest lists the pods that belong to a test, given the
+// namespace and the value of the LoadTestLabel for the test. Only pods with a
+// matching LoadTestLabel are fetched, which avoids listing every pod in busy
+// namespaces.
+func (r *LoadTestReconciler) listPodsForLoadTest(ctx context.Context, namespace, labelValue string) (*corev1.PodList, error) {
+	pods := new(corev1.PodList)
+	if err := r.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{config.LoadTestLabel: labelValue}); err != nil {
+		return nil, err
+	}
+	return pods, nil
+}
+
+// ttlStartTime returns the time from which the time-to-live of a test is
+// measured. This is the start time of the test. A test may have terminated
+// without a start time, such as when its defaults could not be set, so the
+// stop time and then the creation time of the test are used in its place.
+func ttlStartTime(test *grpcv1.LoadTest) time.Time {
+	if test.Status.StartTime != nil {
+		return test.Status.StartTime.Time
+	}
+	if test.Status.StopTime != nil {
+		return test.Status.StopTime.Time
+	}
+	return test.CreationTimestamp.Time
+}
+
+// minTimeoutRequeueTime is the shortest requeue time for a test that has
+// not terminated, so a test that reaches its timeout during a reconcile is
+// reconciled again shortly after.
+const minTimeoutRequeueTime = time.Second
+
 // getRequeueTime takes a LoadTest and its previous status, compares the
 // previous status of the load test with its updated status, and returns a
-// calculated requeue time. If the test has just been assigned a start time
-// (i.e., it has just started), the requeue time is set to the timeout value
-// specified in the LoadTest. If the test has just been assigned a stop time
+// calculated requeue time. If the test has just been assigned a stop time
 // (i.e., it has just terminated), the requeue time is set to the time-to-live
-// specified in the LoadTest, minus its actual running time. In other cases,
-// the requeue time is set to zero.
+// specified in the LoadTest, minus its actual running time. If the test has
+// started and not terminated, the requeue time is set to the time left until
+// its timeout, so it is marked as timed out even if nothing else triggers a
+// reconcile, such as a test without a driver whose workers never terminate.
+// In other cases, the requeue time is set to zero.
 func getRequeueTime(updatedLoadTest *grpcv1.LoadTest, previousStatus grpcv1.LoadTestStatus, log logr.Logger) time.Duration {
 	requeueTime := time.Duration(0)
 
-	if previousStatus.StartTime == nil && updatedLoadTest.Status.StartTime != nil {
-		requeueTime = time.Duration(updatedLoadTest.Spec.TimeoutSeconds) * time.Second
-		log.Info("just started, should be marked as error if still running at :" + time.Now().Add(requeueTime).String())
+	if previousStatus.StopTime == nil && updatedLoadTest.Status.StopTime != nil {
+		requeueTime = time.Duration(updatedLoadTest.Spec.TTLSeconds)*time.Second - updatedLoadTest.Status.StopTime.Sub(ttlStartTime(updatedLoadTest))
+		log.Info("just end, should be deleted at :" + time.Now().Add(requeueTime).String())
 		return requeueTime
 	}
 
-	if previousStatus.StopTime == nil && updatedLoadTest.Status.StopTime != nil {
-		requeueTime = time.Duration(updatedLoadTest.Spec.TTLSeconds)*time.Second - updatedLoadTest.Status.StopTime.Sub(updatedLoadTest.Status.StartTime.Time)
-		log.Info("just end, should be deleted at :" + time.Now().Add(requeueTime).String())
+	if updatedLoadTest.Status.StartTime != nil && !updatedLoadTest.Status.State.IsTerminated() {
+		timeout := time.Duration(updatedLoadTest.Spec.TimeoutSeconds) * time.Second
+		requeueTime = updatedLoadTest.Status.StartTime.Add(timeout).Sub(time.Now())
+		if requeueTime < minTimeoutRequeueTime {
+			requeueTime = minTimeoutRequeueTime
+		}
+		if previousStatus.StartTime == nil {
+			log.Info("just started, should be marked as timed out if still running at :" + time.Now().Add(requeueTime).String())
+		}
 		return requeueTime
 	}
 
 	return requeueTime
 }
 
+// capacityRequeueTime returns how long a test that waits for nodes should wait
+// before it is reconciled again. With backoff enabled, the interval grows with
+// the time since the test started waiting, which is its start time, up to the
+// MaxCapacityRequeueInterval. The interval never passes the timeout of the
+// test, so tests that wait too long are still marked as timed out promptly.
+func (r *LoadTestReconciler) capacityRequeueTime(test *grpcv1.LoadTest) time.Duration {
+	interval := r.CapacityRequeueInterval
+	if interval <= 0 {
+		interval = DefaultCapacityRequeueInterval
+	}
+
+	startTime := test.Status.StartTime
+	if startTime == nil {
+		return interval
+	}
+
+	if r.MaxCapacityRequeueInterval > interval {
+		if backoff := time.Since(startTime.Time) / capacityRequeueBackoffDivisor; backoff > interval {
+			interval = backoff
+		}
+		if interval > r.MaxCapacityRequeueInterval {
+			interval = r.MaxCapacityRequeueInterval
+		}
+	}
+
+	timeout := time.Duration(test.Spec.TimeoutSeconds) * time.Second
+	if remaining := startTime.Add(timeout).Sub(time.Now()); remaining < interval {
+		interval = remaining
+		if interval < minTimeoutRequeueTime {
+			interval = minTimeoutRequeueTime
+		}
+	}
+
+	return interval
+}
+
 // SetupWithManager configures a controller-runtime manager.
 func (r *LoadTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.mgr = mgr
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("loadtest-controller")
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&grpcv1.LoadTest{}).
 		Owns(&corev1.Pod{}).
@@ -15,6 +15,7 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo"
@@ -22,7 +23,11 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
@@ -77,6 +82,24 @@ func deleteTestPods(test *grpcv1.LoadTest) {
 	}
 }
 
+// storedTestClient is a client whose Get returns a copy of a test and whose
+// Delete records the deleted objects.
+type storedTestClient struct {
+	client.Client
+	test    *grpcv1.LoadTest
+	deleted []runtime.Object
+}
+
+func (c *storedTestClient) Get(ctx context.Context, key types.NamespacedName, obj runtime.Object) error {
+	c.test.DeepCopyInto(obj.(*grpcv1.LoadTest))
+	return nil
+}
+
+func (c *storedTestClient) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	c.deleted = append(c.deleted, obj)
+	return nil
+}
+
 var _ = Describe("LoadTest controller", func() {
 	var test *grpcv1.LoadTest
 	var namespacedName types.NamespacedName
@@ -115,6 +138,27 @@ var _ = Describe("LoadTest controller", func() {
 		Consistently(getTestStatus).Should(Equal(test.Status))
 	})
 
+	It("cancels a test with the cancel annotation", func() {
+		test.Annotations = map[string]string{config.CancelAnnotation: "true"}
+		Expect(k8sClient.Create(context.Background(), test)).To(Succeed())
+
+		getTestState := func() (grpcv1.LoadTestState, error) {
+			fetchedTest := new(grpcv1.LoadTest)
+			err := k8sClient.Get(context.Background(), namespacedName, fetchedTest)
+			return fetchedTest.Status.State, err
+		}
+
+		By("checking that the test is eventually cancelled")
+		Eventually(getTestState).Should(Equal(grpcv1.Cancelled))
+
+		By("checking that no pods are created for the test")
+		Consistently(func() (int, error) {
+			pods := new(corev1.PodList)
+			err := k8sClient.List(context.Background(), pods, client.InNamespace(test.Namespace), client.MatchingLabels{config.LoadTestLabel: test.Name})
+			return len(pods.Items), err
+		}).Should(BeZero())
+	})
+
 	It("creates a scenarios ConfigMap", func() {
 		Expect(k8sClient.Create(context.Background(), test)).To(Succeed())
 
@@ -416,6 +460,100 @@ var _ = Describe("LoadTest controller", func() {
 		deleteTestPods(test)
 	})
 
+	It("deletes pods promptly when a building test is deleted", func() {
+		clusterCfg := &testClusterConfig{
+			pools: []*testPool{
+				{
+					name:     "drivers-3",
+					capacity: 1,
+					labels: map[string]string{
+						defaults.DefaultPoolLabels.Driver: "true",
+					},
+				},
+				{
+					name:     "workers-3",
+					capacity: 2,
+					labels: map[string]string{
+						defaults.DefaultPoolLabels.Client: "true",
+						defaults.DefaultPoolLabels.Server: "true",
+					},
+				},
+			},
+		}
+		cluster, err := createCluster(context.Background(), k8sClient, clusterCfg)
+		Expect(err).ToNot(HaveOccurred())
+		defer deleteCluster(context.Background(), k8sClient, cluster)
+
+		test.Spec.Driver.Pool = &cluster.pools[0].name
+		test.Spec.Clients[0].Pool = &cluster.pools[1].name
+		test.Spec.Servers[0].Pool = &cluster.pools[1].name
+		Expect(k8sClient.Create(context.Background(), test)).To(Succeed())
+
+		countTestPods := func() (int, error) {
+			foundPodCount := 0
+
+			list := new(corev1.PodList)
+			if err := k8sClient.List(context.Background(), list, client.InNamespace(test.Namespace)); err != nil {
+				return 0, err
+			}
+
+			for i := range list.Items {
+				item := &list.Items[i]
+				if item.Labels[config.LoadTestLabel] == test.Name {
+					foundPodCount++
+				}
+			}
+
+			return foundPodCount, nil
+		}
+
+		By("waiting for the pods to be created")
+		Eventually(countTestPods).Should(Equal(3))
+
+		By("deleting the test while its pods are building")
+		Expect(k8sClient.Delete(context.Background(), test)).To(Succeed())
+
+		By("checking that the pods are deleted, freeing the pool nodes")
+		Eventually(countTestPods).Should(Equal(0))
+	})
+
+	It("lists only the pods that belong to a test", func() {
+		otherTest := newLoadTest()
+
+		newLabeledPod := func(name, testName string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: test.Namespace,
+					Labels: map[string]string{
+						config.LoadTestLabel: testName,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "run",
+							Image: "gcr.io/grpc-test-example/go:v1",
+						},
+					},
+				},
+			}
+		}
+
+		ownedPod := newLabeledPod(test.Name+"-owned", test.Name)
+		otherPod := newLabeledPod(otherTest.Name+"-other", otherTest.Name)
+		for _, pod := range []*corev1.Pod{ownedPod, otherPod} {
+			Expect(k8sClient.Create(context.Background(), pod)).To(Succeed())
+			defer k8sClient.Delete(context.Background(), pod)
+		}
+
+		reconciler := &LoadTestReconciler{Client: k8sClient}
+		pods, err := reconciler.listPodsForLoadTest(context.Background(), test.Namespace, test.Name)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pods.Items).To(HaveLen(1))
+		Expect(pods.Items[0].Name).To(Equal(ownedPod.Name))
+	})
+
 	It("updates the test status when client pods terminate with errors", func() {
 		By("creating a fake environment with errored pods")
 		runningState := corev1.ContainerState{
@@ -476,6 +614,70 @@ var _ = Describe("LoadTest controller", func() {
 		deleteTestPods(test)
 	})
 
+	It("retries the test when a client pod terminates with errors", func() {
+		By("creating a fake environment with an errored client pod")
+		runningState := corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{},
+		}
+		errorState := corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1,
+			},
+		}
+		test.Spec.MaxRetries = 1
+		builder := podbuilder.New(newDefaults(), test)
+		testSpec := &test.Spec
+		var clientPod *corev1.Pod
+		for i := range testSpec.Servers {
+			pod, err := builder.PodForServer(&testSpec.Servers[i])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(createPod(pod, test)).To(Succeed())
+			Expect(updatePodWithContainerState(pod, runningState)).To(Succeed())
+		}
+		for i := range testSpec.Clients {
+			pod, err := builder.PodForClient(&testSpec.Clients[i])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(createPod(pod, test)).To(Succeed())
+			Expect(updatePodWithContainerState(pod, errorState)).To(Succeed())
+			clientPod = pod
+		}
+		if testSpec.Driver != nil {
+			pod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(createPod(pod, test)).To(Succeed())
+			Expect(updatePodWithContainerState(pod, runningState)).To(Succeed())
+		}
+
+		By("creating the load test")
+		Expect(k8sClient.Create(context.Background(), test)).To(Succeed())
+
+		By("ensuring the test is retried instead of errored")
+		Eventually(func() (int32, error) {
+			fetchedTest := new(grpcv1.LoadTest)
+			if err := k8sClient.Get(context.Background(), namespacedName, fetchedTest); err != nil {
+				return 0, err
+			}
+			return fetchedTest.Status.Retries, nil
+		}).Should(BeEquivalentTo(1))
+		Consistently(func() (grpcv1.LoadTestState, error) {
+			fetchedTest := new(grpcv1.LoadTest)
+			if err := k8sClient.Get(context.Background(), namespacedName, fetchedTest); err != nil {
+				return grpcv1.Unknown, err
+			}
+			return fetchedTest.Status.State, nil
+		}).ShouldNot(Equal(grpcv1.Errored))
+
+		By("ensuring the errored client pod was deleted")
+		Eventually(func() bool {
+			fetchedPod := new(corev1.Pod)
+			err := k8sClient.Get(context.Background(), types.NamespacedName{Name: clientPod.Name, Namespace: clientPod.Namespace}, fetchedPod)
+			return err != nil || fetchedPod.UID != clientPod.UID || fetchedPod.DeletionTimestamp != nil
+		}).Should(BeTrue())
+
+		// clean-up all pods for hermetic purposes
+		deleteTestPods(test)
+	})
+
 	It("updates the test status when driver pod terminated with errors", func() {
 		By("creating a fake environment with errored pods")
 		runningState := corev1.ContainerState{
@@ -708,3 +910,275 @@ var _ = Describe("LoadTest controller", func() {
 		deleteTestPods(test)
 	})
 })
+
+var _ = Describe("ttlStartTime", func() {
+	var test *grpcv1.LoadTest
+	var created, started, stopped metav1.Time
+
+	BeforeEach(func() {
+		created = metav1.NewTime(time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC))
+		started = metav1.NewTime(created.Add(time.Minute))
+		stopped = metav1.NewTime(created.Add(time.Hour))
+		test = newLoadTest()
+		test.CreationTimestamp = created
+	})
+
+	It("uses the start time when it is set", func() {
+		test.Status.StartTime = &started
+		test.Status.StopTime = &stopped
+		Expect(ttlStartTime(test)).To(Equal(started.Time))
+	})
+
+	It("uses the stop time when there is no start time", func() {
+		test.Status.StopTime = &stopped
+		Expect(ttlStartTime(test)).To(Equal(stopped.Time))
+	})
+
+	It("uses the creation time when there are no start or stop times", func() {
+		Expect(ttlStartTime(test)).To(Equal(created.Time))
+	})
+})
+
+var _ = Describe("Reconcile of a terminated test without a start time", func() {
+	var test *grpcv1.LoadTest
+	var fakeClient *storedTestClient
+	var reconciler *LoadTestReconciler
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.Status = grpcv1.LoadTestStatus{
+			State:  grpcv1.Errored,
+			Reason: grpcv1.FailedSettingDefaultsError,
+		}
+		fakeClient = &storedTestClient{test: test}
+		reconciler = &LoadTestReconciler{
+			Client: fakeClient,
+			Log:    ctrl.Log.WithName("test"),
+		}
+	})
+
+	reconcile := func() {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: test.Namespace, Name: test.Name}}
+		Expect(func() {
+			_, err := reconciler.Reconcile(req)
+			Expect(err).ToNot(HaveOccurred())
+		}).ToNot(Panic())
+	}
+
+	It("deletes the test once its time-to-live has passed since creation", func() {
+		test.CreationTimestamp = metav1.NewTime(time.Now().Add(-2 * time.Duration(test.Spec.TTLSeconds) * time.Second))
+		reconcile()
+		Expect(fakeClient.deleted).To(HaveLen(1))
+	})
+
+	It("keeps the test within its time-to-live", func() {
+		test.CreationTimestamp = metav1.Now()
+		reconcile()
+		Expect(fakeClient.deleted).To(BeEmpty())
+	})
+})
+
+// updatedPodsClient is a client whose Update records the updated objects.
+type updatedPodsClient struct {
+	client.Client
+	updated []runtime.Object
+}
+
+func (c *updatedPodsClient) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	c.updated = append(c.updated, obj)
+	return nil
+}
+
+var _ = Describe("setDriverResults", func() {
+	var test *grpcv1.LoadTest
+	var recorder *record.FakeRecorder
+	var reconciler *LoadTestReconciler
+	var driverPod *corev1.Pod
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Succeeded}
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &LoadTestReconciler{
+			Recorder: recorder,
+			Log:      ctrl.Log.WithName("test"),
+		}
+		driverPod = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "driver",
+				Labels: map[string]string{config.RoleLabel: config.DriverRole},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: config.RunContainerName,
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("sets the results of the driver on the status", func() {
+		driverPod.Status.ContainerStatuses[0].State.Terminated.Message = `{"qps": 100, "latencyP99Ms": 2.5}`
+		reconciler.setDriverResults(test, []*corev1.Pod{driverPod}, reconciler.Log)
+		Expect(*test.Status.QPS).To(Equal(100.0))
+		Expect(test.Status.LatencyP50Ms).To(BeNil())
+		Expect(*test.Status.LatencyP99Ms).To(Equal(2.5))
+	})
+
+	It("records a warning and keeps the test succeeded when the results are malformed", func() {
+		driverPod.Status.ContainerStatuses[0].State.Terminated.Message = "not json"
+		reconciler.setDriverResults(test, []*corev1.Pod{driverPod}, reconciler.Log)
+		Expect(test.Status.State).To(Equal(grpcv1.Succeeded))
+		Expect(test.Status.QPS).To(BeNil())
+		Expect(recorder.Events).To(Receive(HavePrefix("Warning " + MalformedResults)))
+	})
+})
+
+var _ = Describe("adoptOrphanedPods", func() {
+	var test *grpcv1.LoadTest
+	var fakeClient *updatedPodsClient
+	var reconciler *LoadTestReconciler
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.UID = types.UID(uuid.New().String())
+		fakeClient = &updatedPodsClient{}
+		reconciler = &LoadTestReconciler{
+			Client: fakeClient,
+			Scheme: scheme.Scheme,
+			Log:    ctrl.Log.WithName("test"),
+		}
+	})
+
+	It("sets a controller reference on pods that lack one", func() {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "orphan", Namespace: test.Namespace}}
+		Expect(reconciler.adoptOrphanedPods(context.Background(), test, []*corev1.Pod{pod}, reconciler.Log)).To(Succeed())
+		Expect(fakeClient.updated).To(HaveLen(1))
+		owner := metav1.GetControllerOf(pod)
+		Expect(owner).ToNot(BeNil())
+		Expect(owner.UID).To(Equal(test.UID))
+	})
+
+	It("ignores pods that already have a controller reference", func() {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "owned", Namespace: test.Namespace}}
+		Expect(ctrl.SetControllerReference(test, pod, scheme.Scheme)).To(Succeed())
+		Expect(reconciler.adoptOrphanedPods(context.Background(), test, []*corev1.Pod{pod}, reconciler.Log)).To(Succeed())
+		Expect(fakeClient.updated).To(BeEmpty())
+	})
+
+	It("ignores pods that are being deleted", func() {
+		now := metav1.Now()
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "terminating", Namespace: test.Namespace, DeletionTimestamp: &now}}
+		Expect(reconciler.adoptOrphanedPods(context.Background(), test, []*corev1.Pod{pod}, reconciler.Log)).To(Succeed())
+		Expect(fakeClient.updated).To(BeEmpty())
+		Expect(metav1.GetControllerOf(pod)).To(BeNil())
+	})
+})
+
+var _ = Describe("getRequeueTime", func() {
+	var test *grpcv1.LoadTest
+	testLog := ctrl.Log.WithName("test")
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.Spec.Driver = nil
+		test.Spec.TimeoutSeconds = 300
+	})
+
+	It("requeues a test that just started at its timeout", func() {
+		now := metav1.Now()
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Initializing, StartTime: &now}
+
+		requeueTime := getRequeueTime(test, grpcv1.LoadTestStatus{}, testLog)
+		Expect(requeueTime).To(BeNumerically("~", 300*time.Second, time.Second))
+	})
+
+	It("requeues a running test without a driver at its timeout", func() {
+		startTime := metav1.NewTime(time.Now().Add(-200 * time.Second))
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Running, StartTime: &startTime}
+
+		requeueTime := getRequeueTime(test, test.Status, testLog)
+		Expect(requeueTime).To(BeNumerically("~", 100*time.Second, time.Second))
+	})
+
+	It("requeues a test past its timeout shortly", func() {
+		startTime := metav1.NewTime(time.Now().Add(-time.Hour))
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Running, StartTime: &startTime}
+
+		requeueTime := getRequeueTime(test, test.Status, testLog)
+		Expect(requeueTime).To(Equal(minTimeoutRequeueTime))
+	})
+
+	It("requeues a test that just terminated at the end of its time-to-live", func() {
+		startTime := metav1.NewTime(time.Now().Add(-100 * time.Second))
+		stopTime := metav1.Now()
+		test.Spec.TTLSeconds = 600
+		previousStatus := grpcv1.LoadTestStatus{State: grpcv1.Running, StartTime: &startTime}
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.TimedOut, StartTime: &startTime, StopTime: &stopTime}
+
+		requeueTime := getRequeueTime(test, previousStatus, testLog)
+		Expect(requeueTime).To(BeNumerically("~", 500*time.Second, time.Second))
+	})
+
+	It("does not requeue a test that terminated before", func() {
+		startTime := metav1.NewTime(time.Now().Add(-100 * time.Second))
+		stopTime := metav1.Now()
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Succeeded, StartTime: &startTime, StopTime: &stopTime}
+
+		Expect(getRequeueTime(test, test.Status, testLog)).To(BeZero())
+	})
+})
+
+var _ = Describe("capacityRequeueTime", func() {
+	var test *grpcv1.LoadTest
+	var reconciler *LoadTestReconciler
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.Spec.TimeoutSeconds = 3600
+		reconciler = &LoadTestReconciler{}
+	})
+
+	It("uses the default interval when none is set", func() {
+		Expect(reconciler.capacityRequeueTime(test)).To(Equal(DefaultCapacityRequeueInterval))
+	})
+
+	It("uses the configured interval", func() {
+		reconciler.CapacityRequeueInterval = 30 * time.Second
+		startTime := metav1.NewTime(time.Now().Add(-time.Hour / 2))
+		test.Status.StartTime = &startTime
+		Expect(reconciler.capacityRequeueTime(test)).To(Equal(30 * time.Second))
+	})
+
+	It("backs off for tests that have waited a long time", func() {
+		reconciler.MaxCapacityRequeueInterval = 5 * time.Minute
+		startTime := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+		test.Status.StartTime = &startTime
+		Expect(reconciler.capacityRequeueTime(test)).To(BeNumerically("~", time.Minute, time.Second))
+	})
+
+	It("does not back off beyond the maximum interval", func() {
+		reconciler.MaxCapacityRequeueInterval = 2 * time.Minute
+		startTime := metav1.NewTime(time.Now().Add(-50 * time.Minute))
+		test.Status.StartTime = &startTime
+		Expect(reconciler.capacityRequeueTime(test)).To(Equal(2 * time.Minute))
+	})
+
+	It("does not back off for tests that just started waiting", func() {
+		reconciler.MaxCapacityRequeueInterval = 5 * time.Minute
+		startTime := metav1.NewTime(time.Now().Add(-10 * time.Second))
+		test.Status.StartTime = &startTime
+		Expect(reconciler.capacityRequeueTime(test)).To(Equal(DefaultCapacityRequeueInterval))
+	})
+
+	It("does not requeue past the timeout of the test", func() {
+		reconciler.MaxCapacityRequeueInterval = 10 * time.Minute
+		startTime := metav1.NewTime(time.Now().Add(-59 * time.Minute))
+		test.Status.StartTime = &startTime
+		Expect(reconciler.capacityRequeueTime(test)).To(BeNumerically("~", time.Minute, time.Second))
+	})
+})
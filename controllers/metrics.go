@@ -0,0 +1,127 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// reconcileErrors counts the errors returned by Reconcile, labeled by their
+// ControllerErrorReason.
+var reconcileErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "loadtest_reconcile_errors_total",
+		Help: "Total number of errors reconciling load tests, by reason.",
+	},
+	[]string{"reason"},
+)
+
+// poolCapacity reports the number of nodes in each pool, labeled by the name
+// of the pool.
+var poolCapacity = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "loadtest_pool_capacity_nodes",
+		Help: "Number of nodes in each pool, by pool.",
+	},
+	[]string{"pool"},
+)
+
+// poolAvailability reports the number of nodes in each pool that are not
+// running an unfinished pod, labeled by the name of the pool.
+var poolAvailability = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "loadtest_pool_available_nodes",
+		Help: "Number of nodes in each pool that are not running an unfinished pod, by pool.",
+	},
+	[]string{"pool"},
+)
+
+// testsScheduled counts the tests whose missing pods were created.
+var testsScheduled = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "loadtest_scheduled_total",
+		Help: "Total number of times the missing pods of a load test were created.",
+	},
+)
+
+// testsDeferred counts the times that a test could not be scheduled, because
+// its pools did not have enough available nodes.
+var testsDeferred = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "loadtest_scheduling_deferred_total",
+		Help: "Total number of times a load test was not scheduled for lack of available nodes.",
+	},
+)
+
+// testsErrored counts the tests that entered the Errored state, labeled by
+// the reason in their status.
+var testsErrored = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "loadtest_errored_total",
+		Help: "Total number of load tests that errored, by reason.",
+	},
+	[]string{"reason"},
+)
+
+// reportedPools holds the names of the pools that have pool gauges, so the
+// gauges of pools that leave the cluster can be removed.
+var reportedPools = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileErrors,
+		poolCapacity,
+		poolAvailability,
+		testsScheduled,
+		testsDeferred,
+		testsErrored,
+	)
+}
+
+// recordReconcileError increments the error count for the reason of an
+// error.
+func recordReconcileError(err error) {
+	reconcileErrors.WithLabelValues(string(ReasonForError(err))).Inc()
+}
+
+// recordClusterInfo sets the pool gauges to the capacity and availability of
+// each pool in a cluster. Gauges for pools that are no longer in the cluster
+// are removed, so they do not report stale values.
+func recordClusterInfo(info *ClusterInfo) {
+	reportedPools.Lock()
+	defer reportedPools.Unlock()
+
+	for pool := range reportedPools.names {
+		if _, ok := info.Capacity[pool]; !ok {
+			poolCapacity.DeleteLabelValues(pool)
+			poolAvailability.DeleteLabelValues(pool)
+			delete(reportedPools.names, pool)
+		}
+	}
+
+	for pool, snapshot := range info.Snapshot() {
+		poolCapacity.WithLabelValues(pool).Set(float64(snapshot.Capacity))
+		poolAvailability.WithLabelValues(pool).Set(float64(snapshot.Available))
+		reportedPools.names[pool] = true
+	}
+}
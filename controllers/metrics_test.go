@@ -0,0 +1,71 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("recordClusterInfo", func() {
+	It("sets the capacity and availability of each pool", func() {
+		recordClusterInfo(&ClusterInfo{
+			Capacity:     map[string]int{"metrics-clients": 3, "metrics-servers": 2},
+			Availability: map[string]int{"metrics-clients": 1, "metrics-servers": -1},
+		})
+
+		Expect(testutil.ToFloat64(poolCapacity.WithLabelValues("metrics-clients"))).To(Equal(3.0))
+		Expect(testutil.ToFloat64(poolAvailability.WithLabelValues("metrics-clients"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(poolCapacity.WithLabelValues("metrics-servers"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(poolAvailability.WithLabelValues("metrics-servers"))).To(Equal(-1.0))
+	})
+
+	It("removes the gauges of pools that left the cluster", func() {
+		recordClusterInfo(&ClusterInfo{
+			Capacity:     map[string]int{"metrics-clients": 3, "metrics-removed": 2},
+			Availability: map[string]int{"metrics-clients": 3, "metrics-removed": 2},
+		})
+		recordClusterInfo(&ClusterInfo{
+			Capacity:     map[string]int{"metrics-clients": 3},
+			Availability: map[string]int{"metrics-clients": 2},
+		})
+
+		Expect(poolCapacity.DeleteLabelValues("metrics-removed")).To(BeFalse())
+		Expect(poolAvailability.DeleteLabelValues("metrics-removed")).To(BeFalse())
+		Expect(testutil.ToFloat64(poolAvailability.WithLabelValues("metrics-clients"))).To(Equal(2.0))
+	})
+})
+
+var _ = Describe("errored tests metric", func() {
+	It("counts errored tests by reason", func() {
+		reconciler := &LoadTestReconciler{}
+		poolErrors := testsErrored.WithLabelValues(grpcv1.PoolError)
+		before := testutil.ToFloat64(poolErrors)
+
+		test := newLoadTest()
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Errored, Reason: grpcv1.PoolError}
+		reconciler.recordStatusEvent(test)
+		test.Status = grpcv1.LoadTestStatus{State: grpcv1.Succeeded}
+		reconciler.recordStatusEvent(test)
+
+		Expect(testutil.ToFloat64(poolErrors)).To(Equal(before + 1))
+	})
+})
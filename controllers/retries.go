@@ -0,0 +1,89 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/status"
+)
+
+// shouldRetry returns true if a test errored because a server or client
+// failed, and it has retries left. Failures of the driver are not retried,
+// since the driver decides the outcome of the benchmark.
+func shouldRetry(test *grpcv1.LoadTest) bool {
+	status := &test.Status
+	if status.State != grpcv1.Errored || status.FailedDriver > 0 {
+		return false
+	}
+	if status.FailedServers+status.FailedClients == 0 {
+		return false
+	}
+	return status.Retries < test.Spec.MaxRetries
+}
+
+// retryTest deletes the pods of a test that errored because a worker failed,
+// and resets its status so the reconciler creates its pods again. The start
+// time is cleared, so each attempt has the full timeout of the test.
+func (r *LoadTestReconciler) retryTest(ctx context.Context, test *grpcv1.LoadTest, pods []*corev1.Pod, log logr.Logger) error {
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		log.Info("deleting pod to retry test", "pod", pod.Name)
+		if err := r.Delete(ctx, pod, r.podDeleteOptions()...); client.IgnoreNotFound(err) != nil {
+			log.Error(err, "failed to delete pod to retry test", "pod", pod.Name)
+			return newControllerError(PodDeleteFailed, err)
+		}
+	}
+
+	retries := test.Status.Retries + 1
+	test.Status = grpcv1.LoadTestStatus{
+		State:   grpcv1.Initializing,
+		Reason:  grpcv1.WorkerRetried,
+		Message: fmt.Sprintf("restarting load test (retry %d/%d) after a worker failed: %s", retries, test.Spec.MaxRetries, test.Status.Message),
+		Retries: retries,
+
+		Conditions: status.AppendCondition(test.Status.Conditions, grpcv1.Initializing, grpcv1.WorkerRetried),
+	}
+	if err := r.Status().Update(ctx, test); err != nil {
+		log.Error(err, "failed to update test status to retry test")
+		return newControllerError(StatusUpdateFailed, err)
+	}
+	r.recordEvent(test, corev1.EventTypeWarning, grpcv1.WorkerRetried, "%s", test.Status.Message)
+	return nil
+}
+
+// withoutDeletedPods returns the pods that are not being deleted. Pods of an
+// earlier attempt of a retried test may take a while to terminate, and must
+// not decide the status of the current attempt.
+func withoutDeletedPods(pods []*corev1.Pod) []*corev1.Pod {
+	var current []*corev1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp == nil {
+			current = append(current, pod)
+		}
+	}
+	return current
+}
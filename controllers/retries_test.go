@@ -0,0 +1,78 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("shouldRetry", func() {
+	var test *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		test.Spec.MaxRetries = 2
+		test.Status = grpcv1.LoadTestStatus{
+			State:         grpcv1.Errored,
+			Reason:        grpcv1.ContainerError,
+			FailedClients: 1,
+		}
+	})
+
+	It("retries a worker failure within the retry budget", func() {
+		Expect(shouldRetry(test)).To(BeTrue())
+		test.Status.Retries = 1
+		Expect(shouldRetry(test)).To(BeTrue())
+	})
+
+	It("does not retry once the retry budget is exhausted", func() {
+		test.Status.Retries = 2
+		Expect(shouldRetry(test)).To(BeFalse())
+	})
+
+	It("does not retry tests without retries", func() {
+		test.Spec.MaxRetries = 0
+		Expect(shouldRetry(test)).To(BeFalse())
+	})
+
+	It("does not retry a driver failure", func() {
+		test.Status.FailedDriver = 1
+		Expect(shouldRetry(test)).To(BeFalse())
+	})
+
+	It("does not retry tests that did not error", func() {
+		test.Status.State = grpcv1.TimedOut
+		Expect(shouldRetry(test)).To(BeFalse())
+		test.Status.State = grpcv1.Running
+		Expect(shouldRetry(test)).To(BeFalse())
+	})
+})
+
+var _ = Describe("withoutDeletedPods", func() {
+	It("removes pods that are being deleted", func() {
+		now := metav1.Now()
+		current := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "current"}}
+		deleted := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "deleted", DeletionTimestamp: &now}}
+		Expect(withoutDeletedPods([]*corev1.Pod{deleted, current})).To(Equal([]*corev1.Pod{current}))
+	})
+})
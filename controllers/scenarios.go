@@ -0,0 +1,62 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// scenariosMessage mirrors the JSON form of a Scenarios message. The
+// scenarios are kept as raw JSON, since the driver interprets them.
+type scenariosMessage struct {
+	Scenarios []json.RawMessage `json:"scenarios"`
+}
+
+// scenariosJSONForLoadTest returns the contents of the scenarios file that is
+// mounted on the driver. When the test only sets ScenariosJSON, it is returned
+// unchanged. Otherwise, the scenarios in ScenariosJSON and in each entry of
+// Scenarios are combined, in order, into a single Scenarios message. An error
+// is returned if any of these is not a valid Scenarios message.
+func scenariosJSONForLoadTest(test *grpcv1.LoadTest) (string, error) {
+	if len(test.Spec.Scenarios) == 0 {
+		return test.Spec.ScenariosJSON, nil
+	}
+
+	var documents []string
+	if test.Spec.ScenariosJSON != "" {
+		documents = append(documents, test.Spec.ScenariosJSON)
+	}
+	documents = append(documents, test.Spec.Scenarios...)
+
+	combined := scenariosMessage{Scenarios: []json.RawMessage{}}
+	for i, document := range documents {
+		var message scenariosMessage
+		if err := json.Unmarshal([]byte(document), &message); err != nil {
+			return "", fmt.Errorf("could not parse scenarios document %d: %v", i, err)
+		}
+		combined.Scenarios = append(combined.Scenarios, message.Scenarios...)
+	}
+
+	data, err := json.Marshal(combined)
+	if err != nil {
+		return "", fmt.Errorf("could not encode combined scenarios: %v", err)
+	}
+	return string(data), nil
+}
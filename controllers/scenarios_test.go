@@ -0,0 +1,64 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("scenariosJSONForLoadTest", func() {
+	It("returns ScenariosJSON unchanged when there are no other scenarios", func() {
+		test := newLoadTest()
+		test.Spec.ScenariosJSON = `{"scenarios": [{"name": "a"}]}`
+
+		scenariosJSON, err := scenariosJSONForLoadTest(test)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scenariosJSON).To(Equal(test.Spec.ScenariosJSON))
+	})
+
+	It("combines the scenarios of all documents in order", func() {
+		test := newLoadTest()
+		test.Spec.ScenariosJSON = `{"scenarios": [{"name": "a"}]}`
+		test.Spec.Scenarios = []string{
+			`{"scenarios": [{"name": "b"}, {"name": "c"}]}`,
+			`{"scenarios": [{"name": "d"}]}`,
+		}
+
+		scenariosJSON, err := scenariosJSONForLoadTest(test)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scenariosJSON).To(MatchJSON(`{"scenarios": [{"name": "a"}, {"name": "b"}, {"name": "c"}, {"name": "d"}]}`))
+	})
+
+	It("combines the scenarios when ScenariosJSON is unset", func() {
+		test := newLoadTest()
+		test.Spec.ScenariosJSON = ""
+		test.Spec.Scenarios = []string{`{"scenarios": [{"name": "b"}]}`}
+
+		scenariosJSON, err := scenariosJSONForLoadTest(test)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scenariosJSON).To(MatchJSON(`{"scenarios": [{"name": "b"}]}`))
+	})
+
+	It("returns an error when a document is not valid JSON", func() {
+		test := newLoadTest()
+		test.Spec.Scenarios = []string{`{"scenarios": [`}
+
+		_, err := scenariosJSONForLoadTest(test)
+		Expect(err).To(HaveOccurred())
+	})
+})
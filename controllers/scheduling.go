@@ -0,0 +1,269 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+	"github.com/grpc/test-infra/status"
+)
+
+// ClusterInfo is a snapshot of the node pools in a cluster. It records the
+// number of nodes in each pool, the number of those nodes that are not
+// occupied by unfinished pods and the pools that serve as defaults for
+// clients, drivers and servers.
+type ClusterInfo struct {
+	// Capacity maps the name of each pool to the number of nodes it has.
+	Capacity map[string]int
+
+	// Availability maps the name of each pool to the number of its nodes
+	// that are not running a pod which has yet to succeed or fail. This
+	// value may be negative if more pods than nodes are assigned to a pool.
+	Availability map[string]int
+
+	// DefaultClientPool is the name of the pool where clients are scheduled
+	// when they do not specify a pool. It is empty if no node has the
+	// default client pool label.
+	DefaultClientPool string
+
+	// DefaultDriverPool is the name of the pool where drivers are scheduled
+	// when they do not specify a pool. It is empty if no node has the
+	// default driver pool label.
+	DefaultDriverPool string
+
+	// DefaultServerPool is the name of the pool where servers are scheduled
+	// when they do not specify a pool. It is empty if no node has the
+	// default server pool label.
+	DefaultServerPool string
+}
+
+// PoolSnapshot records the number of nodes in a pool and the number of those
+// nodes that are available.
+type PoolSnapshot struct {
+	// Capacity is the number of nodes in the pool.
+	Capacity int
+
+	// Available is the number of nodes in the pool that are not running a
+	// pod which has yet to succeed or fail.
+	Available int
+}
+
+// PoolShortfall records a pool that lacks the available nodes to schedule the
+// missing pods of a test.
+type PoolShortfall struct {
+	// Pool is the name of the pool.
+	Pool string
+
+	// Required is the number of nodes the missing pods require.
+	Required int
+
+	// Available is the number of nodes that are available in the pool.
+	Available int
+}
+
+// Deficit returns the number of additional nodes that must become available
+// before the missing pods can be scheduled.
+func (s PoolShortfall) Deficit() int {
+	return s.Required - s.Available
+}
+
+// InadequateAvailabilityError is returned by ClusterCanSchedule when one or
+// more pools lack the available nodes to schedule a test. The test may be
+// scheduled once pods on these pools have terminated.
+type InadequateAvailabilityError struct {
+	// Shortfalls lists each pool that lacks nodes, sorted by pool name.
+	Shortfalls []PoolShortfall
+}
+
+// Error returns a description of the shortfall in each pool.
+func (e *InadequateAvailabilityError) Error() string {
+	descriptions := make([]string, 0, len(e.Shortfalls))
+	for _, s := range e.Shortfalls {
+		descriptions = append(descriptions, fmt.Sprintf("pool %q needs %d more nodes (requires %d, has %d available)", s.Pool, s.Deficit(), s.Required, s.Available))
+	}
+	return "inadequate availability: " + strings.Join(descriptions, "; ")
+}
+
+// CurrentClusterInfo builds a ClusterInfo from the nodes and pods in a
+// cluster. Nodes are grouped into pools by their pool label, and each pod with
+// a pool label that has not succeeded or failed occupies one node in its pool.
+// Nodes and pods without a pool label are logged and ignored.
+func CurrentClusterInfo(nodes []corev1.Node, pods []corev1.Pod, defaultPoolLabels *config.PoolLabelMap, log logr.Logger) *ClusterInfo {
+	info := &ClusterInfo{
+		Capacity:     make(map[string]int),
+		Availability: make(map[string]int),
+	}
+
+	for _, node := range nodes {
+		pool, ok := node.Labels[config.PoolLabel]
+		if !ok {
+			log.Info("encountered a node without a pool label", "nodeName", node.Name)
+			continue
+		}
+
+		if defaultPoolLabels != nil {
+			if info.DefaultClientPool == "" {
+				if _, ok := node.Labels[defaultPoolLabels.Client]; ok {
+					info.DefaultClientPool = pool
+				}
+			}
+			if info.DefaultDriverPool == "" {
+				if _, ok := node.Labels[defaultPoolLabels.Driver]; ok {
+					info.DefaultDriverPool = pool
+				}
+			}
+			if info.DefaultServerPool == "" {
+				if _, ok := node.Labels[defaultPoolLabels.Server]; ok {
+					info.DefaultServerPool = pool
+				}
+			}
+		}
+
+		info.Capacity[pool]++
+	}
+
+	for pool, capacity := range info.Capacity {
+		info.Availability[pool] = capacity
+	}
+	for _, pod := range pods {
+		pool, ok := pod.Labels[config.PoolLabel]
+		if !ok {
+			log.Info("encountered a pod without a pool label", "pod", pod)
+			continue
+		}
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			info.Availability[pool]--
+		}
+	}
+
+	return info
+}
+
+// PoolNames returns the names of all pools in the cluster, sorted by name.
+func (ci *ClusterInfo) PoolNames() []string {
+	names := make([]string, 0, len(ci.Capacity))
+	for pool := range ci.Capacity {
+		names = append(names, pool)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Snapshot returns the capacity and availability of each pool in the
+// cluster, keyed by the name of the pool. This is meant for logging and
+// debugging scheduling decisions.
+func (ci *ClusterInfo) Snapshot() map[string]PoolSnapshot {
+	snapshot := make(map[string]PoolSnapshot, len(ci.Capacity))
+	for pool, capacity := range ci.Capacity {
+		snapshot[pool] = PoolSnapshot{
+			Capacity:  capacity,
+			Available: ci.Availability[pool],
+		}
+	}
+	return snapshot
+}
+
+// RequiredNodeCountByPool returns the number of nodes required from each pool
+// to schedule the missing pods. Unlike the NodeCountByPool field on the
+// LoadTestMissing struct, the counts for the default pool keys are added to
+// the counts for the pools that serve as defaults. Pools that require no
+// nodes are omitted.
+func (ci *ClusterInfo) RequiredNodeCountByPool(missing *status.LoadTestMissing) map[string]int {
+	required := make(map[string]int)
+
+	for pool, count := range missing.NodeCountByPool {
+		switch pool {
+		case status.DefaultClientPool:
+			pool = ci.DefaultClientPool
+		case status.DefaultDriverPool:
+			pool = ci.DefaultDriverPool
+		case status.DefaultServerPool:
+			pool = ci.DefaultServerPool
+		}
+
+		if count > 0 {
+			required[pool] += count
+		}
+	}
+
+	return required
+}
+
+// ReserveNodes subtracts the nodes required to schedule missing pods from the
+// availability of their pools. This holds nodes for a test that must be
+// scheduled before others. Pools that do not exist are ignored.
+func (ci *ClusterInfo) ReserveNodes(missing *status.LoadTestMissing) {
+	for pool, requiredNodeCount := range ci.RequiredNodeCountByPool(missing) {
+		if _, ok := ci.Availability[pool]; ok {
+			ci.Availability[pool] -= requiredNodeCount
+		}
+	}
+}
+
+// Precedes returns true if test a should be scheduled before test b when they
+// wait for nodes in the same pools. Tests with a higher priority precede tests
+// with a lower priority. Tests with the same priority are ordered by their
+// creation timestamp and then by name.
+func Precedes(a, b *grpcv1.LoadTest) bool {
+	if a.Spec.Priority != b.Spec.Priority {
+		return a.Spec.Priority > b.Spec.Priority
+	}
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	return a.Name < b.Name
+}
+
+// ClusterCanSchedule determines whether the missing pods for a test can be
+// scheduled on the cluster right now. It returns true if every pool has
+// enough available nodes. If the test must wait for nodes to be freed, it
+// returns false and an *InadequateAvailabilityError that lists the shortfall
+// in each pool. An error wrapping errNonexistentPool is returned if a
+// required pool does not exist, since the test can never be scheduled.
+func (ci *ClusterInfo) ClusterCanSchedule(missing *status.LoadTestMissing, log logr.Logger) (bool, error) {
+	var shortfalls []PoolShortfall
+	for pool, requiredNodeCount := range ci.RequiredNodeCountByPool(missing) {
+		availableNodeCount, ok := ci.Availability[pool]
+		if !ok {
+			return false, fmt.Errorf("requested pool %q: %w", pool, errNonexistentPool)
+		}
+
+		if requiredNodeCount > availableNodeCount {
+			shortfalls = append(shortfalls, PoolShortfall{
+				Pool:      pool,
+				Required:  requiredNodeCount,
+				Available: availableNodeCount,
+			})
+		}
+	}
+
+	if len(shortfalls) > 0 {
+		sort.Slice(shortfalls, func(i, j int) bool {
+			return shortfalls[i].Pool < shortfalls[j].Pool
+		})
+		return false, &InadequateAvailabilityError{Shortfalls: shortfalls}
+	}
+
+	return true, nil
+}
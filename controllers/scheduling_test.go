@@ -0,0 +1,267 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/status"
+)
+
+var _ = Describe("CurrentClusterInfo", func() {
+	It("counts capacity and availability by pool", func() {
+		cluster := newSimCluster().
+			addPool(driversPoolName, 2).
+			addPool(workersAPoolName, 3).
+			addPods(workersAPoolName, 2, corev1.PodRunning).
+			addPods(workersAPoolName, 1, corev1.PodSucceeded)
+
+		info := CurrentClusterInfo(cluster.nodes, cluster.pods, nil, logf.NullLogger{})
+		Expect(info.Capacity).To(Equal(map[string]int{
+			driversPoolName:  2,
+			workersAPoolName: 3,
+		}))
+		Expect(info.Availability).To(Equal(map[string]int{
+			driversPoolName:  2,
+			workersAPoolName: 1,
+		}))
+	})
+
+	It("finds the default pools from node labels", func() {
+		defaults := newDefaults()
+		cluster := newSimCluster().
+			addPool(driversPoolName, 1, defaults.DefaultPoolLabels.Driver).
+			addPool(workersAPoolName, 1, defaults.DefaultPoolLabels.Client).
+			addPool(workersBPoolName, 1, defaults.DefaultPoolLabels.Server)
+
+		info := CurrentClusterInfo(cluster.nodes, cluster.pods, defaults.DefaultPoolLabels, logf.NullLogger{})
+		Expect(info.DefaultDriverPool).To(Equal(driversPoolName))
+		Expect(info.DefaultClientPool).To(Equal(workersAPoolName))
+		Expect(info.DefaultServerPool).To(Equal(workersBPoolName))
+	})
+
+	It("lists pool names and a snapshot of each pool", func() {
+		cluster := newSimCluster().
+			addPool(workersAPoolName, 3).
+			addPool(driversPoolName, 2).
+			addPods(workersAPoolName, 2, corev1.PodRunning)
+
+		info := CurrentClusterInfo(cluster.nodes, cluster.pods, nil, logf.NullLogger{})
+		Expect(info.PoolNames()).To(Equal([]string{driversPoolName, workersAPoolName}))
+		Expect(info.Snapshot()).To(Equal(map[string]PoolSnapshot{
+			driversPoolName:  {Capacity: 2, Available: 2},
+			workersAPoolName: {Capacity: 3, Available: 1},
+		}))
+	})
+})
+
+var _ = Describe("ClusterCanSchedule", func() {
+	// useDefaultPools clears the pools on the test's components, so they must
+	// be scheduled on the default pools.
+	useDefaultPools := func(test *grpcv1.LoadTest) {
+		test.Spec.Driver.Pool = nil
+		for i := range test.Spec.Servers {
+			test.Spec.Servers[i].Pool = nil
+		}
+		for i := range test.Spec.Clients {
+			test.Spec.Clients[i].Pool = nil
+		}
+	}
+
+	// addClients adds a number of extra clients in the workers-a pool.
+	addClients := func(n int) func(*grpcv1.LoadTest) {
+		return func(test *grpcv1.LoadTest) {
+			client := test.Spec.Clients[0]
+			for i := 0; i < n; i++ {
+				extra := *client.DeepCopy()
+				name := *client.Name + "-extra-" + string(rune('a'+i))
+				extra.Name = &name
+				test.Spec.Clients = append(test.Spec.Clients, extra)
+			}
+		}
+	}
+
+	defaults := newDefaults()
+	driverLabel := defaults.DefaultPoolLabels.Driver
+	clientLabel := defaults.DefaultPoolLabels.Client
+	serverLabel := defaults.DefaultPoolLabels.Server
+
+	table.DescribeTable("scheduling decisions",
+		func(cluster *simCluster, mutate func(*grpcv1.LoadTest), expected schedulingDecision) {
+			test := newLoadTest()
+			if mutate != nil {
+				mutate(test)
+			}
+
+			decision, err := cluster.schedule(test, defaults)
+			Expect(decision).To(Equal(expected))
+			if expected == reject {
+				Expect(errors.Is(err, errNonexistentPool)).To(BeTrue())
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+		},
+		table.Entry("admits a test when pools have exact capacity",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 2),
+			nil, admit),
+		table.Entry("admits a test when pools have spare capacity",
+			newSimCluster().addPool(driversPoolName, 3).addPool(workersAPoolName, 5),
+			nil, admit),
+		table.Entry("defers a test when a pool is too small",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 1),
+			nil, deferred),
+		table.Entry("defers a test when running pods occupy a pool",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 2).
+				addPods(workersAPoolName, 1, corev1.PodRunning),
+			nil, deferred),
+		table.Entry("defers a test when pending pods occupy a pool",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 2).
+				addPods(driversPoolName, 1, corev1.PodPending),
+			nil, deferred),
+		table.Entry("admits a test when only completed pods occupy a pool",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 2).
+				addPods(workersAPoolName, 1, corev1.PodSucceeded).
+				addPods(workersAPoolName, 1, corev1.PodFailed),
+			nil, admit),
+		table.Entry("defers a test with many clients when a pool is too small",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 4),
+			addClients(3), deferred),
+		table.Entry("admits a test with many clients when a pool is large enough",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 5),
+			addClients(3), admit),
+		table.Entry("rejects a test when a requested pool does not exist",
+			newSimCluster().addPool(workersAPoolName, 2),
+			nil, reject),
+		table.Entry("admits a test on default pools",
+			newSimCluster().
+				addPool(driversPoolName, 1, driverLabel).
+				addPool(workersAPoolName, 2, clientLabel, serverLabel),
+			useDefaultPools, admit),
+		table.Entry("defers a test when default pools are too small",
+			newSimCluster().
+				addPool(driversPoolName, 1, driverLabel).
+				addPool(workersAPoolName, 1, clientLabel, serverLabel),
+			useDefaultPools, deferred),
+		table.Entry("admits a test with separate default client and server pools",
+			newSimCluster().
+				addPool(driversPoolName, 1, driverLabel).
+				addPool(workersAPoolName, 1, clientLabel).
+				addPool(workersBPoolName, 1, serverLabel),
+			useDefaultPools, admit),
+		table.Entry("rejects a test when no node has a default pool label",
+			newSimCluster().addPool(driversPoolName, 1).addPool(workersAPoolName, 2),
+			useDefaultPools, reject),
+	)
+})
+
+var _ = Describe("InadequateAvailabilityError", func() {
+	It("names each pool and its node deficit", func() {
+		cluster := newSimCluster().
+			addPool(driversPoolName, 1).
+			addPods(driversPoolName, 1, corev1.PodRunning).
+			addPool(workersAPoolName, 3).
+			addPods(workersAPoolName, 2, corev1.PodRunning)
+		info := CurrentClusterInfo(cluster.nodes, cluster.pods, nil, logf.NullLogger{})
+
+		canSchedule, err := info.ClusterCanSchedule(status.CheckMissingPods(newLoadTest(), nil), logf.NullLogger{})
+		Expect(canSchedule).To(BeFalse())
+
+		var availabilityErr *InadequateAvailabilityError
+		Expect(errors.As(err, &availabilityErr)).To(BeTrue())
+		Expect(availabilityErr.Shortfalls).To(Equal([]PoolShortfall{
+			{Pool: driversPoolName, Required: 1, Available: 0},
+			{Pool: workersAPoolName, Required: 2, Available: 1},
+		}))
+		Expect(err.Error()).To(ContainSubstring(`pool "drivers" needs 1 more nodes`))
+		Expect(err.Error()).To(ContainSubstring(`pool "workers-a" needs 1 more nodes (requires 2, has 1 available)`))
+	})
+})
+
+var _ = Describe("ReserveNodes", func() {
+	It("holds the nodes required by missing pods", func() {
+		cluster := newSimCluster().addPool(driversPoolName, 2).addPool(workersAPoolName, 4)
+		info := CurrentClusterInfo(cluster.nodes, cluster.pods, nil, logf.NullLogger{})
+
+		test := newLoadTest()
+		info.ReserveNodes(status.CheckMissingPods(test, nil))
+		Expect(info.Availability).To(Equal(map[string]int{
+			driversPoolName:  1,
+			workersAPoolName: 2,
+		}))
+
+		canSchedule, err := info.ClusterCanSchedule(status.CheckMissingPods(newLoadTest(), nil), logf.NullLogger{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(canSchedule).To(BeTrue())
+
+		info.ReserveNodes(status.CheckMissingPods(test, nil))
+		canSchedule, err = info.ClusterCanSchedule(status.CheckMissingPods(newLoadTest(), nil), logf.NullLogger{})
+		Expect(err).To(BeAssignableToTypeOf(&InadequateAvailabilityError{}))
+		Expect(canSchedule).To(BeFalse())
+	})
+
+	It("ignores pools that do not exist", func() {
+		cluster := newSimCluster().addPool(workersAPoolName, 2)
+		info := CurrentClusterInfo(cluster.nodes, cluster.pods, nil, logf.NullLogger{})
+
+		info.ReserveNodes(status.CheckMissingPods(newLoadTest(), nil))
+		Expect(info.Availability).To(Equal(map[string]int{
+			workersAPoolName: 0,
+		}))
+	})
+})
+
+var _ = Describe("Precedes", func() {
+	var a, b *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		created := time.Now()
+		a = newLoadTest()
+		a.Name = "a"
+		a.CreationTimestamp = metav1.NewTime(created)
+		b = newLoadTest()
+		b.Name = "b"
+		b.CreationTimestamp = metav1.NewTime(created)
+	})
+
+	It("orders tests with a higher priority first", func() {
+		b.Spec.Priority = 1
+		b.CreationTimestamp = metav1.NewTime(a.CreationTimestamp.Add(time.Minute))
+		Expect(Precedes(b, a)).To(BeTrue())
+		Expect(Precedes(a, b)).To(BeFalse())
+	})
+
+	It("orders tests with the same priority by creation time", func() {
+		a.CreationTimestamp = metav1.NewTime(b.CreationTimestamp.Add(time.Minute))
+		Expect(Precedes(b, a)).To(BeTrue())
+		Expect(Precedes(a, b)).To(BeFalse())
+	})
+
+	It("orders tests created at the same time by name", func() {
+		Expect(Precedes(a, b)).To(BeTrue())
+		Expect(Precedes(b, a)).To(BeFalse())
+	})
+})
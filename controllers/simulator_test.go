@@ -0,0 +1,120 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+	"github.com/grpc/test-infra/status"
+)
+
+// schedulingDecision is the outcome of attempting to schedule a test.
+type schedulingDecision string
+
+const (
+	// admit indicates that all missing pods for the test can be created.
+	admit schedulingDecision = "admit"
+
+	// deferred indicates that the test must wait for nodes to be freed.
+	deferred schedulingDecision = "defer"
+
+	// reject indicates that the test can never be scheduled, because it
+	// requests nodes from a pool that does not exist.
+	reject schedulingDecision = "error"
+)
+
+// simCluster is an in-memory model of the nodes and pods in a cluster. It
+// allows scheduling decisions to be tested without the envtest API server.
+type simCluster struct {
+	nodes []corev1.Node
+	pods  []corev1.Pod
+}
+
+// newSimCluster creates an empty simulated cluster.
+func newSimCluster() *simCluster {
+	return &simCluster{}
+}
+
+// addPool adds a pool with the given number of nodes. Each node is labeled
+// with the pool name and any extra labels, such as default pool labels.
+func (c *simCluster) addPool(name string, capacity int, extraLabels ...string) *simCluster {
+	for i := 0; i < capacity; i++ {
+		labels := map[string]string{config.PoolLabel: name}
+		for _, label := range extraLabels {
+			labels[label] = "true"
+		}
+
+		c.nodes = append(c.nodes, corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   fmt.Sprintf("node-%s-%d", name, i),
+				Labels: labels,
+			},
+		})
+	}
+	return c
+}
+
+// addPods adds pods in a phase to a pool. These pods belong to a test other
+// than the one being scheduled.
+func (c *simCluster) addPods(pool string, count int, phase corev1.PodPhase) *simCluster {
+	for i := 0; i < count; i++ {
+		c.pods = append(c.pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("pod-%s-%d-%d", pool, len(c.pods), i),
+				Labels: map[string]string{
+					config.PoolLabel:     pool,
+					config.LoadTestLabel: "other-test",
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: phase,
+			},
+		})
+	}
+	return c
+}
+
+// schedule decides whether the missing pods of a test can be scheduled on the
+// simulated cluster, using the same functions as the reconciler. The error is
+// returned alongside a reject decision.
+func (c *simCluster) schedule(test *grpcv1.LoadTest, defaults *config.Defaults) (schedulingDecision, error) {
+	missing := status.CheckMissingPods(test, status.PodsForLoadTest(test, c.pods))
+	if missing.IsEmpty() {
+		return admit, nil
+	}
+
+	info := CurrentClusterInfo(c.nodes, c.pods, defaults.DefaultPoolLabels, logf.NullLogger{})
+	canSchedule, err := info.ClusterCanSchedule(missing, logf.NullLogger{})
+	var availabilityErr *InadequateAvailabilityError
+	if errors.As(err, &availabilityErr) {
+		return deferred, nil
+	}
+	if err != nil {
+		return reject, err
+	}
+	if !canSchedule {
+		return deferred, nil
+	}
+	return admit, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+	"github.com/grpc/test-infra/podbuilder"
+)
+
+// NewLoadTestValidator returns a validator for the admission webhook of
+// LoadTests. It sets the defaults of a copy of each test and constructs its
+// pods, as the reconciler does, so tests that the reconciler would mark with
+// the FailedSettingDefaults or ConfigurationError reason are rejected.
+//
+// ConfigMaps and Secrets referenced by a test are not looked up, since they
+// may be created after the test. This includes those referenced by the values
+// of environment variables.
+func NewLoadTestValidator(defaults *config.Defaults) grpcv1.LoadTestValidator {
+	return func(test *grpcv1.LoadTest) error {
+		test = test.DeepCopy()
+		if err := defaults.SetLoadTestDefaults(test); err != nil {
+			return fmt.Errorf("%s: failed to reconcile tests with defaults: %v", grpcv1.FailedSettingDefaultsError, err)
+		}
+
+		builder := podbuilder.New(defaults, test)
+		builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+			// Optional references to missing ConfigMaps are skipped, so
+			// reporting every ConfigMap as missing leaves them unresolved.
+			return nil, kerrors.NewNotFound(corev1.Resource("configmaps"), name)
+		})
+		builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+			return new(corev1.Secret), nil
+		})
+
+		for i := range test.Spec.Servers {
+			server := &test.Spec.Servers[i]
+			skipReferences(server.Build, &server.Run)
+			if _, err := builder.PodForServer(server); err != nil {
+				return fmt.Errorf("%s: failed to construct a pod for server at index %d: %v", grpcv1.ConfigurationError, i, err)
+			}
+		}
+		for i := range test.Spec.Clients {
+			client := &test.Spec.Clients[i]
+			skipReferences(client.Build, &client.Run)
+			if _, err := builder.PodForClient(client); err != nil {
+				return fmt.Errorf("%s: failed to construct a pod for client at index %d: %v", grpcv1.ConfigurationError, i, err)
+			}
+		}
+		skipReferences(test.Spec.Driver.Build, &test.Spec.Driver.Run)
+		if _, err := builder.PodForDriver(test.Spec.Driver); err != nil {
+			return fmt.Errorf("%s: failed to construct a pod for driver: %v", grpcv1.ConfigurationError, err)
+		}
+
+		return nil
+	}
+}
+
+// skipReferences marks the ArgsFrom reference of a run container and the
+// ConfigMap and Secret references of environment variables as optional, so
+// the references are checked for a name and key but their ConfigMaps and
+// Secrets are not required to exist.
+func skipReferences(build *grpcv1.Build, run *grpcv1.Run) {
+	optional := true
+	if run.ArgsFrom != nil {
+		run.ArgsFrom.Optional = &optional
+	}
+
+	skipEnvReferences(run.Env)
+	for i := range run.Sidecars {
+		skipEnvReferences(run.Sidecars[i].Env)
+	}
+	if build != nil {
+		skipEnvReferences(build.Env)
+	}
+}
+
+// skipEnvReferences marks the ConfigMap and Secret references of environment
+// variables as optional.
+func skipEnvReferences(env []corev1.EnvVar) {
+	optional := true
+	for i := range env {
+		if env[i].ValueFrom == nil {
+			continue
+		}
+		if ref := env[i].ValueFrom.ConfigMapKeyRef; ref != nil {
+			ref.Optional = &optional
+		}
+		if ref := env[i].ValueFrom.SecretKeyRef; ref != nil {
+			ref.Optional = &optional
+		}
+	}
+}
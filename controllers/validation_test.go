@@ -0,0 +1,124 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/optional"
+)
+
+var _ = Describe("NewLoadTestValidator", func() {
+	var test *grpcv1.LoadTest
+	var validate grpcv1.LoadTestValidator
+
+	BeforeEach(func() {
+		test = newLoadTest()
+		validate = NewLoadTestValidator(newDefaults())
+	})
+
+	It("accepts a valid test", func() {
+		Expect(validate(test)).To(Succeed())
+	})
+
+	It("does not modify the test", func() {
+		test.Spec.Clients[0].Name = nil
+		original := test.DeepCopy()
+		Expect(validate(test)).To(Succeed())
+		Expect(test).To(Equal(original))
+	})
+
+	It("rejects an unknown language without images", func() {
+		test.Spec.Servers[0].Language = "cobol"
+		test.Spec.Servers[0].Build.Image = nil
+		err := validate(test)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(grpcv1.FailedSettingDefaultsError))
+	})
+
+	It("rejects a test whose pods cannot be constructed", func() {
+		test.Spec.Clients[0].Run.Sidecars = []corev1.Container{{Name: "run"}}
+		err := validate(test)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix(grpcv1.ConfigurationError))
+	})
+
+	It("does not require ConfigMaps referenced by args to exist", func() {
+		test.Spec.Clients[0].Run.ArgsFrom = &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "client-args"},
+			Key:                  "args",
+		}
+		Expect(validate(test)).To(Succeed())
+		Expect(test.Spec.Clients[0].Run.ArgsFrom.Optional).To(BeNil())
+	})
+
+	It("does not require ConfigMaps and Secrets referenced by env to exist", func() {
+		test.Spec.Servers[0].Run.Env = []corev1.EnvVar{{
+			Name: "API_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "tokens"},
+					Key:                  "api",
+				},
+			},
+		}}
+		Expect(validate(test)).To(Succeed())
+		Expect(test.Spec.Servers[0].Run.Env[0].ValueFrom.SecretKeyRef.Optional).To(BeNil())
+	})
+})
+
+var _ = Describe("LoadTest admission", func() {
+	var test *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		test = newLoadTest()
+	})
+
+	It("accepts a valid test", func() {
+		Expect(test.ValidateCreate()).To(Succeed())
+	})
+
+	It("rejects a test without servers", func() {
+		test.Spec.Servers = nil
+		err := test.ValidateCreate()
+		Expect(err).To(MatchError(ContainSubstring(grpcv1.ConfigurationError)))
+		Expect(err).To(MatchError(ContainSubstring("spec.servers")))
+	})
+
+	It("rejects a test without clients", func() {
+		test.Spec.Clients = nil
+		Expect(test.ValidateCreate()).To(MatchError(ContainSubstring("spec.clients")))
+	})
+
+	It("rejects clients colocated on the nodes of servers in another pool", func() {
+		test.Spec.Affinity = &grpcv1.Affinity{Colocation: grpcv1.NodeColocation}
+		test.Spec.Clients[0].Pool = optional.StringPtr("workers-b")
+		Expect(test.ValidateCreate()).To(MatchError(ContainSubstring("spec.clients[0].pool")))
+
+		test.Spec.Affinity.Colocation = grpcv1.ZoneColocation
+		Expect(test.ValidateCreate()).To(Succeed())
+	})
+
+	It("allows updates of existing tests", func() {
+		test.Spec.Servers = nil
+		Expect(test.ValidateUpdate(newLoadTest())).To(Succeed())
+	})
+})
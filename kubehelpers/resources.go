@@ -0,0 +1,33 @@
+package kubehelpers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// CheckRequestsWithinLimits accepts a pointer to resource requirements. It
+// returns an error that names every resource with a request that exceeds its
+// limit. Resources without a limit are not checked. If the pointer is nil or
+// all requests are within their limits, it returns nil.
+func CheckRequestsWithinLimits(resources *corev1.ResourceRequirements) error {
+	if resources == nil {
+		return nil
+	}
+
+	var exceeded []string
+	for name, request := range resources.Requests {
+		limit, ok := resources.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			exceeded = append(exceeded, fmt.Sprintf("%s request %s exceeds limit %s", name, request.String(), limit.String()))
+		}
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+
+	sort.Strings(exceeded)
+	return fmt.Errorf("resource requests exceed limits: %s", strings.Join(exceeded, ", "))
+}
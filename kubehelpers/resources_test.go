@@ -0,0 +1,52 @@
+package kubehelpers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("CheckRequestsWithinLimits", func() {
+	It("returns nil for nil requirements", func() {
+		Expect(CheckRequestsWithinLimits(nil)).To(Succeed())
+	})
+
+	It("returns nil when requests are within limits", func() {
+		resources := &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		}
+		Expect(CheckRequestsWithinLimits(resources)).To(Succeed())
+	})
+
+	It("ignores requests without limits", func() {
+		resources := &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("8"),
+			},
+		}
+		Expect(CheckRequestsWithinLimits(resources)).To(Succeed())
+	})
+
+	It("names each request that exceeds its limit", func() {
+		resources := &corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			},
+		}
+		err := CheckRequestsWithinLimits(resources)
+		Expect(err).To(MatchError("resource requests exceed limits: cpu request 2 exceeds limit 1, memory request 2Gi exceeds limit 1Gi"))
+	})
+})
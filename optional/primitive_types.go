@@ -16,6 +16,10 @@ limitations under the License.
 
 package optional
 
+import (
+	"time"
+)
+
 // StringPtr accepts a string and returns a pointer to it.
 func StringPtr(str string) *string {
 	return &str
@@ -25,3 +29,77 @@ func StringPtr(str string) *string {
 func Int32Ptr(n int32) *int32 {
 	return &n
 }
+
+// Int64Ptr accepts a 64-bit integer and returns a pointer to it.
+func Int64Ptr(n int64) *int64 {
+	return &n
+}
+
+// IntPtr accepts an integer and returns a pointer to it.
+func IntPtr(n int) *int {
+	return &n
+}
+
+// BoolPtr accepts a boolean and returns a pointer to it.
+func BoolPtr(b bool) *bool {
+	return &b
+}
+
+// DurationPtr accepts a duration and returns a pointer to it.
+func DurationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+// StringVal returns the string that a pointer references, or the default if
+// the pointer is nil.
+func StringVal(p *string, defaultStr string) string {
+	if p == nil {
+		return defaultStr
+	}
+	return *p
+}
+
+// Int32Val returns the 32-bit integer that a pointer references, or the
+// default if the pointer is nil.
+func Int32Val(p *int32, defaultN int32) int32 {
+	if p == nil {
+		return defaultN
+	}
+	return *p
+}
+
+// Int64Val returns the 64-bit integer that a pointer references, or the
+// default if the pointer is nil.
+func Int64Val(p *int64, defaultN int64) int64 {
+	if p == nil {
+		return defaultN
+	}
+	return *p
+}
+
+// IntVal returns the integer that a pointer references, or the default if the
+// pointer is nil.
+func IntVal(p *int, defaultN int) int {
+	if p == nil {
+		return defaultN
+	}
+	return *p
+}
+
+// BoolVal returns the boolean that a pointer references, or the default if
+// the pointer is nil.
+func BoolVal(p *bool, defaultB bool) bool {
+	if p == nil {
+		return defaultB
+	}
+	return *p
+}
+
+// DurationVal returns the duration that a pointer references, or the default
+// if the pointer is nil.
+func DurationVal(p *time.Duration, defaultD time.Duration) time.Duration {
+	if p == nil {
+		return defaultD
+	}
+	return *p
+}
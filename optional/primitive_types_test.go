@@ -0,0 +1,63 @@
+/*
+Copyright 2020 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package optional
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pointer functions", func() {
+	It("return pointers to copies of their arguments", func() {
+		n := 42
+		p := IntPtr(n)
+		n++
+		Expect(*p).To(Equal(42))
+
+		Expect(*StringPtr("pool")).To(Equal("pool"))
+		Expect(*Int32Ptr(32)).To(Equal(int32(32)))
+		Expect(*Int64Ptr(64)).To(Equal(int64(64)))
+		Expect(*BoolPtr(true)).To(BeTrue())
+		Expect(*DurationPtr(5 * time.Second)).To(Equal(5 * time.Second))
+	})
+})
+
+var _ = Describe("value functions", func() {
+	Context("with nil pointers", func() {
+		It("return the defaults", func() {
+			Expect(StringVal(nil, "default")).To(Equal("default"))
+			Expect(Int32Val(nil, 3)).To(Equal(int32(3)))
+			Expect(Int64Val(nil, 4)).To(Equal(int64(4)))
+			Expect(IntVal(nil, 5)).To(Equal(5))
+			Expect(BoolVal(nil, true)).To(BeTrue())
+			Expect(DurationVal(nil, time.Minute)).To(Equal(time.Minute))
+		})
+	})
+
+	Context("with non-nil pointers", func() {
+		It("return the referenced values", func() {
+			Expect(StringVal(StringPtr("pool"), "default")).To(Equal("pool"))
+			Expect(Int32Val(Int32Ptr(0), 3)).To(Equal(int32(0)))
+			Expect(Int64Val(Int64Ptr(0), 4)).To(Equal(int64(0)))
+			Expect(IntVal(IntPtr(0), 5)).To(Equal(0))
+			Expect(BoolVal(BoolPtr(false), true)).To(BeFalse())
+			Expect(DurationVal(DurationPtr(0), time.Minute)).To(Equal(time.Duration(0)))
+		})
+	})
+})
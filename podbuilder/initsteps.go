@@ -0,0 +1,184 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package podbuilder
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+)
+
+// Component describes the driver, server or client that a pod is built for.
+// It holds the fields that init steps use, regardless of the role.
+type Component struct {
+	// Name is the name of the component.
+	Name string
+
+	// Role is the role of the component, such as config.ClientRole.
+	Role string
+
+	// Pool is the pool of the component. It is empty if the component uses
+	// the default pool.
+	Pool string
+
+	// Clone holds the clone instructions of the component, if any.
+	Clone *grpcv1.Clone
+
+	// Build holds the build instructions of the component, if any.
+	Build *grpcv1.Build
+
+	// Run holds the run instructions of the component.
+	Run *grpcv1.Run
+}
+
+// InitStep contributes an init container to the pod of a component. Init
+// containers run in the order of their steps, before the run container.
+type InitStep interface {
+	// InitContainer returns the init container of the step for a component,
+	// and any volumes that the container needs beyond the workspace and
+	// Bazel cache volumes. It returns a nil container if the component has
+	// nothing to do in this step.
+	InitContainer(defaults *config.Defaults, component *Component) (*corev1.Container, []corev1.Volume)
+}
+
+// DefaultInitSteps returns the init steps that a PodBuilder uses unless
+// others are set: the clone step, followed by the build step.
+func DefaultInitSteps() []InitStep {
+	return []InitStep{CloneStep{}, BuildStep{}}
+}
+
+// CloneStep is an init step that clones a git repository into the workspace,
+// for components with clone instructions.
+type CloneStep struct{}
+
+// InitContainer returns the clone init container. If the clone instructions
+// reference a secret with credentials, the secret is returned as a volume.
+func (CloneStep) InitContainer(defaults *config.Defaults, component *Component) (*corev1.Container, []corev1.Volume) {
+	clone := component.Clone
+	if clone == nil {
+		return nil, nil
+	}
+
+	var env []corev1.EnvVar
+
+	if clone.Repo != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  config.CloneRepoEnv,
+			Value: safeStrUnwrap(clone.Repo),
+		})
+	}
+
+	if clone.GitRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  config.CloneGitRefEnv,
+			Value: safeStrUnwrap(clone.GitRef),
+		})
+	}
+
+	if clone.Depth != nil {
+		env = append(env, corev1.EnvVar{
+			Name:  config.CloneDepthEnv,
+			Value: strconv.Itoa(int(*clone.Depth)),
+		})
+	}
+
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      config.WorkspaceVolumeName,
+			MountPath: config.WorkspaceMountPath,
+			ReadOnly:  false,
+		},
+	}
+
+	var volumes []corev1.Volume
+	if clone.CredentialsSecretName != nil {
+		mountPath := cloneCredentialsMountPath(defaults)
+		env = append(env, corev1.EnvVar{
+			Name:  config.CloneCredentialsDirEnv,
+			Value: mountPath,
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      config.CloneCredentialsVolumeName,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+
+		defaultMode := int32(0400)
+		volumes = append(volumes, corev1.Volume{
+			Name: config.CloneCredentialsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName:  *clone.CredentialsSecretName,
+					DefaultMode: &defaultMode,
+				},
+			},
+		})
+	}
+
+	return &corev1.Container{
+		Name:         config.CloneInitContainerName,
+		Image:        safeStrUnwrap(clone.Image),
+		Env:          env,
+		VolumeMounts: volumeMounts,
+	}, volumes
+}
+
+// BuildStep is an init step that builds the code in the workspace, for
+// components with build instructions.
+type BuildStep struct{}
+
+// InitContainer returns the build init container.
+func (BuildStep) InitContainer(defaults *config.Defaults, component *Component) (*corev1.Container, []corev1.Volume) {
+	build := component.Build
+	if build == nil {
+		return nil, nil
+	}
+
+	return &corev1.Container{
+		Name:       config.BuildInitContainerName,
+		Image:      safeStrUnwrap(build.Image),
+		Command:    build.Command,
+		Args:       build.Args,
+		Env:        build.Env,
+		Resources:  safeResourcesUnwrap(build.Resources),
+		WorkingDir: workingDir(build.WorkingDir),
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      config.WorkspaceVolumeName,
+				MountPath: config.WorkspaceMountPath,
+				ReadOnly:  false,
+			},
+			{
+				Name:      config.BazelCacheVolumeName,
+				MountPath: config.BazelCacheMountPath,
+				ReadOnly:  false,
+			},
+		},
+	}, nil
+}
+
+// cloneCredentialsMountPath returns the path where the clone credentials are
+// mounted, from the defaults or the CloneCredentialsMountPath constant.
+func cloneCredentialsMountPath(defaults *config.Defaults) string {
+	if defaults != nil && defaults.CloneCredentialsMountPath != "" {
+		return defaults.CloneCredentialsMountPath
+	}
+	return config.CloneCredentialsMountPath
+}
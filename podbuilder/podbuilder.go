@@ -18,9 +18,16 @@ package podbuilder
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
@@ -28,10 +35,86 @@ import (
 	"github.com/grpc/test-infra/kubehelpers"
 )
 
+// ErrLookupFailed is the base error when a PodBuilder cannot fetch a
+// ConfigMap or Secret referenced by a test for a reason other than its
+// absence, such as a temporary failure of the Kubernetes API. Unlike the other
+// errors of a PodBuilder, it does not mean the test is misconfigured, so
+// building the pod should be retried.
+var ErrLookupFailed = errors.New("failed to look up referenced object")
+
+// IsLookupError returns true if an error was caused by a failure to fetch a
+// ConfigMap or Secret, rather than by the configuration of the test.
+func IsLookupError(err error) bool {
+	return errors.Cause(err) == ErrLookupFailed
+}
+
 // errNoPool is the base error when a PodBuilder cannot determine the pool for
 // a pod.
 var errNoPool = errors.New("pool is missing")
 
+// errArgsFrom is the base error when a PodBuilder cannot resolve the arguments
+// referenced by a run container's ArgsFrom field.
+var errArgsFrom = errors.New("could not resolve args from ConfigMap")
+
+// errEnvFrom is the base error when a PodBuilder cannot resolve the ConfigMap
+// or Secret key referenced by the value of an environment variable.
+var errEnvFrom = errors.New("could not resolve env from ConfigMap or Secret")
+
+// errAudience is the base error when the audience for the results token is
+// not valid.
+var errAudience = errors.New("invalid audience for results token")
+
+// errGCSBucket is the base error when the Cloud Storage bucket or prefix for
+// result artifacts is not valid.
+var errGCSBucket = errors.New("invalid GCS bucket for results")
+
+// resultsTokenExpirationSeconds is the requested lifetime of the projected
+// service account token for uploading results. The kubelet refreshes the
+// token before it expires.
+const resultsTokenExpirationSeconds = 3600
+
+// errResources is the base error when the resource requests of a container
+// exceed its limits.
+var errResources = errors.New("invalid resource requirements")
+
+// errPortArg is the base error when the args of a run container set a port
+// that does not match, or conflicts with, a port declared on the container.
+var errPortArg = errors.New("port in args does not match the declared port")
+
+// errCloneDepth is the base error when the depth of a clone is not positive.
+var errCloneDepth = errors.New("invalid clone depth")
+
+// errCloneCredentials is the base error when the secret with credentials for
+// a clone cannot be found.
+var errCloneCredentials = errors.New("invalid clone credentials")
+
+// errDNS is the base error when the DNS settings of a component would be
+// rejected by Kubernetes.
+var errDNS = errors.New("invalid DNS settings")
+
+// errSidecar is the base error when a sidecar container has no name, or its
+// name is taken by another container in the pod.
+var errSidecar = errors.New("invalid sidecar")
+
+// errNodeSelector is the base error when the node selector of a component
+// sets a label that assigns the pool of the component.
+var errNodeSelector = errors.New("invalid node selector")
+
+// errVolume is the base error when the volumes of a pod share a name, or a
+// volume mount references a volume that the pod does not declare.
+var errVolume = errors.New("invalid volumes")
+
+// driverDeadlineMarginSeconds is added to the active deadline of the driver
+// pod, so workers that hang are terminated first and the driver can report
+// the failure.
+const driverDeadlineMarginSeconds = 30
+
+// ConfigMapGetter fetches a ConfigMap by name from the namespace of the test.
+type ConfigMapGetter func(name string) (*corev1.ConfigMap, error)
+
+// SecretGetter fetches a Secret by name from the namespace of the test.
+type SecretGetter func(name string) (*corev1.Secret, error)
+
 // addReadyInitContainer configures a ready init container. This container is
 // meant to wait for workers to become ready, writing the IP address and port of
 // these workers to a file. This file is then shared over a volume with the
@@ -70,17 +153,19 @@ func newReadyContainer(defs *config.Defaults, test *grpcv1.LoadTest) corev1.Cont
 		return corev1.Container{}
 	}
 
+	labelValue := defs.LoadTestLabelValueFor(test)
+
 	var args []string
 	for _, server := range test.Spec.Servers {
 		args = append(args, fmt.Sprintf("%s=%s,%s=%s,%s=%s",
-			config.LoadTestLabel, test.Name,
+			config.LoadTestLabel, labelValue,
 			config.RoleLabel, config.ServerRole,
 			config.ComponentNameLabel, *server.Name,
 		))
 	}
 	for _, client := range test.Spec.Clients {
 		args = append(args, fmt.Sprintf("%s=%s,%s=%s,%s=%s",
-			config.LoadTestLabel, test.Name,
+			config.LoadTestLabel, labelValue,
 			config.RoleLabel, config.ClientRole,
 			config.ComponentNameLabel, *client.Name,
 		))
@@ -112,35 +197,103 @@ func newReadyContainer(defs *config.Defaults, test *grpcv1.LoadTest) corev1.Cont
 
 // PodBuilder constructs pods for a test's driver, server and client.
 type PodBuilder struct {
-	test     *grpcv1.LoadTest
-	defaults *config.Defaults
-	name     string
-	role     string
-	pool     string
-	clone    *grpcv1.Clone
-	build    *grpcv1.Build
-	run      *grpcv1.Run
+	test         *grpcv1.LoadTest
+	defaults     *config.Defaults
+	getConfigMap ConfigMapGetter
+	getSecret    SecretGetter
+	name         string
+	role         string
+	pool         string
+	dnsConfig    *corev1.PodDNSConfig
+	dnsPolicy    *corev1.DNSPolicy
+	affinity     *corev1.Affinity
+	tolerations  []corev1.Toleration
+	volumes      []corev1.Volume
+	nodeSelector map[string]string
+	initSteps    []InitStep
+	warnings     []string
+	clone        *grpcv1.Clone
+	build        *grpcv1.Build
+	run          *grpcv1.Run
 }
 
 // New creates a PodBuilder instance. It accepts and uses defaults and a test to
 // predictably construct pods.
 func New(defaults *config.Defaults, test *grpcv1.LoadTest) *PodBuilder {
 	return &PodBuilder{
-		test:     test,
-		defaults: defaults,
+		test:      test,
+		defaults:  defaults,
+		initSteps: DefaultInitSteps(),
 	}
 }
 
+// SetInitSteps sets the init steps that contribute the init containers of
+// each pod, in the order in which they run. To add a step to the clone and
+// build steps, pass it with the DefaultInitSteps. The ready init container of
+// the driver is not a step, and always runs last.
+func (pb *PodBuilder) SetInitSteps(steps []InitStep) {
+	pb.initSteps = steps
+}
+
+// SetConfigMapGetter sets the function used to fetch ConfigMaps that are
+// referenced by the ArgsFrom field on a run container or by environment
+// variables. Pods for components that reference ConfigMaps cannot be built
+// until this is set.
+func (pb *PodBuilder) SetConfigMapGetter(getter ConfigMapGetter) {
+	pb.getConfigMap = getter
+}
+
+// SetSecretGetter sets the function used to fetch Secrets that are referenced
+// by the CredentialsSecretName field on clone instructions or by environment
+// variables. Pods for components that reference Secrets cannot be built until
+// this is set.
+func (pb *PodBuilder) SetSecretGetter(getter SecretGetter) {
+	pb.getSecret = getter
+}
+
+// Warnings returns the warnings that were raised while building the last pod,
+// such as affinity rules that were dropped because they conflict with the
+// pool of the component.
+func (pb *PodBuilder) Warnings() []string {
+	return pb.warnings
+}
+
 // PodForClient accepts a pointer to a client and returns a pod for it.
 func (pb *PodBuilder) PodForClient(client *grpcv1.Client) (*corev1.Pod, error) {
 	pb.name = safeStrUnwrap(client.Name)
 	pb.role = config.ClientRole
 	pb.pool = safeStrUnwrap(client.Pool)
+	pb.dnsConfig = client.DNSConfig
+	pb.dnsPolicy = client.DNSPolicy
+	pb.affinity = client.Affinity
+	pb.tolerations = client.Tolerations
+	pb.volumes = client.Volumes
+	pb.nodeSelector = client.NodeSelector
+	pb.warnings = nil
 	pb.clone = client.Clone
 	pb.build = client.Build
 	pb.run = &client.Run
 
+	if err := pb.checkResources(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkClone(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkDNS(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkSidecars(); err != nil {
+		return nil, err
+	}
+
 	pod := pb.newPod()
+	if err := pb.setArgsFrom(pod); err != nil {
+		return nil, err
+	}
+	if err := pb.checkEnvFrom(pod); err != nil {
+		return nil, err
+	}
 
 	nodeSelector := make(map[string]string)
 	if client.Pool != nil {
@@ -151,16 +304,31 @@ func (pb *PodBuilder) PodForClient(client *grpcv1.Client) (*corev1.Pod, error) {
 		return nil, errors.Wrapf(errNoPool, "could not determine pool for client %q (no explicit value or default)", pb.name)
 	}
 	pod.Spec.NodeSelector = nodeSelector
+	if err := pb.mergeNodeSelector(pod); err != nil {
+		return nil, err
+	}
+	pb.setColocation(pod)
+	pb.setAffinity(pod)
 
 	runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
 
-	runContainer.Args = append(runContainer.Args, fmt.Sprintf("--driver_port=%d", config.DriverPort))
+	hasPortArg, err := pb.checkPortArg(runContainer.Args, "driver_port", config.DriverPort)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPortArg {
+		runContainer.Args = append(runContainer.Args, fmt.Sprintf("--driver_port=%d", config.DriverPort))
+	}
 	runContainer.Ports = append(runContainer.Ports, corev1.ContainerPort{
 		Name:          "driver",
 		Protocol:      corev1.ProtocolTCP,
 		ContainerPort: config.DriverPort,
 	})
 
+	if err := pb.checkVolumes(pod); err != nil {
+		return nil, err
+	}
+
 	return pod, nil
 }
 
@@ -169,11 +337,37 @@ func (pb *PodBuilder) PodForDriver(driver *grpcv1.Driver) (*corev1.Pod, error) {
 	pb.name = safeStrUnwrap(driver.Name)
 	pb.role = config.DriverRole
 	pb.pool = safeStrUnwrap(driver.Pool)
+	pb.dnsConfig = driver.DNSConfig
+	pb.dnsPolicy = driver.DNSPolicy
+	pb.affinity = driver.Affinity
+	pb.tolerations = driver.Tolerations
+	pb.volumes = driver.Volumes
+	pb.nodeSelector = driver.NodeSelector
+	pb.warnings = nil
 	pb.clone = driver.Clone
 	pb.build = driver.Build
 	pb.run = &driver.Run
 
+	if err := pb.checkResources(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkClone(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkDNS(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkSidecars(); err != nil {
+		return nil, err
+	}
+
 	pod := pb.newPod()
+	if err := pb.setArgsFrom(pod); err != nil {
+		return nil, err
+	}
+	if err := pb.checkEnvFrom(pod); err != nil {
+		return nil, err
+	}
 
 	nodeSelector := make(map[string]string)
 	if driver.Pool != nil {
@@ -184,6 +378,15 @@ func (pb *PodBuilder) PodForDriver(driver *grpcv1.Driver) (*corev1.Pod, error) {
 		return nil, errors.Wrapf(errNoPool, "could not determine pool for driver (no explicit value or default)")
 	}
 	pod.Spec.NodeSelector = nodeSelector
+	if err := pb.mergeNodeSelector(pod); err != nil {
+		return nil, err
+	}
+	pb.setAffinity(pod)
+
+	if pod.Spec.ActiveDeadlineSeconds != nil {
+		deadline := *pod.Spec.ActiveDeadlineSeconds + driverDeadlineMarginSeconds
+		pod.Spec.ActiveDeadlineSeconds = &deadline
+	}
 
 	runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
 	addReadyInitContainer(pb.defaults, pb.test, &pod.Spec, runContainer)
@@ -200,12 +403,12 @@ func (pb *PodBuilder) PodForDriver(driver *grpcv1.Driver) (*corev1.Pod, error) {
 	})
 	runContainer.VolumeMounts = append(runContainer.VolumeMounts, corev1.VolumeMount{
 		Name:      "scenarios",
-		MountPath: config.ScenariosMountPath,
+		MountPath: pb.scenariosMountPath(),
 		ReadOnly:  true,
 	})
 	runContainer.Env = append(runContainer.Env, corev1.EnvVar{
 		Name:  config.ScenariosFileEnv,
-		Value: config.ScenariosMountPath + "/scenarios.json",
+		Value: path.Join(pb.scenariosMountPath(), "scenarios.json"),
 	})
 
 	if results := pb.test.Spec.Results; results != nil {
@@ -215,6 +418,47 @@ func (pb *PodBuilder) PodForDriver(driver *grpcv1.Driver) (*corev1.Pod, error) {
 				Value: *bigQueryTable,
 			})
 		}
+
+		if partitionField := results.PartitionField; partitionField != nil {
+			runContainer.Env = append(runContainer.Env, corev1.EnvVar{
+				Name:  config.BigQueryPartitionFieldEnv,
+				Value: *partitionField,
+			})
+		}
+
+		if results.PartitionType != "" {
+			runContainer.Env = append(runContainer.Env, corev1.EnvVar{
+				Name:  config.BigQueryPartitionTypeEnv,
+				Value: string(results.PartitionType),
+			})
+		}
+
+		if err := validateGCSBucket(results.GCSBucket, results.GCSPrefix); err != nil {
+			return nil, err
+		}
+		if gcsBucket := results.GCSBucket; gcsBucket != nil {
+			runContainer.Env = append(runContainer.Env, corev1.EnvVar{
+				Name:  config.GCSBucketEnv,
+				Value: *gcsBucket,
+			})
+		}
+		if gcsPrefix := results.GCSPrefix; gcsPrefix != nil {
+			runContainer.Env = append(runContainer.Env, corev1.EnvVar{
+				Name:  config.GCSPrefixEnv,
+				Value: *gcsPrefix,
+			})
+		}
+
+		if audience := results.Audience; audience != nil {
+			if err := validateAudience(*audience); err != nil {
+				return nil, err
+			}
+			addResultsTokenVolume(&pod.Spec, runContainer, *audience)
+		}
+	}
+
+	if err := pb.checkVolumes(pod); err != nil {
+		return nil, err
 	}
 
 	return pod, nil
@@ -225,11 +469,37 @@ func (pb *PodBuilder) PodForServer(server *grpcv1.Server) (*corev1.Pod, error) {
 	pb.name = safeStrUnwrap(server.Name)
 	pb.role = config.ServerRole
 	pb.pool = safeStrUnwrap(server.Pool)
+	pb.dnsConfig = server.DNSConfig
+	pb.dnsPolicy = server.DNSPolicy
+	pb.affinity = server.Affinity
+	pb.tolerations = server.Tolerations
+	pb.volumes = server.Volumes
+	pb.nodeSelector = server.NodeSelector
+	pb.warnings = nil
 	pb.clone = server.Clone
 	pb.build = server.Build
 	pb.run = &server.Run
 
+	if err := pb.checkResources(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkClone(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkDNS(); err != nil {
+		return nil, err
+	}
+	if err := pb.checkSidecars(); err != nil {
+		return nil, err
+	}
+
 	pod := pb.newPod()
+	if err := pb.setArgsFrom(pod); err != nil {
+		return nil, err
+	}
+	if err := pb.checkEnvFrom(pod); err != nil {
+		return nil, err
+	}
 
 	nodeSelector := make(map[string]string)
 	if server.Pool != nil {
@@ -240,84 +510,74 @@ func (pb *PodBuilder) PodForServer(server *grpcv1.Server) (*corev1.Pod, error) {
 		return nil, errors.Wrapf(errNoPool, "could not determine pool for server %q (no explicit value or default)", pb.name)
 	}
 	pod.Spec.NodeSelector = nodeSelector
+	if err := pb.mergeNodeSelector(pod); err != nil {
+		return nil, err
+	}
+	pb.setColocation(pod)
+	pb.setAffinity(pod)
 
 	runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
 
-	runContainer.Args = append(runContainer.Args, fmt.Sprintf("--driver_port=%d", config.DriverPort))
+	hasPortArg, err := pb.checkPortArg(runContainer.Args, "driver_port", config.DriverPort)
+	if err != nil {
+		return nil, err
+	}
+	if err := pb.checkServerPortArg(runContainer.Args); err != nil {
+		return nil, err
+	}
+	if !hasPortArg {
+		runContainer.Args = append(runContainer.Args, fmt.Sprintf("--driver_port=%d", config.DriverPort))
+	}
 	runContainer.Ports = append(runContainer.Ports, corev1.ContainerPort{
 		Name:          "driver",
 		Protocol:      corev1.ProtocolTCP,
 		ContainerPort: config.DriverPort,
 	})
 
+	if err := pb.checkVolumes(pod); err != nil {
+		return nil, err
+	}
+
 	return pod, nil
 }
 
 // newPod creates a base pod for any client, driver or server. It is designed to
 // be decorated by more specific methods for each of these.
 func (pb *PodBuilder) newPod() *corev1.Pod {
-	var initContainers []corev1.Container
-
-	if pb.clone != nil {
-		var env []corev1.EnvVar
-
-		if pb.clone.Repo != nil {
-			env = append(env, corev1.EnvVar{
-				Name:  config.CloneRepoEnv,
-				Value: safeStrUnwrap(pb.clone.Repo),
-			})
-		}
+	component := &Component{
+		Name:  pb.name,
+		Role:  pb.role,
+		Pool:  pb.pool,
+		Clone: pb.clone,
+		Build: pb.build,
+		Run:   pb.run,
+	}
 
-		if pb.clone.GitRef != nil {
-			env = append(env, corev1.EnvVar{
-				Name:  config.CloneGitRefEnv,
-				Value: safeStrUnwrap(pb.clone.GitRef),
-			})
+	var initContainers []corev1.Container
+	var stepVolumes []corev1.Volume
+	for _, step := range pb.initSteps {
+		container, volumes := step.InitContainer(pb.defaults, component)
+		if container == nil {
+			continue
 		}
-
-		initContainers = append(initContainers, corev1.Container{
-			Name:  config.CloneInitContainerName,
-			Image: safeStrUnwrap(pb.clone.Image),
-			Env:   env,
-			VolumeMounts: []corev1.VolumeMount{
-				{
-					Name:      config.WorkspaceVolumeName,
-					MountPath: config.WorkspaceMountPath,
-					ReadOnly:  false,
-				},
-			},
-		})
+		initContainers = append(initContainers, *container)
+		stepVolumes = append(stepVolumes, volumes...)
 	}
 
-	if pb.build != nil {
-		initContainers = append(initContainers, corev1.Container{
-			Name:       config.BuildInitContainerName,
-			Image:      safeStrUnwrap(pb.build.Image),
-			Command:    pb.build.Command,
-			Args:       pb.build.Args,
-			Env:        pb.build.Env,
-			WorkingDir: config.WorkspaceMountPath,
-			VolumeMounts: []corev1.VolumeMount{
-				{
-					Name:      config.WorkspaceVolumeName,
-					MountPath: config.WorkspaceMountPath,
-					ReadOnly:  false,
-				},
-				{
-					Name:      config.BazelCacheVolumeName,
-					MountPath: config.BazelCacheMountPath,
-					ReadOnly:  false,
-				},
-			},
-		})
+	// Kubernetes terminates pods that run past their active deadline, which
+	// stops hung components even if the controller misses the timeout.
+	var activeDeadlineSeconds *int64
+	if pb.test.Spec.TimeoutSeconds > 0 {
+		deadline := int64(pb.test.Spec.TimeoutSeconds)
+		activeDeadlineSeconds = &deadline
 	}
 
-	return &corev1.Pod{
+	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      fmt.Sprintf("%s-%s-%s", pb.test.Name, pb.role, pb.name),
 			Namespace: pb.test.Namespace,
 			Labels: map[string]string{
-				config.LoadTestLabel:      pb.test.Name,
+				config.LoadTestLabel:      pb.defaults.LoadTestLabelValueFor(pb.test),
 				config.RoleLabel:          pb.role,
 				config.ComponentNameLabel: pb.name,
 			},
@@ -331,7 +591,8 @@ func (pb *PodBuilder) newPod() *corev1.Pod {
 					Command:    pb.run.Command,
 					Args:       pb.run.Args,
 					Env:        pb.run.Env,
-					WorkingDir: config.WorkspaceMountPath,
+					Resources:  safeResourcesUnwrap(pb.run.Resources),
+					WorkingDir: workingDir(pb.run.WorkingDir),
 					VolumeMounts: []corev1.VolumeMount{
 						{
 							Name:      config.WorkspaceVolumeName,
@@ -346,7 +607,10 @@ func (pb *PodBuilder) newPod() *corev1.Pod {
 					},
 				},
 			},
-			RestartPolicy: corev1.RestartPolicyNever,
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: activeDeadlineSeconds,
+			ImagePullSecrets:      pb.imagePullSecrets(),
+			Tolerations:           pb.podTolerations(),
 			Affinity: &corev1.Affinity{
 				PodAntiAffinity: &corev1.PodAntiAffinity{
 					RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
@@ -374,6 +638,585 @@ func (pb *PodBuilder) newPod() *corev1.Pod {
 			},
 		},
 	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, stepVolumes...)
+	for i := range pb.volumes {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, *pb.volumes[i].DeepCopy())
+	}
+	runContainer := &pod.Spec.Containers[0]
+	runContainer.VolumeMounts = append(runContainer.VolumeMounts, pb.run.VolumeMounts...)
+
+	for i := range pb.run.Sidecars {
+		pod.Spec.Containers = append(pod.Spec.Containers, *pb.run.Sidecars[i].DeepCopy())
+	}
+
+	if pb.dnsConfig != nil {
+		pod.Spec.DNSConfig = pb.dnsConfig.DeepCopy()
+	}
+	if pb.dnsPolicy != nil {
+		pod.Spec.DNSPolicy = *pb.dnsPolicy
+	}
+
+	return pod
+}
+
+// scenariosMountPath returns the path where the scenarios are mounted in the
+// driver, from the defaults or the ScenariosMountPath constant.
+func (pb *PodBuilder) scenariosMountPath() string {
+	if pb.defaults.ScenariosMountPath != "" {
+		return pb.defaults.ScenariosMountPath
+	}
+	return config.ScenariosMountPath
+}
+
+// validateAudience returns an error if an audience for the results token is
+// not a URL with a host, such as "https://example.com" or
+// "//iam.googleapis.com/projects/...".
+func validateAudience(audience string) error {
+	if strings.ContainsAny(audience, " \t\n") {
+		return errors.Wrapf(errAudience, "audience %q contains whitespace", audience)
+	}
+	u, err := url.Parse(audience)
+	if err != nil {
+		return errors.Wrapf(errAudience, "audience %q is not a URL: %v", audience, err)
+	}
+	if u.Host == "" {
+		return errors.Wrapf(errAudience, "audience %q has no host", audience)
+	}
+	return nil
+}
+
+// gcsBucketPattern matches the characters that Cloud Storage allows in bucket
+// names. A name must start and end with a lowercase letter or number.
+var gcsBucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*[a-z0-9]$`)
+
+// validateGCSBucket returns an error if a bucket for result artifacts does not
+// follow the naming rules of Cloud Storage, or if a prefix is set without a
+// bucket. See https://cloud.google.com/storage/docs/buckets#naming.
+func validateGCSBucket(bucket, prefix *string) error {
+	if bucket == nil {
+		if prefix != nil {
+			return errors.Wrapf(errGCSBucket, "prefix %q is set without a bucket", *prefix)
+		}
+		return nil
+	}
+
+	name := *bucket
+	if len(name) < 3 || len(name) > 222 {
+		return errors.Wrapf(errGCSBucket, "bucket %q must contain 3 to 222 characters", name)
+	}
+	if !gcsBucketPattern.MatchString(name) {
+		return errors.Wrapf(errGCSBucket, "bucket %q must contain only lowercase letters, numbers, dashes, underscores and dots, and must start and end with a letter or number", name)
+	}
+	for _, component := range strings.Split(name, ".") {
+		if len(component) == 0 || len(component) > 63 {
+			return errors.Wrapf(errGCSBucket, "bucket %q must have dot-separated components of 1 to 63 characters", name)
+		}
+	}
+	if strings.HasPrefix(name, "goog") {
+		return errors.Wrapf(errGCSBucket, "bucket %q must not start with \"goog\"", name)
+	}
+	if net.ParseIP(name) != nil {
+		return errors.Wrapf(errGCSBucket, "bucket %q must not be an IP address", name)
+	}
+	if prefix != nil && strings.HasPrefix(*prefix, "/") {
+		return errors.Wrapf(errGCSBucket, "prefix %q must not start with a slash", *prefix)
+	}
+	return nil
+}
+
+// addResultsTokenVolume adds a projected volume with a service account token
+// for the audience to the pod, and mounts it in the container. The path to the
+// token is set in the ResultsTokenFileEnv env variable.
+func addResultsTokenVolume(podspec *corev1.PodSpec, container *corev1.Container, audience string) {
+	expirationSeconds := int64(resultsTokenExpirationSeconds)
+	podspec.Volumes = append(podspec.Volumes, corev1.Volume{
+		Name: config.ResultsTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
+							Audience:          audience,
+							ExpirationSeconds: &expirationSeconds,
+							Path:              config.ResultsTokenPath,
+						},
+					},
+				},
+			},
+		},
+	})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      config.ResultsTokenVolumeName,
+		MountPath: config.ResultsTokenMountPath,
+		ReadOnly:  true,
+	})
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  config.ResultsTokenFileEnv,
+		Value: config.ResultsTokenMountPath + "/" + config.ResultsTokenPath,
+	})
+}
+
+// imagePullSecrets returns references to the secrets used to pull the images
+// of a pod. The secrets of the test take precedence over the defaults. It
+// returns nil if neither has secrets, so the field is omitted from the pod.
+func (pb *PodBuilder) imagePullSecrets() []corev1.LocalObjectReference {
+	names := pb.test.Spec.ImagePullSecrets
+	if len(names) == 0 {
+		names = pb.defaults.ImagePullSecrets
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	secrets := make([]corev1.LocalObjectReference, len(names))
+	for i, name := range names {
+		secrets[i] = corev1.LocalObjectReference{Name: name}
+	}
+	return secrets
+}
+
+// podTolerations returns a copy of the tolerations of a pod. The tolerations of
+// the component replace the defaults, without merging. It returns nil if
+// neither has tolerations, so the field is omitted from the pod.
+func (pb *PodBuilder) podTolerations() []corev1.Toleration {
+	tolerations := pb.tolerations
+	if len(tolerations) == 0 {
+		tolerations = pb.defaults.DefaultTolerations
+	}
+	if len(tolerations) == 0 {
+		return nil
+	}
+
+	copied := make([]corev1.Toleration, len(tolerations))
+	for i := range tolerations {
+		tolerations[i].DeepCopyInto(&copied[i])
+	}
+	return copied
+}
+
+// setColocation applies the colocation affinity of the test to a client or
+// server pod. Client pods require a server pod of the same test in the same
+// topology domain. For node colocation, the anti-affinity of client and server
+// pods is relaxed to allow pods of the same test to share a node, while still
+// keeping pods of other tests off the node.
+func (pb *PodBuilder) setColocation(pod *corev1.Pod) {
+	affinity := pb.test.Spec.Affinity
+	if affinity == nil || affinity.Colocation == "" {
+		return
+	}
+
+	labelValue := pb.defaults.LoadTestLabelValueFor(pb.test)
+
+	var topologyKey string
+	switch affinity.Colocation {
+	case grpcv1.NodeColocation:
+		topologyKey = "kubernetes.io/hostname"
+		antiAffinityTerms := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		for i := range antiAffinityTerms {
+			selector := antiAffinityTerms[i].LabelSelector
+			selector.MatchExpressions = append(selector.MatchExpressions, metav1.LabelSelectorRequirement{
+				Key:      config.LoadTestLabel,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{labelValue},
+			})
+		}
+	case grpcv1.ZoneColocation:
+		topologyKey = "topology.kubernetes.io/zone"
+	default:
+		return
+	}
+
+	if pb.role != config.ClientRole {
+		return
+	}
+
+	pod.Spec.Affinity.PodAffinity = &corev1.PodAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+			{
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						config.LoadTestLabel: labelValue,
+						config.RoleLabel:     config.ServerRole,
+					},
+				},
+				TopologyKey: topologyKey,
+			},
+		},
+	}
+}
+
+// setAffinity merges the affinity of the component into the affinity of the
+// pod. Required node selector requirements that conflict with the node
+// selector for the pool are removed with a warning, since the pool is always
+// enforced and the pod could never be scheduled otherwise.
+func (pb *PodBuilder) setAffinity(pod *corev1.Pod) {
+	if pb.affinity == nil {
+		return
+	}
+	affinity := pb.affinity.DeepCopy()
+
+	if nodeAffinity := affinity.NodeAffinity; nodeAffinity != nil {
+		if required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			var terms []corev1.NodeSelectorTerm
+			for _, term := range required.NodeSelectorTerms {
+				var expressions []corev1.NodeSelectorRequirement
+				for _, requirement := range term.MatchExpressions {
+					if pb.conflictsWithPool(pod, requirement) {
+						pb.warnings = append(pb.warnings, fmt.Sprintf("ignoring node affinity requirement %s %s %v for %s %q, since it conflicts with the pool of the pod", requirement.Key, requirement.Operator, requirement.Values, pb.role, pb.name))
+						continue
+					}
+					expressions = append(expressions, requirement)
+				}
+				if len(expressions) == 0 && len(term.MatchFields) == 0 {
+					continue
+				}
+				term.MatchExpressions = expressions
+				terms = append(terms, term)
+			}
+			required.NodeSelectorTerms = terms
+			if len(terms) == 0 {
+				nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+			}
+		}
+		pod.Spec.Affinity.NodeAffinity = nodeAffinity
+	}
+
+	if podAffinity := affinity.PodAffinity; podAffinity != nil {
+		if pod.Spec.Affinity.PodAffinity == nil {
+			pod.Spec.Affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		current := pod.Spec.Affinity.PodAffinity
+		current.RequiredDuringSchedulingIgnoredDuringExecution = append(current.RequiredDuringSchedulingIgnoredDuringExecution, podAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		current.PreferredDuringSchedulingIgnoredDuringExecution = append(current.PreferredDuringSchedulingIgnoredDuringExecution, podAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+
+	if podAntiAffinity := affinity.PodAntiAffinity; podAntiAffinity != nil {
+		if pod.Spec.Affinity.PodAntiAffinity == nil {
+			pod.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		current := pod.Spec.Affinity.PodAntiAffinity
+		current.RequiredDuringSchedulingIgnoredDuringExecution = append(current.RequiredDuringSchedulingIgnoredDuringExecution, podAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+		current.PreferredDuringSchedulingIgnoredDuringExecution = append(current.PreferredDuringSchedulingIgnoredDuringExecution, podAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+	}
+}
+
+// conflictsWithPool returns true if a node selector requirement cannot be
+// satisfied by a node that matches the node selector of the pod.
+func (pb *PodBuilder) conflictsWithPool(pod *corev1.Pod, requirement corev1.NodeSelectorRequirement) bool {
+	value, ok := pod.Spec.NodeSelector[requirement.Key]
+	if !ok {
+		return false
+	}
+
+	hasValue := false
+	for _, v := range requirement.Values {
+		if v == value {
+			hasValue = true
+			break
+		}
+	}
+
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		return !hasValue
+	case corev1.NodeSelectorOpNotIn:
+		return hasValue
+	case corev1.NodeSelectorOpExists:
+		return false
+	default:
+		// DoesNotExist always conflicts, and pool labels are not integers
+		// that Gt and Lt could compare.
+		return true
+	}
+}
+
+// mergeNodeSelector adds the node selector of the component to the node
+// selector of the pod, which holds the label of its pool. It returns an error
+// if the component sets the pool label, or a label that was set to assign the
+// default pool, since the pool takes precedence.
+func (pb *PodBuilder) mergeNodeSelector(pod *corev1.Pod) error {
+	for key, value := range pb.nodeSelector {
+		if key == config.PoolLabel {
+			return errors.Wrapf(errNodeSelector, "node selector for %s %q sets the %q label, which is set by the pool of the component", pb.role, pb.name, key)
+		}
+		if _, ok := pod.Spec.NodeSelector[key]; ok {
+			return errors.Wrapf(errNodeSelector, "node selector for %s %q sets the %q label, which is set by the default pool", pb.role, pb.name, key)
+		}
+		pod.Spec.NodeSelector[key] = value
+	}
+	return nil
+}
+
+// setArgsFrom resolves the ArgsFrom field of the run instructions, placing the
+// arguments from the referenced ConfigMap key before any inline arguments on
+// the run container. It returns an error if the reference is incomplete or
+// the ConfigMap key cannot be found, unless the reference is optional. Errors
+// other than the absence of the ConfigMap are wrapped in ErrLookupFailed, even
+// when the reference is optional, so the pod is not built without its args.
+func (pb *PodBuilder) setArgsFrom(pod *corev1.Pod) error {
+	ref := pb.run.ArgsFrom
+	if ref == nil {
+		return nil
+	}
+
+	if ref.Name == "" || ref.Key == "" {
+		return errors.Wrapf(errArgsFrom, "reference for %s %q requires a name and key", pb.role, pb.name)
+	}
+
+	if pb.getConfigMap == nil {
+		return errors.Wrapf(errArgsFrom, "no ConfigMap getter set to resolve args for %s %q", pb.role, pb.name)
+	}
+
+	optional := ref.Optional != nil && *ref.Optional
+
+	cfgMap, err := pb.getConfigMap(ref.Name)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(ErrLookupFailed, "failed to get ConfigMap %q for %s %q: %v", ref.Name, pb.role, pb.name, err)
+		}
+		if optional {
+			return nil
+		}
+		return errors.Wrapf(errArgsFrom, "ConfigMap %q for %s %q does not exist", ref.Name, pb.role, pb.name)
+	}
+
+	value, ok := cfgMap.Data[ref.Key]
+	if !ok {
+		if optional {
+			return nil
+		}
+		return errors.Wrapf(errArgsFrom, "ConfigMap %q has no key %q for %s %q", ref.Name, ref.Key, pb.role, pb.name)
+	}
+
+	runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+	runContainer.Args = append(strings.Fields(value), runContainer.Args...)
+	return nil
+}
+
+// checkEnvFrom returns an error if an environment variable of any container
+// takes its value from a ConfigMap or Secret key that cannot be found, since
+// such pods never start. References that are optional are not checked, and
+// variables with plain values are ignored. Failures to fetch a ConfigMap or
+// Secret for reasons other than its absence are wrapped in ErrLookupFailed.
+func (pb *PodBuilder) checkEnvFrom(pod *corev1.Pod) error {
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+				if pb.getConfigMap == nil {
+					return errors.Wrapf(errEnvFrom, "no ConfigMap getter set to resolve env %q of container %q for %s %q", env.Name, container.Name, pb.role, pb.name)
+				}
+				cfgMap, err := pb.getConfigMap(ref.Name)
+				if kerrors.IsNotFound(err) {
+					return errors.Wrapf(errEnvFrom, "ConfigMap %q for env %q of container %q for %s %q does not exist", ref.Name, env.Name, container.Name, pb.role, pb.name)
+				}
+				if err != nil {
+					return errors.Wrapf(ErrLookupFailed, "failed to get ConfigMap %q for env %q of container %q for %s %q: %v", ref.Name, env.Name, container.Name, pb.role, pb.name, err)
+				}
+				_, inData := cfgMap.Data[ref.Key]
+				_, inBinaryData := cfgMap.BinaryData[ref.Key]
+				if !inData && !inBinaryData {
+					return errors.Wrapf(errEnvFrom, "ConfigMap %q has no key %q for env %q of container %q for %s %q", ref.Name, ref.Key, env.Name, container.Name, pb.role, pb.name)
+				}
+			}
+
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil && (ref.Optional == nil || !*ref.Optional) {
+				if pb.getSecret == nil {
+					return errors.Wrapf(errEnvFrom, "no Secret getter set to resolve env %q of container %q for %s %q", env.Name, container.Name, pb.role, pb.name)
+				}
+				secret, err := pb.getSecret(ref.Name)
+				if kerrors.IsNotFound(err) {
+					return errors.Wrapf(errEnvFrom, "Secret %q for env %q of container %q for %s %q does not exist", ref.Name, env.Name, container.Name, pb.role, pb.name)
+				}
+				if err != nil {
+					return errors.Wrapf(ErrLookupFailed, "failed to get Secret %q for env %q of container %q for %s %q: %v", ref.Name, env.Name, container.Name, pb.role, pb.name, err)
+				}
+				if _, ok := secret.Data[ref.Key]; !ok {
+					return errors.Wrapf(errEnvFrom, "Secret %q has no key %q for env %q of container %q for %s %q", ref.Name, ref.Key, env.Name, container.Name, pb.role, pb.name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkClone returns an error if the clone instructions set a depth that is
+// not positive, which git rejects, or reference a secret with credentials
+// that does not exist. Failures to fetch the secret for other reasons are
+// wrapped in ErrLookupFailed.
+func (pb *PodBuilder) checkClone() error {
+	if pb.clone == nil {
+		return nil
+	}
+
+	if pb.clone.Depth != nil && *pb.clone.Depth < 1 {
+		return errors.Wrapf(errCloneDepth, "clone container for %s %q has depth %d, which must be positive", pb.role, pb.name, *pb.clone.Depth)
+	}
+
+	if pb.clone.CredentialsSecretName != nil {
+		secretName := *pb.clone.CredentialsSecretName
+		if secretName == "" {
+			return errors.Wrapf(errCloneCredentials, "clone container for %s %q has an empty secret name", pb.role, pb.name)
+		}
+		if pb.getSecret == nil {
+			return errors.Wrapf(errCloneCredentials, "no Secret getter set to find credentials for %s %q", pb.role, pb.name)
+		}
+		_, err := pb.getSecret(secretName)
+		if kerrors.IsNotFound(err) {
+			return errors.Wrapf(errCloneCredentials, "Secret %q for %s %q does not exist", secretName, pb.role, pb.name)
+		}
+		if err != nil {
+			return errors.Wrapf(ErrLookupFailed, "failed to get Secret %q for %s %q: %v", secretName, pb.role, pb.name, err)
+		}
+	}
+
+	return nil
+}
+
+// checkSidecars returns an error if a sidecar has no name, or if its name is
+// the name of the run container, an init container or another sidecar.
+func (pb *PodBuilder) checkSidecars() error {
+	names := map[string]bool{
+		config.RunContainerName:       true,
+		config.CloneInitContainerName: true,
+		config.BuildInitContainerName: true,
+		config.ReadyInitContainerName: true,
+	}
+	for i, sidecar := range pb.run.Sidecars {
+		if sidecar.Name == "" {
+			return errors.Wrapf(errSidecar, "sidecar at index %d for %s %q has no name", i, pb.role, pb.name)
+		}
+		if names[sidecar.Name] {
+			return errors.Wrapf(errSidecar, "sidecar %q for %s %q has the name of another container in the pod", sidecar.Name, pb.role, pb.name)
+		}
+		names[sidecar.Name] = true
+	}
+	return nil
+}
+
+// checkVolumes returns an error if two volumes of a pod share a name, such as
+// a volume of the component that has the name of a volume the controller adds,
+// or if a volume mount of any container references a volume that the pod does
+// not declare.
+func (pb *PodBuilder) checkVolumes(pod *corev1.Pod) error {
+	names := make(map[string]bool)
+	for _, volume := range pod.Spec.Volumes {
+		if names[volume.Name] {
+			return errors.Wrapf(errVolume, "pod for %s %q has more than one volume named %q", pb.role, pb.name, volume.Name)
+		}
+		names[volume.Name] = true
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, mount := range container.VolumeMounts {
+			if !names[mount.Name] {
+				return errors.Wrapf(errVolume, "volume mount %q of container %q for %s %q references a volume that is not declared", mount.Name, container.Name, pb.role, pb.name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkDNS returns an error if the DNS policy is None without nameservers in
+// the DNS config, since such pods are rejected by Kubernetes.
+func (pb *PodBuilder) checkDNS() error {
+	if pb.dnsPolicy == nil || *pb.dnsPolicy != corev1.DNSNone {
+		return nil
+	}
+	if pb.dnsConfig == nil || len(pb.dnsConfig.Nameservers) == 0 {
+		return errors.Wrapf(errDNS, "%s %q has DNS policy %s, which requires nameservers in its DNS config", pb.role, pb.name, corev1.DNSNone)
+	}
+	return nil
+}
+
+// checkResources returns an error if the resource requests of the build or run
+// container exceed their limits, since such pods are rejected by Kubernetes.
+func (pb *PodBuilder) checkResources() error {
+	if pb.build != nil {
+		if err := kubehelpers.CheckRequestsWithinLimits(pb.build.Resources); err != nil {
+			return errors.Wrapf(errResources, "build container for %s %q: %v", pb.role, pb.name, err)
+		}
+	}
+	if err := kubehelpers.CheckRequestsWithinLimits(pb.run.Resources); err != nil {
+		return errors.Wrapf(errResources, "run container for %s %q: %v", pb.role, pb.name, err)
+	}
+	return nil
+}
+
+// checkPortArg looks for a flag that sets a port in the args of the run
+// container, in the forms --name=value, -name=value, --name value and
+// -name value. It returns true if the flag is present, and an error if any
+// value does not match the port declared on the container, since the
+// component would not be reachable.
+func (pb *PodBuilder) checkPortArg(args []string, name string, port int32) (bool, error) {
+	values := portArgValues(args, name)
+	for _, value := range values {
+		if value != strconv.Itoa(int(port)) {
+			return true, errors.Wrapf(errPortArg, "run container for %s %q sets --%s=%s, but declares port %d", pb.role, pb.name, name, value, port)
+		}
+	}
+	return len(values) > 0, nil
+}
+
+// checkServerPortArg returns an error if the args of the run container of a
+// server set --server_port to the driver port. The run container declares no
+// port for the benchmark service, since the port is chosen by the args or by
+// the scenario, so the driver port is the only declared port it can conflict
+// with. A server listening for the benchmark on the driver port cannot also
+// receive instructions from the driver.
+func (pb *PodBuilder) checkServerPortArg(args []string) error {
+	for _, value := range portArgValues(args, "server_port") {
+		if value == strconv.Itoa(config.DriverPort) {
+			return errors.Wrapf(errPortArg, "run container for %s %q sets --server_port=%s, but declares it as the driver port", pb.role, pb.name, value)
+		}
+	}
+	return nil
+}
+
+// portArgValues returns the values of a flag in args, in the forms
+// --name=value, -name=value, --name value and -name value.
+func portArgValues(args []string, name string) []string {
+	var values []string
+	for i, arg := range args {
+		trimmed := strings.TrimLeft(arg, "-")
+		if trimmed == arg || len(arg)-len(trimmed) > 2 {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, name+"="):
+			values = append(values, strings.TrimPrefix(trimmed, name+"="))
+		case trimmed == name && i+1 < len(args):
+			values = append(values, args[i+1])
+		}
+	}
+	return values
+}
+
+// safeResourcesUnwrap accepts a pointer to resource requirements, returning a
+// copy of the requirements or empty requirements if the pointer is nil.
+func safeResourcesUnwrap(resources *corev1.ResourceRequirements) corev1.ResourceRequirements {
+	if resources == nil {
+		return corev1.ResourceRequirements{}
+	}
+	return *resources.DeepCopy()
+}
+
+// workingDir resolves the working directory of a build or run container. A
+// relative dir is joined to the workspace where the repository is cloned, and
+// a nil or empty dir resolves to the workspace itself.
+func workingDir(dir *string) string {
+	d := safeStrUnwrap(dir)
+	if path.IsAbs(d) {
+		return path.Clean(d)
+	}
+	return path.Join(config.WorkspaceMountPath, d)
 }
 
 // safeStrUnwrap accepts a string pointer, returning the dereferenced string or
@@ -24,6 +24,9 @@ import (
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 	"github.com/grpc/test-infra/config"
@@ -224,6 +227,48 @@ var _ = Describe("PodBuilder", func() {
 				Expect(gitRefEnv.Value).To(Equal(*client.Clone.GitRef))
 			})
 
+			It("sets an environment variable with the clone depth", func() {
+				client.Clone = new(grpcv1.Clone)
+				client.Clone.Repo = optional.StringPtr("https://github.com/grpc/grpc.git")
+				client.Clone.GitRef = optional.StringPtr("master")
+				client.Clone.Depth = optional.Int32Ptr(1)
+
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+
+				cloneContainer := kubehelpers.ContainerForName(config.CloneInitContainerName, pod.Spec.InitContainers)
+				Expect(cloneContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name:  config.CloneDepthEnv,
+					Value: "1",
+				}))
+			})
+
+			It("does not set the clone depth when it is unset", func() {
+				client.Clone = new(grpcv1.Clone)
+				client.Clone.Repo = optional.StringPtr("https://github.com/grpc/grpc.git")
+				client.Clone.GitRef = optional.StringPtr("master")
+
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+
+				cloneContainer := kubehelpers.ContainerForName(config.CloneInitContainerName, pod.Spec.InitContainers)
+				for _, env := range cloneContainer.Env {
+					Expect(env.Name).ToNot(Equal(config.CloneDepthEnv))
+				}
+			})
+
+			It("returns an error when the clone depth is not positive", func() {
+				client.Clone = new(grpcv1.Clone)
+				client.Clone.Repo = optional.StringPtr("https://github.com/grpc/grpc.git")
+				client.Clone.GitRef = optional.StringPtr("master")
+
+				for _, depth := range []int32{0, -1} {
+					client.Clone.Depth = optional.Int32Ptr(depth)
+					_, err := builder.PodForClient(client)
+					Expect(err).To(MatchError(ContainSubstring(errCloneDepth.Error())))
+				}
+			})
+
 			It("creates volume mount for workspace", func() {
 				client.Clone = new(grpcv1.Clone)
 				client.Clone.Repo = optional.StringPtr("https://github.com/grpc/test-infra.git")
@@ -293,9 +338,58 @@ var _ = Describe("PodBuilder", func() {
 					MountPath: config.WorkspaceMountPath,
 				}))
 			})
+
+			It("sets the requested resources, including ephemeral storage", func() {
+				resources := &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("20Gi"),
+						corev1.ResourceCPU:              resource.MustParse("2"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("40Gi"),
+					},
+				}
+				client.Build = new(grpcv1.Build)
+				client.Build.Resources = resources
+
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+
+				buildContainer := kubehelpers.ContainerForName(config.BuildInitContainerName, pod.Spec.InitContainers)
+				Expect(buildContainer.Resources).To(Equal(*resources))
+			})
 		})
 
 		Context("run container", func() {
+			It("sets the requested resources, including ephemeral storage", func() {
+				resources := &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+					},
+				}
+				client.Run.Resources = resources
+
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+
+				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+				Expect(runContainer.Resources).To(Equal(*resources))
+			})
+
+			It("returns an error when requests exceed limits", func() {
+				client.Run.Resources = &corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("4"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("2"),
+					},
+				}
+
+				_, err := builder.PodForClient(client)
+				Expect(err).To(MatchError(ContainSubstring("cpu request 4 exceeds limit 2")))
+			})
+
 			It("creates volume mount for workspace", func() {
 				client.Run = grpcv1.Run{}
 				client.Run.Command = []string{"go"}
@@ -339,6 +433,191 @@ var _ = Describe("PodBuilder", func() {
 				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
 				Expect(runContainer.Args).To(ContainElement(fmt.Sprintf("--driver_port=%d", config.DriverPort)))
 			})
+
+			Context("args from ConfigMap", func() {
+				var cfgMaps map[string]*corev1.ConfigMap
+
+				BeforeEach(func() {
+					cfgMaps = map[string]*corev1.ConfigMap{
+						"client-args": {
+							Data: map[string]string{
+								"args": "--flag_a=1 --flag_b=2\n--flag_c=3\n",
+							},
+						},
+					}
+					builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+						cfgMap, ok := cfgMaps[name]
+						if !ok {
+							return nil, kerrors.NewNotFound(corev1.Resource("configmaps"), name)
+						}
+						return cfgMap, nil
+					})
+
+					client.Run = grpcv1.Run{}
+					client.Run.Command = []string{"go"}
+					client.Run.ArgsFrom = &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "client-args"},
+						Key:                  "args",
+					}
+				})
+
+				It("splits the ConfigMap value on whitespace and newlines", func() {
+					pod, err := builder.PodForClient(client)
+					Expect(err).ToNot(HaveOccurred())
+
+					runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+					Expect(runContainer.Args).To(Equal([]string{
+						"--flag_a=1",
+						"--flag_b=2",
+						"--flag_c=3",
+						fmt.Sprintf("--driver_port=%d", config.DriverPort),
+					}))
+				})
+
+				It("places args from the ConfigMap before inline args", func() {
+					client.Run.Args = []string{"--flag_a=override"}
+
+					pod, err := builder.PodForClient(client)
+					Expect(err).ToNot(HaveOccurred())
+
+					runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+					Expect(runContainer.Args).To(Equal([]string{
+						"--flag_a=1",
+						"--flag_b=2",
+						"--flag_c=3",
+						"--flag_a=override",
+						fmt.Sprintf("--driver_port=%d", config.DriverPort),
+					}))
+				})
+
+				It("errors when the reference has no key", func() {
+					client.Run.ArgsFrom.Key = ""
+
+					_, err := builder.PodForClient(client)
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("errors when the ConfigMap does not exist", func() {
+					client.Run.ArgsFrom.Name = "missing"
+
+					_, err := builder.PodForClient(client)
+					Expect(err).To(MatchError(ContainSubstring(errArgsFrom.Error())))
+					Expect(IsLookupError(err)).To(BeFalse())
+				})
+
+				It("ignores a missing ConfigMap when the reference is optional", func() {
+					isOptional := true
+					client.Run.ArgsFrom.Name = "missing"
+					client.Run.ArgsFrom.Optional = &isOptional
+
+					_, err := builder.PodForClient(client)
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("returns a lookup error when the ConfigMap cannot be fetched", func() {
+					isOptional := true
+					client.Run.ArgsFrom.Optional = &isOptional
+					builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+						return nil, kerrors.NewServiceUnavailable("try again")
+					})
+
+					_, err := builder.PodForClient(client)
+					Expect(err).To(HaveOccurred())
+					Expect(IsLookupError(err)).To(BeTrue())
+				})
+
+				It("errors when the ConfigMap does not contain the key", func() {
+					client.Run.ArgsFrom.Key = "missing"
+
+					_, err := builder.PodForClient(client)
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("ignores a missing key when the reference is optional", func() {
+					isOptional := true
+					client.Run.ArgsFrom.Key = "missing"
+					client.Run.ArgsFrom.Optional = &isOptional
+					client.Run.Args = []string{"--flag_a=inline"}
+
+					pod, err := builder.PodForClient(client)
+					Expect(err).ToNot(HaveOccurred())
+
+					runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+					Expect(runContainer.Args).To(Equal([]string{
+						"--flag_a=inline",
+						fmt.Sprintf("--driver_port=%d", config.DriverPort),
+					}))
+				})
+
+				It("errors when no ConfigMap getter is set", func() {
+					builder.SetConfigMapGetter(nil)
+
+					_, err := builder.PodForClient(client)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		It("sets an active deadline matching the test timeout", func() {
+			testSpec.TimeoutSeconds = 900
+			pod, err := builder.PodForClient(client)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.ActiveDeadlineSeconds).ToNot(BeNil())
+			Expect(*pod.Spec.ActiveDeadlineSeconds).To(Equal(int64(900)))
+		})
+
+		It("does not set an active deadline when the test has no timeout", func() {
+			testSpec.TimeoutSeconds = 0
+			pod, err := builder.PodForClient(client)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.ActiveDeadlineSeconds).To(BeNil())
+		})
+
+		Context("colocation", func() {
+			It("does not set a pod affinity by default", func() {
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pod.Spec.Affinity.PodAffinity).To(BeNil())
+			})
+
+			It("requires a server of the same test on the same node", func() {
+				testSpec.Affinity = &grpcv1.Affinity{Colocation: grpcv1.NodeColocation}
+
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue("pool", *client.Pool))
+				Expect(pod.Spec.Affinity.PodAffinity).ToNot(BeNil())
+
+				terms := pod.Spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+				Expect(terms).To(HaveLen(1))
+				Expect(terms[0].TopologyKey).To(Equal("kubernetes.io/hostname"))
+				Expect(terms[0].LabelSelector.MatchLabels).To(Equal(map[string]string{
+					config.LoadTestLabel: test.Name,
+					config.RoleLabel:     config.ServerRole,
+				}))
+
+				antiAffinityTerms := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+				Expect(antiAffinityTerms[0].LabelSelector.MatchExpressions).To(ContainElement(metav1.LabelSelectorRequirement{
+					Key:      config.LoadTestLabel,
+					Operator: metav1.LabelSelectorOpNotIn,
+					Values:   []string{test.Name},
+				}))
+			})
+
+			It("requires a server of the same test in the same zone", func() {
+				testSpec.Affinity = &grpcv1.Affinity{Colocation: grpcv1.ZoneColocation}
+
+				pod, err := builder.PodForClient(client)
+				Expect(err).ToNot(HaveOccurred())
+
+				terms := pod.Spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+				Expect(terms).To(HaveLen(1))
+				Expect(terms[0].TopologyKey).To(Equal("topology.kubernetes.io/zone"))
+				Expect(terms[0].LabelSelector.MatchLabels).To(HaveKeyWithValue(config.LoadTestLabel, test.Name))
+
+				antiAffinityTerms := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+				Expect(antiAffinityTerms[0].LabelSelector.MatchExpressions).To(HaveLen(1))
+			})
 		})
 
 		It("sets a pod anti-affinity", func() {
@@ -609,6 +888,36 @@ var _ = Describe("PodBuilder", func() {
 			})
 		})
 
+		It("sets an active deadline matching the test timeout", func() {
+			testSpec.TimeoutSeconds = 900
+			pod, err := builder.PodForServer(server)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.ActiveDeadlineSeconds).ToNot(BeNil())
+			Expect(*pod.Spec.ActiveDeadlineSeconds).To(Equal(int64(900)))
+		})
+
+		It("does not set an active deadline when the test has no timeout", func() {
+			testSpec.TimeoutSeconds = 0
+			pod, err := builder.PodForServer(server)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.ActiveDeadlineSeconds).To(BeNil())
+		})
+
+		It("allows pods of the same test on its node when colocated", func() {
+			testSpec.Affinity = &grpcv1.Affinity{Colocation: grpcv1.NodeColocation}
+
+			pod, err := builder.PodForServer(server)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Affinity.PodAffinity).To(BeNil())
+
+			antiAffinityTerms := pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+			Expect(antiAffinityTerms[0].LabelSelector.MatchExpressions).To(ContainElement(metav1.LabelSelectorRequirement{
+				Key:      config.LoadTestLabel,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{test.Name},
+			}))
+		})
+
 		It("sets a pod anti-affinity", func() {
 			// Note: this is a simple test to ensure the anti-affinity is set.
 			// It does not confirm its properties are correct. This check is
@@ -642,6 +951,24 @@ var _ = Describe("PodBuilder", func() {
 			Expect(testName).To(Equal(test.Name))
 		})
 
+		It("sets a label with the UID of the load test when configured", func() {
+			test.UID = "6f1c8f2e-3f8e-4c1a-9f0e-0d6d2b1b7a54"
+			defaults.LoadTestLabelValue = config.LoadTestLabelValueUID
+
+			pod, err := builder.PodForDriver(driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.ObjectMeta.Labels[config.LoadTestLabel]).To(Equal(string(test.UID)))
+
+			for _, container := range pod.Spec.InitContainers {
+				if container.Name != config.ReadyInitContainerName {
+					continue
+				}
+				for _, arg := range container.Args {
+					Expect(arg).To(HavePrefix(config.LoadTestLabel + "=" + string(test.UID) + ","))
+				}
+			}
+		})
+
 		It("sets a label indicating it is a driver", func() {
 			pod, err := builder.PodForDriver(driver)
 			Expect(err).ToNot(HaveOccurred())
@@ -691,6 +1018,40 @@ var _ = Describe("PodBuilder", func() {
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("mounts the scenarios and points the driver to them", func() {
+			pod, err := builder.PodForDriver(driver)
+			Expect(err).ToNot(HaveOccurred())
+
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "scenarios",
+				MountPath: config.ScenariosMountPath,
+				ReadOnly:  true,
+			}))
+			Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+				Name:  config.ScenariosFileEnv,
+				Value: config.ScenariosMountPath + "/scenarios.json",
+			}))
+		})
+
+		It("uses the scenarios mount path of the defaults", func() {
+			defaults.ScenariosMountPath = "/etc/grpc/scenarios/"
+
+			pod, err := builder.PodForDriver(driver)
+			Expect(err).ToNot(HaveOccurred())
+
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "scenarios",
+				MountPath: "/etc/grpc/scenarios/",
+				ReadOnly:  true,
+			}))
+			Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+				Name:  config.ScenariosFileEnv,
+				Value: "/etc/grpc/scenarios/scenarios.json",
+			}))
+		})
+
 		Context("clone init container", func() {
 			It("contains an init container named clone when clone instructions are present", func() {
 				driver.Clone = new(grpcv1.Clone)
@@ -850,6 +1211,25 @@ var _ = Describe("PodBuilder", func() {
 			})
 		})
 
+		It("sets an active deadline longer than the test timeout", func() {
+			testSpec.TimeoutSeconds = 900
+			pod, err := builder.PodForDriver(driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.ActiveDeadlineSeconds).ToNot(BeNil())
+			Expect(*pod.Spec.ActiveDeadlineSeconds).To(BeNumerically(">", 900))
+
+			workerPod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*pod.Spec.ActiveDeadlineSeconds).To(BeNumerically(">", *workerPod.Spec.ActiveDeadlineSeconds))
+		})
+
+		It("does not set an active deadline when the test has no timeout", func() {
+			testSpec.TimeoutSeconds = 0
+			pod, err := builder.PodForDriver(driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.ActiveDeadlineSeconds).To(BeNil())
+		})
+
 		It("sets a pod anti-affinity", func() {
 			// Note: this is a simple test to ensure the anti-affinity is set.
 			// It does not confirm its properties are correct. This check is
@@ -859,5 +1239,963 @@ var _ = Describe("PodBuilder", func() {
 			Expect(pod.Spec.Affinity).ToNot(BeNil())
 			Expect(pod.Spec.Affinity.PodAntiAffinity).ToNot((BeNil()))
 		})
+
+		Context("results partitioning", func() {
+			It("sets the partition field and type on the run container", func() {
+				testSpec.Results = &grpcv1.Results{
+					BigQueryTable:  optional.StringPtr("grpc-testing.e2e_benchmark.results"),
+					PartitionField: optional.StringPtr("start_time"),
+					PartitionType:  grpcv1.DayPartition,
+				}
+				pod, err := builder.PodForDriver(driver)
+				Expect(err).ToNot(HaveOccurred())
+
+				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+				Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name:  config.BigQueryPartitionFieldEnv,
+					Value: "start_time",
+				}))
+				Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name:  config.BigQueryPartitionTypeEnv,
+					Value: "DAY",
+				}))
+			})
+
+			It("does not set partition env variables without partitioning", func() {
+				testSpec.Results = &grpcv1.Results{
+					BigQueryTable: optional.StringPtr("grpc-testing.e2e_benchmark.results"),
+				}
+				pod, err := builder.PodForDriver(driver)
+				Expect(err).ToNot(HaveOccurred())
+
+				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+				for _, env := range runContainer.Env {
+					Expect(env.Name).ToNot(Equal(config.BigQueryPartitionFieldEnv))
+					Expect(env.Name).ToNot(Equal(config.BigQueryPartitionTypeEnv))
+				}
+			})
+		})
+
+		Context("results bucket", func() {
+			It("sets the bucket and prefix on the run container", func() {
+				testSpec.Results = &grpcv1.Results{
+					GCSBucket: optional.StringPtr("grpc-testing-results"),
+					GCSPrefix: optional.StringPtr("results/master/"),
+				}
+				pod, err := builder.PodForDriver(driver)
+				Expect(err).ToNot(HaveOccurred())
+
+				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+				Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name:  config.GCSBucketEnv,
+					Value: "grpc-testing-results",
+				}))
+				Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name:  config.GCSPrefixEnv,
+					Value: "results/master/",
+				}))
+			})
+
+			It("does not set bucket env variables when only BigQuery is set", func() {
+				testSpec.Results = &grpcv1.Results{
+					BigQueryTable: optional.StringPtr("grpc-testing.e2e_benchmark.results"),
+				}
+				pod, err := builder.PodForDriver(driver)
+				Expect(err).ToNot(HaveOccurred())
+
+				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+				for _, env := range runContainer.Env {
+					Expect(env.Name).ToNot(Equal(config.GCSBucketEnv))
+					Expect(env.Name).ToNot(Equal(config.GCSPrefixEnv))
+				}
+			})
+
+			It("returns an error for an invalid bucket", func() {
+				invalidBuckets := map[string]string{
+					"ab":             "3 to 222 characters",
+					"Results":        "only lowercase letters",
+					"results-":       "start and end with a letter or number",
+					"google-results": "must not start with \"goog\"",
+					"192.168.5.4":    "must not be an IP address",
+				}
+				invalidBuckets[fmt.Sprintf("%064d.results", 0)] = "1 to 63 characters"
+				for bucket, message := range invalidBuckets {
+					testSpec.Results = &grpcv1.Results{GCSBucket: optional.StringPtr(bucket)}
+					_, err := builder.PodForDriver(driver)
+					Expect(err).To(MatchError(ContainSubstring(errGCSBucket.Error())), "bucket named %q", bucket)
+					Expect(err).To(MatchError(ContainSubstring(message)), "bucket named %q", bucket)
+				}
+			})
+
+			It("returns an error when the prefix is set without a bucket", func() {
+				testSpec.Results = &grpcv1.Results{GCSPrefix: optional.StringPtr("results/")}
+				_, err := builder.PodForDriver(driver)
+				Expect(err).To(MatchError(ContainSubstring("set without a bucket")))
+			})
+
+			It("returns an error when the prefix starts with a slash", func() {
+				testSpec.Results = &grpcv1.Results{
+					GCSBucket: optional.StringPtr("grpc-testing-results"),
+					GCSPrefix: optional.StringPtr("/results/"),
+				}
+				_, err := builder.PodForDriver(driver)
+				Expect(err).To(MatchError(ContainSubstring("must not start with a slash")))
+			})
+		})
+
+		Context("results token", func() {
+			findVolume := func(pod *corev1.Pod) *corev1.Volume {
+				for i := range pod.Spec.Volumes {
+					if pod.Spec.Volumes[i].Name == config.ResultsTokenVolumeName {
+						return &pod.Spec.Volumes[i]
+					}
+				}
+				return nil
+			}
+
+			It("does not add a token volume without an audience", func() {
+				testSpec.Results = &grpcv1.Results{}
+				pod, err := builder.PodForDriver(driver)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(findVolume(pod)).To(BeNil())
+			})
+
+			It("adds a projected token volume with the audience", func() {
+				audience := "//iam.googleapis.com/projects/123/locations/global/workloadIdentityPools/pool/providers/provider"
+				testSpec.Results = &grpcv1.Results{Audience: optional.StringPtr(audience)}
+				pod, err := builder.PodForDriver(driver)
+				Expect(err).ToNot(HaveOccurred())
+
+				volume := findVolume(pod)
+				Expect(volume).ToNot(BeNil())
+				Expect(volume.Projected).ToNot(BeNil())
+				Expect(volume.Projected.Sources).To(HaveLen(1))
+				token := volume.Projected.Sources[0].ServiceAccountToken
+				Expect(token).ToNot(BeNil())
+				Expect(token.Audience).To(Equal(audience))
+				Expect(token.Path).To(Equal(config.ResultsTokenPath))
+
+				runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+				Expect(runContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+					Name:      config.ResultsTokenVolumeName,
+					MountPath: config.ResultsTokenMountPath,
+					ReadOnly:  true,
+				}))
+				Expect(runContainer.Env).To(ContainElement(corev1.EnvVar{
+					Name:  config.ResultsTokenFileEnv,
+					Value: config.ResultsTokenMountPath + "/" + config.ResultsTokenPath,
+				}))
+			})
+
+			It("does not add a token volume to workers", func() {
+				testSpec.Results = &grpcv1.Results{Audience: optional.StringPtr("https://example.com")}
+
+				clientPod, err := builder.PodForClient(&testSpec.Clients[0])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(findVolume(clientPod)).To(BeNil())
+
+				serverPod, err := builder.PodForServer(&testSpec.Servers[0])
+				Expect(err).ToNot(HaveOccurred())
+				Expect(findVolume(serverPod)).To(BeNil())
+			})
+
+			It("returns an error when the audience has no host", func() {
+				testSpec.Results = &grpcv1.Results{Audience: optional.StringPtr("results-uploader")}
+				_, err := builder.PodForDriver(driver)
+				Expect(err).To(MatchError(ContainSubstring("has no host")))
+			})
+
+			It("returns an error when the audience contains whitespace", func() {
+				testSpec.Results = &grpcv1.Results{Audience: optional.StringPtr("https://example.com/a b")}
+				_, err := builder.PodForDriver(driver)
+				Expect(err).To(MatchError(ContainSubstring("contains whitespace")))
+			})
+		})
+	})
+
+	Describe("clone credentials", func() {
+		var client *grpcv1.Client
+
+		BeforeEach(func() {
+			client = &testSpec.Clients[0]
+			client.Clone = &grpcv1.Clone{
+				Repo:                  optional.StringPtr("git@github.com:example/grpc.git"),
+				GitRef:                optional.StringPtr("master"),
+				CredentialsSecretName: optional.StringPtr("git-credentials"),
+			}
+			builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+				if name != "git-credentials" {
+					return nil, kerrors.NewNotFound(corev1.Resource("secrets"), name)
+				}
+				return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+			})
+		})
+
+		It("mounts the secret in the clone container", func() {
+			pod, err := builder.PodForClient(client)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pod.Spec.Volumes).To(ContainElement(WithTransform(func(v corev1.Volume) string {
+				if v.Secret == nil {
+					return ""
+				}
+				return v.Name + "=" + v.Secret.SecretName
+			}, Equal(config.CloneCredentialsVolumeName+"=git-credentials"))))
+
+			cloneContainer := kubehelpers.ContainerForName(config.CloneInitContainerName, pod.Spec.InitContainers)
+			Expect(cloneContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      config.CloneCredentialsVolumeName,
+				MountPath: config.CloneCredentialsMountPath,
+				ReadOnly:  true,
+			}))
+			Expect(cloneContainer.Env).To(ContainElement(corev1.EnvVar{
+				Name:  config.CloneCredentialsDirEnv,
+				Value: config.CloneCredentialsMountPath,
+			}))
+
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			for _, mount := range runContainer.VolumeMounts {
+				Expect(mount.Name).ToNot(Equal(config.CloneCredentialsVolumeName))
+			}
+		})
+
+		It("uses the mount path of the defaults", func() {
+			defaults.CloneCredentialsMountPath = "/etc/git-secret"
+			pod, err := builder.PodForClient(client)
+			Expect(err).ToNot(HaveOccurred())
+
+			cloneContainer := kubehelpers.ContainerForName(config.CloneInitContainerName, pod.Spec.InitContainers)
+			Expect(cloneContainer.Env).To(ContainElement(corev1.EnvVar{
+				Name:  config.CloneCredentialsDirEnv,
+				Value: "/etc/git-secret",
+			}))
+		})
+
+		It("does not mount credentials when no secret is set", func() {
+			client.Clone.CredentialsSecretName = nil
+			pod, err := builder.PodForClient(client)
+			Expect(err).ToNot(HaveOccurred())
+			for _, volume := range pod.Spec.Volumes {
+				Expect(volume.Name).ToNot(Equal(config.CloneCredentialsVolumeName))
+			}
+		})
+
+		It("returns an error when the secret does not exist", func() {
+			client.Clone.CredentialsSecretName = optional.StringPtr("missing")
+			_, err := builder.PodForClient(client)
+			Expect(err).To(MatchError(ContainSubstring(errCloneCredentials.Error())))
+			Expect(err).To(MatchError(ContainSubstring(`"missing"`)))
+			Expect(IsLookupError(err)).To(BeFalse())
+		})
+
+		It("returns a lookup error when the secret cannot be fetched", func() {
+			builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+				return nil, kerrors.NewServiceUnavailable("try again")
+			})
+			_, err := builder.PodForClient(client)
+			Expect(err).ToNot(MatchError(ContainSubstring(errCloneCredentials.Error())))
+			Expect(IsLookupError(err)).To(BeTrue())
+		})
+
+		It("returns an error when no secret getter is set", func() {
+			builder = New(defaults, test)
+			_, err := builder.PodForClient(client)
+			Expect(err).To(MatchError(ContainSubstring(errCloneCredentials.Error())))
+		})
+	})
+
+	Describe("driver port args", func() {
+		It("accepts args that set the declared port", func() {
+			testSpec.Clients[0].Run.Args = []string{"--driver_port=10000"}
+			testSpec.Servers[0].Run.Args = []string{"-driver_port", "10000", "--server_port=10010"}
+
+			clientPod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, clientPod.Spec.Containers)
+			Expect(runContainer.Args).To(Equal([]string{"--driver_port=10000"}))
+
+			serverPod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			runContainer = kubehelpers.ContainerForName(config.RunContainerName, serverPod.Spec.Containers)
+			Expect(runContainer.Args).To(Equal([]string{"-driver_port", "10000", "--server_port=10010"}))
+		})
+
+		It("adds the port when the args do not set it", func() {
+			testSpec.Servers[0].Run.Args = []string{"--server_port=10010"}
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.Args).To(Equal([]string{"--server_port=10010", "--driver_port=10000"}))
+		})
+
+		It("returns an error when the args set a different port", func() {
+			testSpec.Clients[0].Run.Args = []string{"--driver_port=10010"}
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).To(MatchError(ContainSubstring(errPortArg.Error())))
+			Expect(err).To(MatchError(ContainSubstring("--driver_port=10010")))
+		})
+
+		It("returns an error when a separate value sets a different port", func() {
+			testSpec.Servers[0].Run.Args = []string{"--driver_port", "10010"}
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).To(MatchError(ContainSubstring(errPortArg.Error())))
+		})
+
+		It("returns an error when args from a ConfigMap set a different port", func() {
+			testSpec.Servers[0].Run.ArgsFrom = &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "server-args"},
+				Key:                  "args",
+			}
+			builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+				return &corev1.ConfigMap{Data: map[string]string{"args": "--driver_port=9999"}}, nil
+			})
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).To(MatchError(ContainSubstring(errPortArg.Error())))
+		})
+
+		It("returns an error when a server sets its server port to the driver port", func() {
+			testSpec.Servers[0].Run.Args = []string{"--server_port=10000"}
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).To(MatchError(ContainSubstring(errPortArg.Error())))
+			Expect(err).To(MatchError(ContainSubstring("--server_port=10000")))
+		})
+
+		It("ignores flags with a similar name", func() {
+			testSpec.Clients[0].Run.Args = []string{"--driver_port_override=10010"}
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("DNS settings", func() {
+		It("sets the DNS config and policy when provided", func() {
+			ndots := "2"
+			dnsConfig := &corev1.PodDNSConfig{
+				Searches: []string{"svc.cluster.local"},
+				Options:  []corev1.PodDNSConfigOption{{Name: "ndots", Value: &ndots}},
+			}
+			dnsPolicy := corev1.DNSDefault
+			testSpec.Clients[0].DNSConfig = dnsConfig
+			testSpec.Clients[0].DNSPolicy = &dnsPolicy
+			testSpec.Servers[0].DNSConfig = dnsConfig
+			testSpec.Driver.DNSPolicy = &dnsPolicy
+
+			clientPod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(clientPod.Spec.DNSConfig).To(Equal(dnsConfig))
+			Expect(clientPod.Spec.DNSPolicy).To(Equal(corev1.DNSDefault))
+
+			serverPod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serverPod.Spec.DNSConfig).To(Equal(dnsConfig))
+			Expect(serverPod.Spec.DNSPolicy).To(BeEmpty())
+
+			driverPod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(driverPod.Spec.DNSConfig).To(BeNil())
+			Expect(driverPod.Spec.DNSPolicy).To(Equal(corev1.DNSDefault))
+		})
+
+		It("leaves the DNS settings to Kubernetes when not provided", func() {
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.DNSConfig).To(BeNil())
+			Expect(pod.Spec.DNSPolicy).To(BeEmpty())
+		})
+
+		It("returns an error when the policy is None without nameservers", func() {
+			dnsPolicy := corev1.DNSNone
+			testSpec.Servers[0].DNSPolicy = &dnsPolicy
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).To(MatchError(ContainSubstring(errDNS.Error())))
+
+			testSpec.Servers[0].DNSConfig = &corev1.PodDNSConfig{Nameservers: []string{"10.0.0.10"}}
+			_, err = builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("affinity", func() {
+		zoneTerm := corev1.NodeSelectorRequirement{
+			Key:      "topology.kubernetes.io/zone",
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{"us-central1-a", "us-central1-b"},
+		}
+
+		It("merges the affinity of the component into the pod", func() {
+			spread := corev1.WeightedPodAffinityTerm{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"role": "client"}},
+					TopologyKey:   "topology.kubernetes.io/zone",
+				},
+			}
+			testSpec.Clients[0].Affinity = &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{
+							{MatchExpressions: []corev1.NodeSelectorRequirement{zoneTerm}},
+						},
+					},
+				},
+				PodAntiAffinity: &corev1.PodAntiAffinity{
+					PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{spread},
+				},
+			}
+
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.Warnings()).To(BeEmpty())
+			Expect(pod.Spec.NodeSelector).To(HaveKey("pool"))
+
+			nodeTerms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			Expect(nodeTerms).To(Equal([]corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{zoneTerm}},
+			}))
+
+			antiAffinity := pod.Spec.Affinity.PodAntiAffinity
+			Expect(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+			Expect(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(Equal([]corev1.WeightedPodAffinityTerm{spread}))
+		})
+
+		It("removes requirements that conflict with the pool and warns", func() {
+			testSpec.Servers[0].Pool = optional.StringPtr("testing-pool")
+			testSpec.Servers[0].Affinity = &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{
+							{
+								MatchExpressions: []corev1.NodeSelectorRequirement{
+									{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"other-pool"}},
+									zoneTerm,
+								},
+							},
+							{
+								MatchExpressions: []corev1.NodeSelectorRequirement{
+									{Key: "pool", Operator: corev1.NodeSelectorOpDoesNotExist},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue("pool", "testing-pool"))
+			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms).To(Equal([]corev1.NodeSelectorTerm{
+				{MatchExpressions: []corev1.NodeSelectorRequirement{zoneTerm}},
+			}))
+			Expect(builder.Warnings()).To(HaveLen(2))
+
+			driverPod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(driverPod.Spec.Affinity.NodeAffinity).To(BeNil())
+			Expect(builder.Warnings()).To(BeEmpty())
+		})
+
+		It("keeps requirements on the pool that match it", func() {
+			testSpec.Clients[0].Pool = optional.StringPtr("testing-pool")
+			testSpec.Clients[0].Affinity = &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{
+							{
+								MatchExpressions: []corev1.NodeSelectorRequirement{
+									{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"testing-pool"}},
+									{Key: "pool", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"other-pool"}},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.Warnings()).To(BeEmpty())
+			Expect(pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions).To(HaveLen(2))
+		})
+	})
+
+	Describe("sidecars", func() {
+		It("appends sidecars after the run container", func() {
+			sidecar := corev1.Container{
+				Name:  "proxy",
+				Image: "envoyproxy/envoy:v1.17.0",
+				Args:  []string{"--config-path", "/etc/envoy/envoy.yaml"},
+			}
+			testSpec.Clients[0].Run.Sidecars = []corev1.Container{sidecar}
+
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Containers).To(HaveLen(2))
+			Expect(pod.Spec.Containers[0].Name).To(Equal(config.RunContainerName))
+			Expect(pod.Spec.Containers[1]).To(Equal(sidecar))
+		})
+
+		It("returns an error when a sidecar has the name of another container", func() {
+			for _, name := range []string{config.RunContainerName, config.CloneInitContainerName, config.BuildInitContainerName, config.ReadyInitContainerName} {
+				testSpec.Servers[0].Run.Sidecars = []corev1.Container{{Name: name, Image: "busybox"}}
+				_, err := builder.PodForServer(&testSpec.Servers[0])
+				Expect(err).To(MatchError(ContainSubstring(errSidecar.Error())), "sidecar named %q", name)
+			}
+		})
+
+		It("returns an error when sidecars share a name", func() {
+			testSpec.Driver.Run.Sidecars = []corev1.Container{
+				{Name: "scraper", Image: "busybox"},
+				{Name: "scraper", Image: "busybox"},
+			}
+			_, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).To(MatchError(ContainSubstring(errSidecar.Error())))
+		})
+
+		It("returns an error when a sidecar has no name", func() {
+			testSpec.Clients[0].Run.Sidecars = []corev1.Container{{Image: "busybox"}}
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).To(MatchError(ContainSubstring(errSidecar.Error())))
+		})
+	})
+
+	Describe("image pull secrets", func() {
+		buildPods := func() []*corev1.Pod {
+			clientPod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			serverPod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			driverPod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			return []*corev1.Pod{clientPod, serverPod, driverPod}
+		}
+
+		It("omits the field when there are no secrets", func() {
+			for _, pod := range buildPods() {
+				Expect(pod.Spec.ImagePullSecrets).To(BeNil())
+			}
+		})
+
+		It("sets the default secrets", func() {
+			defaults.ImagePullSecrets = []string{"registry-a", "registry-b"}
+			for _, pod := range buildPods() {
+				Expect(pod.Spec.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{
+					{Name: "registry-a"},
+					{Name: "registry-b"},
+				}))
+			}
+		})
+
+		It("prefers the secrets of the test over the defaults", func() {
+			defaults.ImagePullSecrets = []string{"registry-a"}
+			testSpec.ImagePullSecrets = []string{"registry-c"}
+			for _, pod := range buildPods() {
+				Expect(pod.Spec.ImagePullSecrets).To(Equal([]corev1.LocalObjectReference{
+					{Name: "registry-c"},
+				}))
+			}
+		})
+	})
+
+	Describe("tolerations", func() {
+		spotToleration := corev1.Toleration{
+			Key:      "cloud.google.com/gke-spot",
+			Operator: corev1.TolerationOpEqual,
+			Value:    "true",
+			Effect:   corev1.TaintEffectNoSchedule,
+		}
+
+		buildPods := func() []*corev1.Pod {
+			clientPod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			serverPod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			driverPod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			return []*corev1.Pod{clientPod, serverPod, driverPod}
+		}
+
+		It("omits the field when there are no tolerations", func() {
+			for _, pod := range buildPods() {
+				Expect(pod.Spec.Tolerations).To(BeNil())
+			}
+		})
+
+		It("omits the field when the default tolerations are empty", func() {
+			defaults.DefaultTolerations = []corev1.Toleration{}
+			for _, pod := range buildPods() {
+				Expect(pod.Spec.Tolerations).To(BeNil())
+			}
+		})
+
+		It("sets the default tolerations", func() {
+			defaults.DefaultTolerations = []corev1.Toleration{spotToleration}
+			for _, pod := range buildPods() {
+				Expect(pod.Spec.Tolerations).To(Equal([]corev1.Toleration{spotToleration}))
+			}
+		})
+
+		It("prefers the tolerations of a component over the defaults", func() {
+			preemptibleToleration := corev1.Toleration{
+				Key:      "cloud.google.com/gke-preemptible",
+				Operator: corev1.TolerationOpExists,
+			}
+			defaults.DefaultTolerations = []corev1.Toleration{spotToleration}
+			testSpec.Clients[0].Tolerations = []corev1.Toleration{preemptibleToleration}
+
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Tolerations).To(Equal([]corev1.Toleration{preemptibleToleration}))
+
+			pod, err = builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Tolerations).To(Equal([]corev1.Toleration{spotToleration}))
+		})
+
+		It("uses the defaults when a component has an empty list of tolerations", func() {
+			defaults.DefaultTolerations = []corev1.Toleration{spotToleration}
+			testSpec.Servers[0].Tolerations = []corev1.Toleration{}
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Tolerations).To(Equal([]corev1.Toleration{spotToleration}))
+		})
+
+		It("does not share the default tolerations between pods", func() {
+			defaults.DefaultTolerations = []corev1.Toleration{spotToleration}
+			pods := buildPods()
+			pods[0].Spec.Tolerations[0].Value = "false"
+			Expect(defaults.DefaultTolerations[0].Value).To(Equal("true"))
+			Expect(pods[1].Spec.Tolerations[0].Value).To(Equal("true"))
+		})
+	})
+
+	Describe("volumes", func() {
+		scratchVolume := corev1.Volume{
+			Name: "scratch",
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		}
+		scratchMount := corev1.VolumeMount{
+			Name:      "scratch",
+			MountPath: "/tmp/scratch",
+		}
+
+		It("adds the volumes and mounts of the component", func() {
+			testSpec.Clients[0].Volumes = []corev1.Volume{scratchVolume}
+			testSpec.Clients[0].Run.VolumeMounts = []corev1.VolumeMount{scratchMount}
+
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Volumes).To(ContainElement(scratchVolume))
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.VolumeMounts).To(ContainElement(scratchMount))
+		})
+
+		It("keeps the scenarios volume of the driver", func() {
+			testSpec.Driver.Volumes = []corev1.Volume{scratchVolume}
+			testSpec.Driver.Run.VolumeMounts = []corev1.VolumeMount{scratchMount}
+
+			pod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.Volumes).To(ContainElement(scratchVolume))
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.VolumeMounts).To(ContainElement(scratchMount))
+			Expect(pod.Spec.Volumes).To(ContainElement(corev1.Volume{
+				Name: "scenarios",
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: test.Name,
+						},
+					},
+				},
+			}))
+		})
+
+		It("errors when a volume has the name of a volume the controller adds", func() {
+			testSpec.Driver.Volumes = []corev1.Volume{{Name: "scenarios"}}
+
+			_, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).To(MatchError(ContainSubstring(errVolume.Error())))
+		})
+
+		It("errors when a mount references a volume that is not declared", func() {
+			testSpec.Servers[0].Run.VolumeMounts = []corev1.VolumeMount{scratchMount}
+
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).To(MatchError(ContainSubstring(errVolume.Error())))
+		})
+	})
+
+	Describe("env from ConfigMaps and Secrets", func() {
+		var configMapEnv, secretEnv corev1.EnvVar
+
+		BeforeEach(func() {
+			configMapEnv = corev1.EnvVar{
+				Name: "CONFIG_VALUE",
+				ValueFrom: &corev1.EnvVarSource{
+					ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "settings"},
+						Key:                  "value",
+					},
+				},
+			}
+			secretEnv = corev1.EnvVar{
+				Name: "API_TOKEN",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "tokens"},
+						Key:                  "api",
+					},
+				},
+			}
+			builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+				if name != "settings" {
+					return nil, kerrors.NewNotFound(corev1.Resource("configmaps"), name)
+				}
+				return &corev1.ConfigMap{Data: map[string]string{"value": "42"}}, nil
+			})
+			builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+				if name != "tokens" {
+					return nil, kerrors.NewNotFound(corev1.Resource("secrets"), name)
+				}
+				return &corev1.Secret{Data: map[string][]byte{"api": []byte("secret")}}, nil
+			})
+		})
+
+		It("accepts references to keys that exist", func() {
+			testSpec.Clients[0].Run.Env = []corev1.EnvVar{configMapEnv, secretEnv}
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.Env).To(ContainElement(secretEnv))
+		})
+
+		It("ignores variables with plain values", func() {
+			builder.SetConfigMapGetter(nil)
+			builder.SetSecretGetter(nil)
+			testSpec.Servers[0].Run.Env = []corev1.EnvVar{{Name: "PLAIN", Value: "value"}}
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error naming a missing ConfigMap key", func() {
+			configMapEnv.ValueFrom.ConfigMapKeyRef.Key = "missing"
+			testSpec.Servers[0].Run.Env = []corev1.EnvVar{configMapEnv}
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).To(MatchError(ContainSubstring(errEnvFrom.Error())))
+			Expect(err).To(MatchError(ContainSubstring(`no key "missing"`)))
+		})
+
+		It("returns an error naming a missing Secret", func() {
+			secretEnv.ValueFrom.SecretKeyRef.Name = "other-tokens"
+			testSpec.Driver.Run.Env = []corev1.EnvVar{secretEnv}
+			_, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).To(MatchError(ContainSubstring(errEnvFrom.Error())))
+			Expect(err).To(MatchError(ContainSubstring(`Secret "other-tokens"`)))
+			Expect(IsLookupError(err)).To(BeFalse())
+		})
+
+		It("returns a lookup error when a ConfigMap cannot be fetched", func() {
+			builder.SetConfigMapGetter(func(name string) (*corev1.ConfigMap, error) {
+				return nil, kerrors.NewServiceUnavailable("try again")
+			})
+			testSpec.Servers[0].Run.Env = []corev1.EnvVar{configMapEnv}
+			_, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(MatchError(ContainSubstring(errEnvFrom.Error())))
+			Expect(IsLookupError(err)).To(BeTrue())
+		})
+
+		It("returns a lookup error when a Secret cannot be fetched", func() {
+			builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+				return nil, kerrors.NewServiceUnavailable("try again")
+			})
+			testSpec.Driver.Run.Env = []corev1.EnvVar{secretEnv}
+			_, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(MatchError(ContainSubstring(errEnvFrom.Error())))
+			Expect(IsLookupError(err)).To(BeTrue())
+		})
+
+		It("checks the env of sidecars", func() {
+			secretEnv.ValueFrom.SecretKeyRef.Key = "missing"
+			testSpec.Clients[0].Run.Sidecars = []corev1.Container{{Name: "proxy", Env: []corev1.EnvVar{secretEnv}}}
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).To(MatchError(ContainSubstring(errEnvFrom.Error())))
+			Expect(err).To(MatchError(ContainSubstring(`container "proxy"`)))
+		})
+
+		It("does not return an error for optional references", func() {
+			optional := true
+			configMapEnv.ValueFrom.ConfigMapKeyRef.Name = "missing"
+			configMapEnv.ValueFrom.ConfigMapKeyRef.Optional = &optional
+			secretEnv.ValueFrom.SecretKeyRef.Key = "missing"
+			secretEnv.ValueFrom.SecretKeyRef.Optional = &optional
+			testSpec.Clients[0].Run.Env = []corev1.EnvVar{configMapEnv, secretEnv}
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("node selector", func() {
+		gpuSelector := map[string]string{"cloud.google.com/gke-accelerator": "nvidia-tesla-t4"}
+
+		It("adds the labels of the component to the pool label", func() {
+			testSpec.Servers[0].NodeSelector = gpuSelector
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue("cloud.google.com/gke-accelerator", "nvidia-tesla-t4"))
+			Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue(config.PoolLabel, *testSpec.Servers[0].Pool))
+		})
+
+		It("adds the labels of the component to the default pool label", func() {
+			testSpec.Driver.Pool = nil
+			testSpec.Driver.NodeSelector = gpuSelector
+
+			pod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pod.Spec.NodeSelector).To(HaveLen(2))
+			Expect(pod.Spec.NodeSelector).To(HaveKeyWithValue("cloud.google.com/gke-accelerator", "nvidia-tesla-t4"))
+		})
+
+		It("returns an error when the component sets the pool label", func() {
+			testSpec.Clients[0].NodeSelector = map[string]string{config.PoolLabel: "other-pool"}
+
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).To(MatchError(ContainSubstring(errNodeSelector.Error())))
+		})
+
+		It("returns an error when the component sets the default pool label", func() {
+			testSpec.Clients[0].Pool = nil
+			testSpec.Clients[0].NodeSelector = map[string]string{builder.defaults.DefaultPoolLabels.Client: "false"}
+
+			_, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).To(MatchError(ContainSubstring(errNodeSelector.Error())))
+		})
+	})
+
+	Describe("working directories", func() {
+		It("defaults to the workspace", func() {
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			buildContainer := kubehelpers.ContainerForName(config.BuildInitContainerName, pod.Spec.InitContainers)
+			Expect(buildContainer.WorkingDir).To(Equal(config.WorkspaceMountPath))
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.WorkingDir).To(Equal(config.WorkspaceMountPath))
+		})
+
+		It("resolves relative directories against the workspace", func() {
+			testSpec.Servers[0].Build.WorkingDir = optional.StringPtr("src/cpp")
+			testSpec.Servers[0].Run.WorkingDir = optional.StringPtr("./bazel-bin/test/cpp/qps/")
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			buildContainer := kubehelpers.ContainerForName(config.BuildInitContainerName, pod.Spec.InitContainers)
+			Expect(buildContainer.WorkingDir).To(Equal(config.WorkspaceMountPath + "/src/cpp"))
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.WorkingDir).To(Equal(config.WorkspaceMountPath + "/bazel-bin/test/cpp/qps"))
+		})
+
+		It("keeps absolute directories", func() {
+			testSpec.Servers[0].Run.WorkingDir = optional.StringPtr("/tmp/output")
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+
+			runContainer := kubehelpers.ContainerForName(config.RunContainerName, pod.Spec.Containers)
+			Expect(runContainer.WorkingDir).To(Equal("/tmp/output"))
+		})
+	})
+
+	Describe("init steps", func() {
+		initContainerNames := func(pod *corev1.Pod) []string {
+			var names []string
+			for _, container := range pod.Spec.InitContainers {
+				names = append(names, container.Name)
+			}
+			return names
+		}
+		volumeNames := func(pod *corev1.Pod) []string {
+			var names []string
+			for _, volume := range pod.Spec.Volumes {
+				names = append(names, volume.Name)
+			}
+			return names
+		}
+
+		It("clones and builds by default", func() {
+			testSpec.Servers[0].Clone.CredentialsSecretName = optional.StringPtr("git-credentials")
+			builder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+				return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+			})
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(initContainerNames(pod)).To(Equal([]string{config.CloneInitContainerName, config.BuildInitContainerName}))
+			Expect(volumeNames(pod)).To(Equal([]string{config.WorkspaceVolumeName, config.BazelCacheVolumeName, config.CloneCredentialsVolumeName}))
+
+			explicitBuilder := New(defaults, test)
+			explicitBuilder.SetInitSteps(DefaultInitSteps())
+			explicitBuilder.SetSecretGetter(func(name string) (*corev1.Secret, error) {
+				return &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+			})
+			explicitPod, err := explicitBuilder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(explicitPod).To(Equal(pod))
+		})
+
+		It("adds the init containers and volumes of a registered step", func() {
+			builder.SetInitSteps(append(DefaultInitSteps(), datasetStep{}))
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(initContainerNames(pod)).To(Equal([]string{config.CloneInitContainerName, config.BuildInitContainerName, "dataset"}))
+			Expect(volumeNames(pod)).To(ContainElement("dataset"))
+		})
+
+		It("skips steps that do not apply to a component", func() {
+			builder.SetInitSteps(append(DefaultInitSteps(), datasetStep{}))
+
+			pod, err := builder.PodForClient(&testSpec.Clients[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(initContainerNames(pod)).To(Equal([]string{config.CloneInitContainerName, config.BuildInitContainerName}))
+			Expect(volumeNames(pod)).ToNot(ContainElement("dataset"))
+		})
+
+		It("runs the steps in the order they are set", func() {
+			builder.SetInitSteps([]InitStep{datasetStep{}, CloneStep{}})
+
+			pod, err := builder.PodForServer(&testSpec.Servers[0])
+			Expect(err).ToNot(HaveOccurred())
+			Expect(initContainerNames(pod)).To(Equal([]string{"dataset", config.CloneInitContainerName}))
+		})
+
+		It("keeps the ready init container of the driver last", func() {
+			testSpec.Driver.Clone = testSpec.Servers[0].Clone
+			builder.SetInitSteps(append(DefaultInitSteps(), datasetStep{}))
+
+			pod, err := builder.PodForDriver(testSpec.Driver)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(initContainerNames(pod)).To(Equal([]string{config.CloneInitContainerName, config.ReadyInitContainerName}))
+		})
 	})
 })
+
+// datasetStep is an init step that downloads a dataset for servers.
+type datasetStep struct{}
+
+func (datasetStep) InitContainer(defaults *config.Defaults, component *Component) (*corev1.Container, []corev1.Volume) {
+	if component.Role != config.ServerRole {
+		return nil, nil
+	}
+	return &corev1.Container{
+		Name:         "dataset",
+		Image:        "dataset-downloader",
+		VolumeMounts: []corev1.VolumeMount{{Name: "dataset", MountPath: "/data"}},
+	}, []corev1.Volume{
+		{Name: "dataset"},
+	}
+}
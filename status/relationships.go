@@ -25,7 +25,9 @@ import (
 
 // PodsForLoadTest returns a slice of pointers to pods which belong to a
 // specific load test. It accepts the load test to match and a list of all pods
-// to consider. If none of the pods match, an empty slice is returned.
+// to consider. A pod belongs to the test if its LoadTestLabel matches either
+// the name or the UID of the test. If none of the pods match, an empty slice
+// is returned.
 func PodsForLoadTest(loadtest *grpcv1.LoadTest, allPods []corev1.Pod) []*corev1.Pod {
 	if loadtest == nil {
 		return nil
@@ -37,7 +39,7 @@ func PodsForLoadTest(loadtest *grpcv1.LoadTest, allPods []corev1.Pod) []*corev1.
 		pod := &allPods[i]
 
 		parent, ok := pod.Labels[config.LoadTestLabel]
-		if ok && parent == loadtest.Name {
+		if ok && (parent == loadtest.Name || (loadtest.UID != "" && parent == string(loadtest.UID))) {
 			pods = append(pods, pod)
 		}
 	}
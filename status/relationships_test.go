@@ -19,6 +19,7 @@ package status
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 	"github.com/grpc/test-infra/config"
@@ -90,4 +91,58 @@ var _ = Describe("PodsForLoadTest", func() {
 		pods := PodsForLoadTest(test, allPods)
 		Expect(pods).To(ConsistOf(&allPods[0], &allPods[2]))
 	})
+
+	It("includes pods with labels matching the UID", func() {
+		test := new(grpcv1.LoadTest)
+		test.Name = "pods-matching-uid-loadtest"
+		test.UID = types.UID("6f1c8f2e-3f8e-4c1a-9f0e-0d6d2b1b7a54")
+
+		allPods := []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "good-pod-1",
+					Labels: map[string]string{
+						config.LoadTestLabel: string(test.UID),
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "bad-pod-1",
+					Labels: map[string]string{
+						config.LoadTestLabel: "0b9e4f3c-7a2d-4e4b-8c59-2f8f1d0c6e13",
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "bad-pod-2",
+					Labels: map[string]string{
+						config.LoadTestLabel: "",
+					},
+				},
+			},
+		}
+
+		pods := PodsForLoadTest(test, allPods)
+		Expect(pods).To(ConsistOf(&allPods[0]))
+	})
+
+	It("does not match empty labels when the test has no UID", func() {
+		test := new(grpcv1.LoadTest)
+		test.Name = "pods-without-uid-loadtest"
+
+		allPods := []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "bad-pod-1",
+					Labels: map[string]string{
+						config.LoadTestLabel: "",
+					},
+				},
+			},
+		}
+
+		Expect(PodsForLoadTest(test, allPods)).To(BeEmpty())
+	})
 })
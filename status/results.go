@@ -0,0 +1,80 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+)
+
+// Results are the benchmark results that a driver reports by writing a JSON
+// object to the termination message file of its run container, which is
+// /dev/termination-log unless the container specifies another path. For
+// example:
+//
+//	{"qps": 12345.6, "latencyP50Ms": 0.8, "latencyP99Ms": 2.4}
+//
+// Each field is optional, and other fields are ignored.
+type Results struct {
+	// QPS is the number of queries per second that the test achieved.
+	QPS *float64 `json:"qps,omitempty"`
+
+	// LatencyP50Ms is the median latency in milliseconds.
+	LatencyP50Ms *float64 `json:"latencyP50Ms,omitempty"`
+
+	// LatencyP99Ms is the 99th percentile latency in milliseconds.
+	LatencyP99Ms *float64 `json:"latencyP99Ms,omitempty"`
+}
+
+// ResultsForPod parses the results in the termination message of the run
+// container of a pod. If the run container has not terminated or its
+// termination message is empty, nil results and a nil error are returned. An
+// error is returned if the termination message is not a JSON object with
+// numeric results.
+func ResultsForPod(pod *corev1.Pod) (*Results, error) {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.Name != config.RunContainerName {
+			continue
+		}
+
+		terminated := containerStatus.State.Terminated
+		if terminated == nil || strings.TrimSpace(terminated.Message) == "" {
+			return nil, nil
+		}
+
+		results := new(Results)
+		if err := json.Unmarshal([]byte(terminated.Message), results); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse results in termination message of pod %q", pod.Name)
+		}
+		return results, nil
+	}
+
+	return nil, nil
+}
+
+// SetOn copies the results to the status of a load test.
+func (r *Results) SetOn(status *grpcv1.LoadTestStatus) {
+	status.QPS = r.QPS
+	status.LatencyP50Ms = r.LatencyP50Ms
+	status.LatencyP99Ms = r.LatencyP99Ms
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 gRPC authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ResultsForPod", func() {
+	podWithMessage := func(name, message string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "driver"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: name,
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{
+								ExitCode: 0,
+								Message:  message,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("parses the results in the termination message of the run container", func() {
+		pod := podWithMessage(config.RunContainerName, `{"qps": 12345.6, "latencyP50Ms": 0.8, "latencyP99Ms": 2.4, "other": "ignored"}`)
+
+		results, err := ResultsForPod(pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).ToNot(BeNil())
+		Expect(*results.QPS).To(Equal(12345.6))
+		Expect(*results.LatencyP50Ms).To(Equal(0.8))
+		Expect(*results.LatencyP99Ms).To(Equal(2.4))
+	})
+
+	It("leaves missing results unset", func() {
+		pod := podWithMessage(config.RunContainerName, `{"qps": 100}`)
+
+		results, err := ResultsForPod(pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*results.QPS).To(Equal(100.0))
+		Expect(results.LatencyP50Ms).To(BeNil())
+		Expect(results.LatencyP99Ms).To(BeNil())
+	})
+
+	It("returns nil results when the termination message is empty", func() {
+		results, err := ResultsForPod(podWithMessage(config.RunContainerName, " \n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(BeNil())
+	})
+
+	It("returns nil results when the run container has not terminated", func() {
+		pod := podWithMessage(config.RunContainerName, "")
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+
+		results, err := ResultsForPod(pod)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(BeNil())
+	})
+
+	It("ignores the termination messages of sidecars", func() {
+		results, err := ResultsForPod(podWithMessage("proxy", `{"qps": 100}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(BeNil())
+	})
+
+	It("returns an error when the results are malformed", func() {
+		_, err := ResultsForPod(podWithMessage(config.RunContainerName, "qps=100"))
+		Expect(err).To(HaveOccurred())
+
+		_, err = ResultsForPod(podWithMessage(config.RunContainerName, `{"qps": "fast"}`))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Results", func() {
+	It("sets the results on the status of a test", func() {
+		qps := 100.0
+		status := grpcv1.LoadTestStatus{State: grpcv1.Succeeded}
+		(&Results{QPS: &qps}).SetOn(&status)
+		Expect(status.QPS).To(Equal(&qps))
+		Expect(status.LatencyP50Ms).To(BeNil())
+		Expect(status.State).To(Equal(grpcv1.Succeeded))
+	})
+})
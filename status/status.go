@@ -21,6 +21,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 	"github.com/grpc/test-infra/config"
@@ -44,6 +45,27 @@ const (
 	Errored State = "Errored"
 )
 
+// podEvictedReason is the reason the kubelet sets on the status of a pod that
+// it evicted.
+const podEvictedReason = "Evicted"
+
+// preemptionReasons are the reasons the kubelet sets on the status of a pod
+// that it terminated because its node was preempted or shut down.
+var preemptionReasons = map[string]bool{
+	"NodeShutdown": true,
+	"Shutdown":     true,
+	"Terminated":   true,
+}
+
+// imagePullReasons are the waiting reasons of a container whose image is being
+// pulled, or has failed to be pulled and will be retried. ContainerCreating
+// is included because the kubelet reports it while the image is downloaded.
+var imagePullReasons = map[string]bool{
+	"ContainerCreating": true,
+	"ErrImagePull":      true,
+	"ImagePullBackOff":  true,
+}
+
 // StateForContainerStatus accepts the status of a container and returns a
 // ContainerState and a pointer to the integer exit code. If the container has
 // not terminated, a Pending state and nil pointer are returned.
@@ -73,6 +95,25 @@ func StateForContainerStatus(status *corev1.ContainerStatus) (State, *int32) {
 // terminated or it terminated successfully, the reason and message strings will
 // be empty.
 func StateForPodStatus(status *corev1.PodStatus) (state State, reason string, message string) {
+	// An evicted pod has failed, but its containers may have been stopped
+	// without a terminated state.
+	if status.Phase == corev1.PodFailed && status.Reason == podEvictedReason {
+		if strings.Contains(status.Message, string(corev1.ResourceEphemeralStorage)) || strings.Contains(status.Message, "ephemeral local storage") {
+			return Errored, grpcv1.EphemeralStorageEvicted, status.Message
+		}
+		return Errored, grpcv1.PodEvicted, status.Message
+	}
+
+	// A pod on a node that was preempted is terminated by the kubelet, which
+	// is a failure of the infrastructure rather than the benchmark.
+	if status.Phase == corev1.PodFailed && preemptionReasons[status.Reason] {
+		message := status.Message
+		if message == "" {
+			message = "pod was terminated because its node was preempted or shut down"
+		}
+		return Errored, grpcv1.Preempted, message
+	}
+
 	podState := Pending
 
 	for i := range status.InitContainerStatuses {
@@ -102,11 +143,127 @@ func StateForPodStatus(status *corev1.PodStatus) (state State, reason string, me
 	return podState, "", ""
 }
 
+// StateForPod accepts a pod and returns a State, reason and message like
+// StateForPodStatus. Containers in the pod spec besides the run container are
+// sidecars, and their statuses are ignored. This way, the state of the pod
+// depends only on its init containers and run container.
+func StateForPod(pod *corev1.Pod) (state State, reason string, message string) {
+	sidecars := make(map[string]bool)
+	for _, container := range pod.Spec.Containers {
+		if container.Name != config.RunContainerName {
+			sidecars[container.Name] = true
+		}
+	}
+	if len(sidecars) == 0 {
+		return StateForPodStatus(&pod.Status)
+	}
+
+	podStatus := pod.Status
+	podStatus.ContainerStatuses = nil
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if !sidecars[containerStatus.Name] {
+			podStatus.ContainerStatuses = append(podStatus.ContainerStatuses, containerStatus)
+		}
+	}
+	return StateForPodStatus(&podStatus)
+}
+
+// PendingReasonForPod returns a reason and message when a pod has not
+// started because it is waiting to be scheduled or for an image to be
+// pulled. The reason is AwaitingSchedule or PullingImage. If the pod is not
+// waiting for either, the reason and message strings are empty.
+func PendingReasonForPod(pod *corev1.Pod) (reason string, message string) {
+	if pod.Status.Phase != "" && pod.Status.Phase != corev1.PodPending {
+		return "", ""
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			message := fmt.Sprintf("pod %q is waiting to be scheduled", pod.Name)
+			if condition.Message != "" {
+				message = fmt.Sprintf("%s: %s", message, condition.Message)
+			}
+			return grpcv1.AwaitingSchedule, message
+		}
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, containerStatus := range statuses {
+		waitState := containerStatus.State.Waiting
+		if waitState == nil || !imagePullReasons[waitState.Reason] {
+			continue
+		}
+		message := fmt.Sprintf("pod %q is pulling image %q for container %q", pod.Name, containerStatus.Image, containerStatus.Name)
+		if waitState.Message != "" {
+			message = fmt.Sprintf("%s: %s", message, waitState.Message)
+		}
+		return grpcv1.PullingImage, message
+	}
+
+	return "", ""
+}
+
+// countFailedPods sets the counts of errored pods for each role in a status.
+func countFailedPods(status *grpcv1.LoadTestStatus, pods []*corev1.Pod) {
+	for _, pod := range pods {
+		if podState, _, _ := StateForPod(pod); podState != Errored {
+			continue
+		}
+
+		switch pod.Labels[config.RoleLabel] {
+		case config.DriverRole:
+			status.FailedDriver++
+		case config.ServerRole:
+			status.FailedServers++
+		case config.ClientRole:
+			status.FailedClients++
+		}
+	}
+}
+
+// ExpectedPodCount returns the number of pods that a load test requires when
+// all of its components are running: one for each server and client, and one
+// for the driver if the test has one.
+func ExpectedPodCount(test *grpcv1.LoadTest) int {
+	count := len(test.Spec.Servers) + len(test.Spec.Clients)
+	if test.Spec.Driver != nil {
+		count++
+	}
+	return count
+}
+
 // ForLoadTest creates and returns a LoadTestStatus, given a load test and the
 // pods it owns. This sets the state, reason and message for the load test. In
 // addition, it attempts to set the start and stop times based on what has been
-// previously encountered.
+// previously encountered, and adds a condition when the state changes.
 func ForLoadTest(test *grpcv1.LoadTest, pods []*corev1.Pod) grpcv1.LoadTestStatus {
+	status := currentStatus(test, pods)
+	status.Conditions = AppendCondition(test.Status.Conditions, status.State, status.Reason)
+	return status
+}
+
+// AppendCondition returns a copy of the conditions of a load test, with a
+// condition for a state appended. If the last condition is for the same
+// state, the conditions are returned unchanged, so a state that is observed
+// by many reconciles is only recorded when it is entered.
+func AppendCondition(conditions []grpcv1.LoadTestCondition, state grpcv1.LoadTestState, reason string) []grpcv1.LoadTestCondition {
+	result := make([]grpcv1.LoadTestCondition, len(conditions), len(conditions)+1)
+	copy(result, conditions)
+
+	if state == "" || (len(result) > 0 && result[len(result)-1].State == state) {
+		return result
+	}
+	return append(result, grpcv1.LoadTestCondition{
+		State:              state,
+		Reason:             reason,
+		LastTransitionTime: metav1.Now(),
+	})
+}
+
+// currentStatus returns the status of a load test without its conditions,
+// given the pods it owns.
+func currentStatus(test *grpcv1.LoadTest, pods []*corev1.Pod) grpcv1.LoadTestStatus {
 	status := grpcv1.LoadTestStatus{}
 
 	if test.Status.StartTime == nil {
@@ -115,25 +272,30 @@ func ForLoadTest(test *grpcv1.LoadTest, pods []*corev1.Pod) grpcv1.LoadTestStatu
 		status.StartTime = test.Status.StartTime
 	}
 
+	status.Pods = int32(len(pods))
+	status.Retries = test.Status.Retries
+	countFailedPods(&status, pods)
+
 	timeout := time.Duration(test.Spec.TimeoutSeconds) * time.Second
 
 	// Here marked the LoadTest running too long as errored. This status update
 	// could trigger cleanup_agent to terminate its workers.
 	if time.Now().Sub(status.StartTime.Time) >= timeout {
 		status.StopTime = optional.CurrentTimePtr()
-		status.State = grpcv1.Errored
-		status.Reason = grpcv1.TimeoutErrored
-		status.Message = fmt.Sprintf("timeout exceeded")
+		status.State = grpcv1.TimedOut
+		status.Reason = grpcv1.TimeoutExceeded
+		status.Message = fmt.Sprintf("load test did not terminate within its timeout of %v", timeout)
 		return status
 	}
 
+	terminatedPods := 0
 	for _, pod := range pods {
 		role, ok := pod.Labels[config.RoleLabel]
 		if !ok {
 			continue
 		}
 
-		podState, reason, message := StateForPodStatus(&pod.Status)
+		podState, reason, message := StateForPod(pod)
 
 		if podState != Succeeded && podState != Errored {
 			continue
@@ -150,7 +312,9 @@ func ForLoadTest(test *grpcv1.LoadTest, pods []*corev1.Pod) grpcv1.LoadTestStatu
 			}
 		} else {
 			if podState == Succeeded {
-				// ignore workers that complete "successfully" for now
+				// workers that complete successfully do not decide the
+				// state, but they signal that the test is stopping
+				terminatedPods++
 				continue
 			}
 
@@ -167,7 +331,7 @@ func ForLoadTest(test *grpcv1.LoadTest, pods []*corev1.Pod) grpcv1.LoadTestStatu
 	}
 
 	currentPods := len(pods)
-	requiredPods := len(test.Spec.Servers) + len(test.Spec.Clients) + 1
+	requiredPods := ExpectedPodCount(test)
 
 	if currentPods < requiredPods {
 		status.State = grpcv1.Initializing
@@ -176,6 +340,51 @@ func ForLoadTest(test *grpcv1.LoadTest, pods []*corev1.Pod) grpcv1.LoadTestStatu
 		return status
 	}
 
+	// Without a driver, nothing else decides the outcome, so the test
+	// succeeds once all of its workers have succeeded.
+	if test.Spec.Driver == nil && terminatedPods >= requiredPods {
+		status.State = grpcv1.Succeeded
+		status.Reason = grpcv1.WorkersSucceeded
+		status.Message = fmt.Sprintf("all %d pods of the load test without a driver succeeded", terminatedPods)
+		if test.Status.StopTime == nil {
+			status.StopTime = optional.CurrentTimePtr()
+		} else {
+			status.StopTime = test.Status.StopTime
+		}
+		return status
+	}
+
+	if terminatedPods > 0 {
+		status.State = grpcv1.Stopping
+		status.Reason = grpcv1.PodsTerminated
+		status.Message = fmt.Sprintf("load test has %d/%d terminated pods", terminatedPods, requiredPods)
+		return status
+	}
+
+	// Pods waiting to be scheduled are reported first, since their images
+	// cannot be pulled until they have a node.
+	var pullingReason, pullingMessage string
+	for _, pod := range pods {
+		reason, message := PendingReasonForPod(pod)
+		switch reason {
+		case grpcv1.AwaitingSchedule:
+			status.State = grpcv1.Initializing
+			status.Reason = reason
+			status.Message = message
+			return status
+		case grpcv1.PullingImage:
+			if pullingReason == "" {
+				pullingReason, pullingMessage = reason, message
+			}
+		}
+	}
+	if pullingReason != "" {
+		status.State = grpcv1.Initializing
+		status.Reason = pullingReason
+		status.Message = pullingMessage
+		return status
+	}
+
 	status.State = grpcv1.Running
 	return status
 }
@@ -214,6 +214,114 @@ var _ = Describe("StateForPodStatus", func() {
 			Expect(state).To(Equal(Pending))
 		})
 	})
+
+	Context("node preempted", func() {
+		It("marks pod as errored with a preemption reason", func() {
+			for _, podReason := range []string{"NodeShutdown", "Shutdown", "Terminated"} {
+				podStatus.Phase = corev1.PodFailed
+				podStatus.Reason = podReason
+				podStatus.Message = ""
+
+				state, reason, message := StateForPodStatus(podStatus)
+				Expect(state).To(Equal(Errored))
+				Expect(reason).To(Equal(grpcv1.Preempted))
+				Expect(message).ToNot(BeEmpty())
+			}
+		})
+	})
+})
+
+var _ = Describe("StateForPod", func() {
+	var pod *corev1.Pod
+
+	BeforeEach(func() {
+		pod = &corev1.Pod{
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: config.RunContainerName},
+					{Name: "proxy"},
+				},
+			},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: config.RunContainerName,
+						State: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{ExitCode: 0},
+						},
+					},
+					{
+						Name: "proxy",
+						State: corev1.ContainerState{
+							Running: &corev1.ContainerStateRunning{},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	It("marks the pod as succeeded when the run container succeeded and a sidecar runs", func() {
+		state, _, _ := StateForPod(pod)
+		Expect(state).To(Equal(Succeeded))
+	})
+
+	It("ignores sidecars that errored", func() {
+		pod.Status.ContainerStatuses[1].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+		}
+		state, _, _ := StateForPod(pod)
+		Expect(state).To(Equal(Succeeded))
+	})
+
+	It("marks the pod as errored when the run container errored", func() {
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+		}
+		state, reason, _ := StateForPod(pod)
+		Expect(state).To(Equal(Errored))
+		Expect(reason).To(Equal(grpcv1.ContainerError))
+	})
+
+	It("marks the pod as pending while the run container runs", func() {
+		pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+			Running: &corev1.ContainerStateRunning{},
+		}
+		state, _, _ := StateForPod(pod)
+		Expect(state).To(Equal(Pending))
+	})
+})
+
+var _ = Describe("ExpectedPodCount", func() {
+	It("returns zero for an empty spec", func() {
+		Expect(ExpectedPodCount(&grpcv1.LoadTest{})).To(Equal(0))
+	})
+
+	It("counts only the driver when there are no workers", func() {
+		test := &grpcv1.LoadTest{
+			Spec: grpcv1.LoadTestSpec{
+				Driver: &grpcv1.Driver{},
+			},
+		}
+		Expect(ExpectedPodCount(test)).To(Equal(1))
+	})
+
+	It("counts each server and client without a driver", func() {
+		test := &grpcv1.LoadTest{
+			Spec: grpcv1.LoadTestSpec{
+				Servers: make([]grpcv1.Server, 2),
+				Clients: make([]grpcv1.Client, 3),
+			},
+		}
+		Expect(ExpectedPodCount(test)).To(Equal(5))
+	})
+
+	It("counts the driver, servers and clients", func() {
+		test := newLoadTestWithMultipleClientsAndServers()
+		expected := len(test.Spec.Servers) + len(test.Spec.Clients) + 1
+		Expect(ExpectedPodCount(test)).To(Equal(expected))
+		Expect(populatePodListWithCurrentLoadTestPod(test)).To(HaveLen(expected))
+	})
 })
 
 var _ = Describe("ForLoadTest", func() {
@@ -314,13 +422,74 @@ var _ = Describe("ForLoadTest", func() {
 		Expect(status.StartTime).To(Equal(&fakeStartTime))
 	})
 
-	It("sets error state when running longer than timeout", func() {
+	It("adds a condition when the state changes", func() {
+		enteredTime := metav1.Time{Time: time.Now().Add(-time.Minute)}
+		test.Status.Conditions = []grpcv1.LoadTestCondition{
+			{State: grpcv1.Initializing, Reason: grpcv1.PodsMissing, LastTransitionTime: enteredTime},
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.Conditions).To(HaveLen(2))
+		Expect(status.Conditions[0].LastTransitionTime).To(Equal(enteredTime))
+		Expect(status.Conditions[1].State).To(Equal(grpcv1.Running))
+		Expect(status.Conditions[1].LastTransitionTime.Time).To(BeTemporally(">", enteredTime.Time))
+	})
+
+	It("does not add a condition when the state is unchanged", func() {
+		enteredTime := metav1.Time{Time: time.Now().Add(-time.Minute)}
+		test.Status.Conditions = []grpcv1.LoadTestCondition{
+			{State: grpcv1.Initializing, Reason: grpcv1.PodsMissing, LastTransitionTime: enteredTime},
+			{State: grpcv1.Running, LastTransitionTime: enteredTime},
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.Conditions).To(Equal(test.Status.Conditions))
+	})
+
+	It("sets the number of pods", func() {
+		status := ForLoadTest(test, pods[:2])
+		Expect(status.Pods).To(BeEquivalentTo(2))
+	})
+
+	It("keeps the number of retries", func() {
+		test.Status.Retries = 2
+		status := ForLoadTest(test, pods)
+		Expect(status.Retries).To(BeEquivalentTo(2))
+	})
+
+	It("sets timed out state when running longer than timeout", func() {
 		fakeStartTime := metav1.Time{Time: time.Date(2020, time.October, 23, 15, 0, 0, 0, time.UTC)}
 		test.Status.StartTime = &fakeStartTime
 		status := ForLoadTest(test, pods)
 
 		Expect(status.StartTime).ToNot(BeNil())
-		Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+		Expect(status.StopTime).ToNot(BeNil())
+		Expect(status.State).To(BeEquivalentTo(grpcv1.TimedOut))
+		Expect(status.Reason).To(Equal(grpcv1.TimeoutExceeded))
+		Expect(status.State.IsTerminated()).To(BeTrue())
+	})
+
+	It("sets timed out state for a test without a driver", func() {
+		test.Spec.Driver = nil
+		fakeStartTime := metav1.NewTime(time.Now().Add(-time.Minute))
+		test.Status.StartTime = &fakeStartTime
+		for _, pod := range pods[1:] {
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{},
+					},
+				},
+			}
+		}
+
+		status := ForLoadTest(test, pods[1:])
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.TimedOut))
+		Expect(status.Reason).To(Equal(grpcv1.TimeoutExceeded))
+		Expect(status.StopTime).ToNot(BeNil())
 	})
 
 	It("sets succeeded state when driver pod succeeded", func() {
@@ -383,6 +552,85 @@ var _ = Describe("ForLoadTest", func() {
 		Expect(status.State).ToNot(BeEquivalentTo(grpcv1.Succeeded))
 	})
 
+	Context("some pods terminated", func() {
+		terminated := func(exitCode int32) []corev1.ContainerStatus {
+			return []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode},
+					},
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			driverPod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{},
+					},
+				},
+			}
+			serverPod.Status.ContainerStatuses = []corev1.ContainerStatus{
+				{
+					State: corev1.ContainerState{
+						Running: &corev1.ContainerStateRunning{},
+					},
+				},
+			}
+			clientPod.Status.ContainerStatuses = terminated(0)
+		})
+
+		It("sets stopping state when only some worker pods succeeded", func() {
+			status := ForLoadTest(test, pods)
+
+			Expect(status.State).To(BeEquivalentTo(grpcv1.Stopping))
+			Expect(status.Reason).To(Equal(grpcv1.PodsTerminated))
+			Expect(status.StopTime).To(BeNil())
+		})
+
+		It("sets succeeded state once the driver succeeds", func() {
+			Expect(ForLoadTest(test, pods).State).To(BeEquivalentTo(grpcv1.Stopping))
+
+			serverPod.Status.ContainerStatuses = terminated(0)
+			driverPod.Status.ContainerStatuses = terminated(0)
+			status := ForLoadTest(test, pods)
+
+			Expect(status.State).To(BeEquivalentTo(grpcv1.Succeeded))
+			Expect(status.StopTime).ToNot(BeNil())
+		})
+
+		It("sets errored state once the driver errors", func() {
+			Expect(ForLoadTest(test, pods).State).To(BeEquivalentTo(grpcv1.Stopping))
+
+			driverPod.Status.ContainerStatuses = terminated(1)
+			status := ForLoadTest(test, pods)
+
+			Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+			Expect(status.StopTime).ToNot(BeNil())
+		})
+
+		It("sets errored state when another worker pod errors", func() {
+			serverPod.Status.ContainerStatuses = terminated(1)
+			status := ForLoadTest(test, pods)
+
+			Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+		})
+
+		It("sets succeeded state once all workers of a test without a driver succeed", func() {
+			test.Spec.Driver = nil
+			workerPods := []*corev1.Pod{serverPod, clientPod}
+			Expect(ForLoadTest(test, workerPods).State).To(BeEquivalentTo(grpcv1.Stopping))
+
+			serverPod.Status.ContainerStatuses = terminated(0)
+			status := ForLoadTest(test, workerPods)
+
+			Expect(status.State).To(BeEquivalentTo(grpcv1.Succeeded))
+			Expect(status.Reason).To(Equal(grpcv1.WorkersSucceeded))
+			Expect(status.StopTime).ToNot(BeNil())
+		})
+	})
+
 	It("sets errored state when driver pod errored", func() {
 		driverPod.Status.ContainerStatuses = []corev1.ContainerStatus{
 			{
@@ -481,6 +729,48 @@ var _ = Describe("ForLoadTest", func() {
 		Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
 	})
 
+	It("sets an ephemeral storage reason when a pod was evicted for its storage", func() {
+		serverPod.Status = corev1.PodStatus{
+			Phase:   corev1.PodFailed,
+			Reason:  "Evicted",
+			Message: "Pod ephemeral local storage usage exceeds the total limit of containers 10Gi.",
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+		Expect(status.Reason).To(Equal(grpcv1.EphemeralStorageEvicted))
+		Expect(status.Message).To(ContainSubstring("ephemeral local storage"))
+		Expect(status.FailedServers).To(BeEquivalentTo(1))
+	})
+
+	It("sets an eviction reason when a pod was evicted for other resources", func() {
+		clientPod.Status = corev1.PodStatus{
+			Phase:   corev1.PodFailed,
+			Reason:  "Evicted",
+			Message: "The node was low on resource: memory.",
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+		Expect(status.Reason).To(Equal(grpcv1.PodEvicted))
+	})
+
+	It("sets a preemption reason when the node of a pod was preempted", func() {
+		clientPod.Status = corev1.PodStatus{
+			Phase:   corev1.PodFailed,
+			Reason:  "Terminated",
+			Message: "Pod was terminated in response to imminent node shutdown.",
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+		Expect(status.Reason).To(Equal(grpcv1.Preempted))
+		Expect(status.Message).To(ContainSubstring("node shutdown"))
+	})
+
 	It("sets stop time when unset", func() {
 		testStart := metav1.Now()
 
@@ -548,6 +838,57 @@ var _ = Describe("ForLoadTest", func() {
 		Expect(*status.StopTime).To(Equal(*stopTime))
 	})
 
+	It("counts failed pods for each role", func() {
+		secondClientPod := clientPod.DeepCopy()
+		secondClientPod.Name = "client-2"
+		secondClientPod.Labels[config.ComponentNameLabel] = "client-2"
+		test.Spec.Clients = append(test.Spec.Clients, grpcv1.Client{
+			Name: optional.StringPtr("client-2"),
+		})
+		pods = append(pods, secondClientPod)
+
+		errored := []corev1.ContainerStatus{
+			{
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+				},
+			},
+		}
+		driverPod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{
+				State: corev1.ContainerState{
+					Running: &corev1.ContainerStateRunning{},
+				},
+			},
+		}
+		serverPod.Status.ContainerStatuses = errored
+		clientPod.Status.ContainerStatuses = errored
+		secondClientPod.Status.ContainerStatuses = errored
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Errored))
+		Expect(status.FailedDriver).To(BeEquivalentTo(0))
+		Expect(status.FailedServers).To(BeEquivalentTo(1))
+		Expect(status.FailedClients).To(BeEquivalentTo(2))
+	})
+
+	It("counts a failed driver", func() {
+		driverPod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+			{
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{ExitCode: 127},
+				},
+			},
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.FailedDriver).To(BeEquivalentTo(1))
+		Expect(status.FailedServers).To(BeEquivalentTo(0))
+		Expect(status.FailedClients).To(BeEquivalentTo(0))
+	})
+
 	It("sets initializing state when pods are missing", func() {
 		pods = pods[1:] // remove the driver from the world
 
@@ -555,4 +896,88 @@ var _ = Describe("ForLoadTest", func() {
 
 		Expect(status.State).To(BeEquivalentTo(grpcv1.Initializing))
 	})
+
+	It("sets running state when all pods are created and not pending", func() {
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Running))
+		Expect(status.Reason).To(BeEmpty())
+	})
+
+	It("sets an awaiting schedule reason when a pod is not scheduled", func() {
+		clientPod.Status.Phase = corev1.PodPending
+		clientPod.Status.Conditions = []corev1.PodCondition{
+			{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Reason:  corev1.PodReasonUnschedulable,
+				Message: "0/3 nodes are available",
+			},
+		}
+		serverPod.Status.Phase = corev1.PodPending
+		serverPod.Status.ContainerStatuses = []corev1.ContainerStatus{
+			{
+				Name:  "run",
+				Image: "fake-server-image",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"},
+				},
+			},
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Initializing))
+		Expect(status.Reason).To(Equal(grpcv1.AwaitingSchedule))
+		Expect(status.Message).To(ContainSubstring("client-1"))
+		Expect(status.Message).To(ContainSubstring("0/3 nodes are available"))
+	})
+
+	It("sets a pulling image reason when a scheduled pod waits for its image", func() {
+		serverPod.Status.Phase = corev1.PodPending
+		serverPod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+		}
+		serverPod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+			{
+				Name:  "ready",
+				Image: "fake-ready-image",
+				State: corev1.ContainerState{
+					Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"},
+				},
+			},
+		}
+
+		status := ForLoadTest(test, pods)
+
+		Expect(status.State).To(BeEquivalentTo(grpcv1.Initializing))
+		Expect(status.Reason).To(Equal(grpcv1.PullingImage))
+		Expect(status.Message).To(ContainSubstring("fake-ready-image"))
+		Expect(status.Message).To(ContainSubstring("Back-off pulling image"))
+	})
+})
+
+var _ = Describe("AppendCondition", func() {
+	It("does not modify the conditions it is given", func() {
+		conditions := make([]grpcv1.LoadTestCondition, 1, 2)
+		conditions[0] = grpcv1.LoadTestCondition{State: grpcv1.Initializing}
+
+		result := AppendCondition(conditions, grpcv1.Running, "")
+
+		Expect(result).To(HaveLen(2))
+		Expect(conditions[:2][1]).To(Equal(grpcv1.LoadTestCondition{}))
+	})
+
+	It("records the reason of the state", func() {
+		result := AppendCondition(nil, grpcv1.Errored, grpcv1.ConfigurationError)
+
+		Expect(result).To(HaveLen(1))
+		Expect(result[0].State).To(Equal(grpcv1.Errored))
+		Expect(result[0].Reason).To(Equal(grpcv1.ConfigurationError))
+		Expect(result[0].LastTransitionTime.IsZero()).To(BeFalse())
+	})
+
+	It("ignores an empty state", func() {
+		Expect(AppendCondition(nil, "", "")).To(BeEmpty())
+	})
 })
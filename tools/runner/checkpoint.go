@@ -0,0 +1,154 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// CheckpointEntry records the result of a test invocation that finished.
+type CheckpointEntry struct {
+	// Queue is the name of the queue that ran the test.
+	Queue string `json:"queue"`
+
+	// Index is the index of the test in its queue.
+	Index int `json:"index"`
+
+	// Name is the name of the test configuration.
+	Name string `json:"name"`
+
+	// Hash is the hash of the test configuration (see ConfigHash).
+	Hash string `json:"hash"`
+
+	// Result is the outcome of the test.
+	Result InvocationResult `json:"result"`
+}
+
+// checkpointFile is the content of a checkpoint file.
+type checkpointFile struct {
+	Entries []CheckpointEntry `json:"entries"`
+}
+
+// checkpointKey identifies a test invocation in a checkpoint.
+type checkpointKey struct {
+	queue string
+	index int
+}
+
+// Checkpoint records the results of the test invocations of a run in a file,
+// so a run that was interrupted can be resumed without repeating the tests
+// that passed. It is safe to use from the goroutines of all queues.
+type Checkpoint struct {
+	path    string
+	mux     sync.Mutex
+	entries map[checkpointKey]CheckpointEntry
+}
+
+// LoadCheckpoint reads a checkpoint file. If the file does not exist, an
+// empty checkpoint is returned, which creates the file when the first result
+// is recorded.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		path:    path,
+		entries: make(map[checkpointKey]CheckpointEntry),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read checkpoint %q: %v", path, err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("could not parse checkpoint %q: %v", path, err)
+	}
+	for _, entry := range file.Entries {
+		checkpoint.entries[checkpointKey{entry.Queue, entry.Index}] = entry
+	}
+	return checkpoint, nil
+}
+
+// Passed returns true if the checkpoint records that a test invocation
+// passed with the same configuration. An invocation whose configuration
+// changed since it passed, so its hash differs, is treated as new.
+func (c *Checkpoint) Passed(invocation TestInvocation, hash string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	entry, ok := c.entries[checkpointKey{invocation.Queue, invocation.Index}]
+	return ok && entry.Name == invocation.Name && entry.Hash == hash && entry.Result == InvocationPassed
+}
+
+// Len returns the number of test invocations in the checkpoint.
+func (c *Checkpoint) Len() int {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return len(c.entries)
+}
+
+// Record adds the result of a test invocation to the checkpoint, replacing
+// any earlier result of the invocation, and writes the checkpoint file.
+func (c *Checkpoint) Record(entry CheckpointEntry) error {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.entries[checkpointKey{entry.Queue, entry.Index}] = entry
+	return c.write()
+}
+
+// write replaces the checkpoint file. The entries are written to a temporary
+// file in the same directory, which is then renamed, so a run that crashes
+// while writing leaves the previous checkpoint intact.
+func (c *Checkpoint) write() error {
+	file := checkpointFile{Entries: make([]CheckpointEntry, 0, len(c.entries))}
+	for _, entry := range c.entries {
+		file.Entries = append(file.Entries, entry)
+	}
+	sort.Slice(file.Entries, func(i, j int) bool {
+		if file.Entries[i].Queue != file.Entries[j].Queue {
+			return file.Entries[i].Queue < file.Entries[j].Queue
+		}
+		return file.Entries[i].Index < file.Entries[j].Index
+	})
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not encode checkpoint: %v", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), filepath.Base(c.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("could not create temporary file for checkpoint %q: %v", c.path, err)
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not write checkpoint %q: %v", c.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("Checkpoint", func() {
+	var dir, path string
+	invocation := TestInvocation{Queue: "queue", Index: 0, Name: "test-0"}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "runner-checkpoint")
+		Expect(err).ToNot(HaveOccurred())
+		path = filepath.Join(dir, "checkpoint.json")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("starts empty when the file does not exist", func() {
+		checkpoint, err := LoadCheckpoint(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.Len()).To(Equal(0))
+		Expect(checkpoint.Passed(invocation, "hash")).To(BeFalse())
+	})
+
+	It("reads the results that were recorded", func() {
+		checkpoint, err := LoadCheckpoint(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.Record(CheckpointEntry{Queue: "queue", Index: 0, Name: "test-0", Hash: "hash", Result: InvocationPassed})).To(Succeed())
+		Expect(checkpoint.Record(CheckpointEntry{Queue: "queue", Index: 1, Name: "test-1", Hash: "hash", Result: InvocationFailed})).To(Succeed())
+
+		resumed, err := LoadCheckpoint(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resumed.Len()).To(Equal(2))
+		Expect(resumed.Passed(invocation, "hash")).To(BeTrue())
+		Expect(resumed.Passed(TestInvocation{Queue: "queue", Index: 1, Name: "test-1"}, "hash")).To(BeFalse())
+	})
+
+	It("treats a test whose configuration changed as new", func() {
+		checkpoint, err := LoadCheckpoint(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.Record(CheckpointEntry{Queue: "queue", Index: 0, Name: "test-0", Hash: "hash", Result: InvocationPassed})).To(Succeed())
+
+		Expect(checkpoint.Passed(invocation, "other-hash")).To(BeFalse())
+		Expect(checkpoint.Passed(TestInvocation{Queue: "queue", Index: 0, Name: "test-renamed"}, "hash")).To(BeFalse())
+	})
+
+	It("does not leave temporary files", func() {
+		checkpoint, err := LoadCheckpoint(path)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checkpoint.Record(CheckpointEntry{Queue: "queue", Index: 0, Name: "test-0", Hash: "hash", Result: InvocationPassed})).To(Succeed())
+
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name()).To(Equal("checkpoint.json"))
+	})
+
+	It("returns an error for a file that cannot be parsed", func() {
+		Expect(ioutil.WriteFile(path, []byte("not json"), 0644)).To(Succeed())
+		_, err := LoadCheckpoint(path)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("Runner checkpoint", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "runner-checkpoint")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	run := func(checkpoint *Checkpoint, getter *fake.LoadTestGetter, configs []*grpcv1.LoadTest) *junit.TestSuites {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetCheckpoint(checkpoint)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), configs, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+		return decodeReport(report)
+	}
+
+	It("skips tests that passed and runs tests that changed", func() {
+		checkpoint, err := LoadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+		Expect(err).ToNot(HaveOccurred())
+		configs := []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+		}
+		run(checkpoint, newFakeGetter(grpcv1.Succeeded), configs)
+		Expect(checkpoint.Len()).To(Equal(2))
+
+		configs[1].Spec.TimeoutSeconds = 60
+		getter := newFakeGetter(grpcv1.Succeeded)
+		cases := run(checkpoint, getter, configs).Suites[0].Cases
+		Expect(countActions(getter, "create")).To(Equal(1))
+		Expect(cases[0].Skipped).ToNot(BeNil())
+		Expect(cases[1].Skipped).To(BeNil())
+	})
+
+	It("runs tests that failed again", func() {
+		checkpoint, err := LoadCheckpoint(filepath.Join(dir, "checkpoint.json"))
+		Expect(err).ToNot(HaveOccurred())
+		configs := []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}
+		run(checkpoint, newFakeGetter(grpcv1.Errored), configs)
+
+		getter := newFakeGetter(grpcv1.Succeeded)
+		run(checkpoint, getter, configs)
+		Expect(countActions(getter, "create")).To(Equal(1))
+	})
+})
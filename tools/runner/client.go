@@ -1,15 +1,22 @@
 package runner
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"strings"
 
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -20,9 +27,14 @@ import (
 	clientset "github.com/grpc/test-infra/clientset"
 )
 
-// NewLoadTestGetter returns a client to interact with LoadTest resources.
-// The client can be used to create, query for status and delete LoadTests.
-func NewLoadTestGetter() clientset.LoadTestGetter {
+// ErrLoadTestCRDNotInstalled is returned by CheckLoadTestCRD when the cluster
+// does not serve LoadTest resources.
+var ErrLoadTestCRDNotInstalled = errors.New("LoadTest CRD not installed")
+
+// NewLoadTestGetter returns a client to interact with LoadTest resources in a
+// namespace. The client can be used to create, query for status and delete
+// LoadTests.
+func NewLoadTestGetter(namespace string) clientset.LoadTestGetter {
 	schemebuilder := runtime.NewSchemeBuilder(func(scheme *runtime.Scheme) error {
 		scheme.AddKnownTypes(grpcv1.GroupVersion,
 			&grpcv1.LoadTest{},
@@ -32,6 +44,81 @@ func NewLoadTestGetter() clientset.LoadTestGetter {
 		return nil
 	})
 
+	config := newRestConfig()
+
+	schemebuilder.AddToScheme(clientgoscheme.Scheme)
+	scheme := clientgoscheme.Scheme
+	types := scheme.AllKnownTypes()
+	_ = types
+
+	grpcClientset, err := clientset.NewForConfig(config)
+	if err != nil {
+		log.Fatalf("failed to create a grpc clientset: %v", err)
+	}
+	return grpcClientset.LoadTestV1().LoadTests(namespace)
+}
+
+// NewNamespaceGetter returns a client to interact with namespaces.
+func NewNamespaceGetter() corev1client.NamespaceInterface {
+	coreClientset, err := kubernetes.NewForConfig(newRestConfig())
+	if err != nil {
+		log.Fatalf("failed to create a core clientset: %v", err)
+	}
+	return coreClientset.CoreV1().Namespaces()
+}
+
+// NewPodGetter returns a client to interact with pods in a namespace.
+func NewPodGetter(namespace string) corev1client.PodInterface {
+	coreClientset, err := kubernetes.NewForConfig(newRestConfig())
+	if err != nil {
+		log.Fatalf("failed to create a core clientset: %v", err)
+	}
+	return coreClientset.CoreV1().Pods(namespace)
+}
+
+// NewNodeGetter returns a client to interact with the nodes of the cluster.
+func NewNodeGetter() corev1client.NodeInterface {
+	coreClientset, err := kubernetes.NewForConfig(newRestConfig())
+	if err != nil {
+		log.Fatalf("failed to create a core clientset: %v", err)
+	}
+	return coreClientset.CoreV1().Nodes()
+}
+
+// NewDeploymentGetter returns a client to interact with deployments in a
+// namespace.
+func NewDeploymentGetter(namespace string) appsv1client.DeploymentInterface {
+	coreClientset, err := kubernetes.NewForConfig(newRestConfig())
+	if err != nil {
+		log.Fatalf("failed to create a core clientset: %v", err)
+	}
+	return coreClientset.AppsV1().Deployments(namespace)
+}
+
+// EnsureNamespace creates a namespace, unless it already exists.
+func EnsureNamespace(namespaceGetter corev1client.NamespaceInterface, name string) error {
+	_, err := namespaceGetter.Get(name, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get namespace %q: %v", name, err)
+	}
+	_, err = namespaceGetter.Create(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %q: %v", name, err)
+	}
+	return nil
+}
+
+// newRestConfig returns the configuration to connect to the cluster. The
+// in-cluster configuration is used when available. Otherwise, the
+// configuration is read from the kubeconfig file of the user.
+func newRestConfig() *rest.Config {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		if err != rest.ErrNotInCluster {
@@ -57,14 +144,21 @@ func NewLoadTestGetter() clientset.LoadTestGetter {
 		}
 	}
 
-	schemebuilder.AddToScheme(clientgoscheme.Scheme)
-	scheme := clientgoscheme.Scheme
-	types := scheme.AllKnownTypes()
-	_ = types
+	return config
+}
 
-	grpcClientset, err := clientset.NewForConfig(config)
-	if err != nil {
-		log.Fatalf("failed to create a grpc clientset: %v", err)
+// CheckLoadTestCRD verifies that the cluster serves LoadTest resources.
+// Without the CRD, creating a LoadTest fails with an error that looks
+// transient, so callers should run this check once before submitting tests.
+// An error wrapping ErrLoadTestCRDNotInstalled is returned if the CRD is
+// missing. Other errors are returned as they are encountered.
+func CheckLoadTestCRD(loadTestGetter clientset.LoadTestGetter) error {
+	_, err := loadTestGetter.List(metav1.ListOptions{Limit: 1})
+	if err == nil {
+		return nil
+	}
+	if meta.IsNoMatchError(err) || kerrors.IsNotFound(err) {
+		return fmt.Errorf("%w: %v", ErrLoadTestCRDNotInstalled, err)
 	}
-	return grpcClientset.LoadTestV1().LoadTests(corev1.NamespaceDefault)
+	return err
 }
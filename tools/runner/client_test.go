@@ -0,0 +1,152 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+)
+
+// newFakeGetter returns a fake LoadTestGetter for the default namespace, in
+// which tests are in a state as soon as they are created.
+func newFakeGetter(state grpcv1.LoadTestState) *fake.LoadTestGetter {
+	getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+	getter.SetCreatedStatus(grpcv1.LoadTestStatus{State: state})
+	return getter
+}
+
+// countActions returns the number of calls to a fake with a verb, including
+// calls that failed.
+func countActions(getter *fake.LoadTestGetter, verb string) int {
+	count := 0
+	for _, action := range getter.Actions() {
+		if action.Verb == verb {
+			count++
+		}
+	}
+	return count
+}
+
+// actionNames returns the names of the tests in the calls to a fake with a
+// verb that succeeded, in the order of the calls.
+func actionNames(getter *fake.LoadTestGetter, verb string) []string {
+	var names []string
+	for _, action := range getter.Actions() {
+		if action.Verb == verb && action.Err == nil {
+			names = append(names, action.Name)
+		}
+	}
+	return names
+}
+
+// actionTimes returns the times of the calls to a fake with a verb that
+// succeeded, in the order of the calls.
+func actionTimes(getter *fake.LoadTestGetter, verb string) []time.Time {
+	var times []time.Time
+	for _, action := range getter.Actions() {
+		if action.Verb == verb && action.Err == nil {
+			times = append(times, action.Time)
+		}
+	}
+	return times
+}
+
+var _ = Describe("CheckLoadTestCRD", func() {
+	It("returns nil when LoadTests can be listed", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		Expect(CheckLoadTestCRD(getter)).To(Succeed())
+	})
+
+	It("returns ErrLoadTestCRDNotInstalled when the kind is unknown", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetError("list", &meta.NoKindMatchError{
+			GroupKind: schema.GroupKind{
+				Group: grpcv1.GroupVersion.Group,
+				Kind:  "LoadTest",
+			},
+			SearchedVersions: []string{grpcv1.GroupVersion.Version},
+		})
+		err := CheckLoadTestCRD(getter)
+		Expect(errors.Is(err, ErrLoadTestCRDNotInstalled)).To(BeTrue())
+	})
+
+	It("returns other errors unchanged", func() {
+		listErr := errors.New("connection refused")
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetError("list", listErr)
+		err := CheckLoadTestCRD(getter)
+		Expect(err).To(Equal(listErr))
+		Expect(errors.Is(err, ErrLoadTestCRDNotInstalled)).To(BeFalse())
+	})
+})
+
+var _ = Describe("EnsureNamespace", func() {
+	countCreates := func(clientset *k8sfake.Clientset) int {
+		creates := 0
+		for _, action := range clientset.Actions() {
+			if action.GetVerb() == "create" {
+				creates++
+			}
+		}
+		return creates
+	}
+
+	It("creates the namespace when it is missing", func() {
+		clientset := k8sfake.NewSimpleClientset()
+		Expect(EnsureNamespace(clientset.CoreV1().Namespaces(), "benchmarks")).To(Succeed())
+
+		namespace, err := clientset.CoreV1().Namespaces().Get("benchmarks", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(namespace.Name).To(Equal("benchmarks"))
+		Expect(countCreates(clientset)).To(Equal(1))
+	})
+
+	It("does nothing when the namespace exists", func() {
+		clientset := k8sfake.NewSimpleClientset(&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "benchmarks"},
+		})
+		Expect(EnsureNamespace(clientset.CoreV1().Namespaces(), "benchmarks")).To(Succeed())
+		Expect(countCreates(clientset)).To(Equal(0))
+	})
+
+	It("is idempotent", func() {
+		clientset := k8sfake.NewSimpleClientset()
+		Expect(EnsureNamespace(clientset.CoreV1().Namespaces(), "benchmarks")).To(Succeed())
+		Expect(EnsureNamespace(clientset.CoreV1().Namespaces(), "benchmarks")).To(Succeed())
+		Expect(countCreates(clientset)).To(Equal(1))
+	})
+
+	It("returns an error when the namespace cannot be checked", func() {
+		clientset := k8sfake.NewSimpleClientset()
+		clientset.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("connection refused")
+		})
+		Expect(EnsureNamespace(clientset.CoreV1().Namespaces(), "benchmarks")).ToNot(Succeed())
+		Expect(countCreates(clientset)).To(Equal(0))
+	})
+})
@@ -17,21 +17,56 @@ package runner
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 
 	"sigs.k8s.io/yaml"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 )
 
+// StdinFileName is the file name that refers to standard input when it is
+// passed to DecodeFromFiles or DecodeFromFilesWithValues.
+const StdinFileName = "-"
+
+// stdinName is the name of standard input in error messages.
+const stdinName = "<stdin>"
+
+// stdin is the reader for the StdinFileName. It is replaced in tests.
+var stdin io.Reader = os.Stdin
+
 // DecodeFromFiles reads LoadTest configurations from a set of files.
-// Each file is a multipart YAML file containing LoadTest configurations.
+// Each file is a multipart YAML file containing LoadTest configurations. The file name "-" reads from
+// standard input.
 func DecodeFromFiles(fileNames []string) ([]*grpcv1.LoadTest, error) {
+	return DecodeFromFilesWithValues(fileNames, nil)
+}
+
+// DecodeFromFilesWithValues reads LoadTest configurations from a set of files,
+// after expanding each file as a Go template with a map of values. Values are
+// referenced by name (e.g. {{.driverImage}}), and referencing a value that is
+// not in the map is an error. If the map is nil, files are not expanded.
+//
+// Files are expanded before the parameter matrix of each test is, so
+// placeholders for parameters must be escaped (e.g. {{"{{.messageSize}}"}}).
+func DecodeFromFilesWithValues(fileNames []string, values map[string]string) ([]*grpcv1.LoadTest, error) {
 	var configs []*grpcv1.LoadTest
+	readStdin := false
 	for _, fileName := range fileNames {
-		c, err := decodeFromFile(fileName)
+		if fileName == StdinFileName {
+			if readStdin {
+				return nil, fmt.Errorf("standard input (%q) can only be read once", StdinFileName)
+			}
+			readStdin = true
+		}
+		c, err := decodeFromFile(fileName, values)
 		if err != nil {
 			return nil, err
 		}
@@ -40,19 +75,65 @@ func DecodeFromFiles(fileNames []string) ([]*grpcv1.LoadTest, error) {
 	return configs, nil
 }
 
-// decodeFromFile reads LoadTest configurations from a single file.
-func decodeFromFile(fileName string) ([]*grpcv1.LoadTest, error) {
-	var configs []*grpcv1.LoadTest
-	f, err := os.Open(fileName)
+// DecodeValuesFile reads a YAML file that maps the names of values to their
+// values, for use with DecodeFromFilesWithValues.
+func DecodeValuesFile(fileName string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(fileName)
 	if err != nil {
 		return nil, err
 	}
-	scanner := bufio.NewScanner(f)
-	for {
+	values := make(map[string]string)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("error decoding values from %q: %v", fileName, err)
+	}
+	return values, nil
+}
+
+// DecodeFromReader reads LoadTest configurations from a reader, such as
+// standard input. The contents are a multipart YAML stream, and the name is
+// used to identify the reader in errors.
+func DecodeFromReader(name string, r io.Reader) ([]*grpcv1.LoadTest, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading configs from %q: %v", name, err)
+	}
+	return decodeData(name, data)
+}
+
+// decodeFromFile reads LoadTest configurations from a single file, expanding
+// it with values if they are not nil. The StdinFileName reads from standard
+// input.
+func decodeFromFile(fileName string, values map[string]string) ([]*grpcv1.LoadTest, error) {
+	var data []byte
+	var err error
+	if fileName == StdinFileName {
+		fileName = stdinName
+		data, err = ioutil.ReadAll(stdin)
+	} else {
+		data, err = ioutil.ReadFile(fileName)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if values != nil {
+		data, err = expandValues(fileName, data, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return decodeData(fileName, data)
+}
 
-		config, err := decodeNext(scanner)
+// decodeData decodes the LoadTest configurations in the contents of a file.
+func decodeData(fileName string, data []byte) ([]*grpcv1.LoadTest, error) {
+	var configs []*grpcv1.LoadTest
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 1
+	for {
+		startLine := line
+		config, err := decodeNext(scanner, &line)
 		if err != nil {
-			return nil, fmt.Errorf("error decoding config from %q: %v", fileName, err)
+			return nil, fmt.Errorf("error decoding config from %q at line %d: %v", fileName, errorLine(err, startLine), err)
 		}
 		if config == nil {
 			break
@@ -62,11 +143,45 @@ func decodeFromFile(fileName string) ([]*grpcv1.LoadTest, error) {
 	return configs, nil
 }
 
-// decodeNext decodes the next LoadTest configuration found in the file.
-func decodeNext(scanner *bufio.Scanner) (*grpcv1.LoadTest, error) {
+// expandValues executes the contents of a file as a template with values.
+func expandValues(fileName string, data []byte, values map[string]string) ([]byte, error) {
+	tmpl, err := template.New(fileName).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %q: %v", fileName, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, fmt.Errorf("error expanding values in %q: %v", fileName, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlLinePattern matches the line number in errors from the YAML parser,
+// which counts lines from the start of the parsed document.
+var yamlLinePattern = regexp.MustCompile(`yaml: line (\d+):`)
+
+// errorLine returns the line of a file where a YAML error occurred, given the
+// line on which the document that failed to parse starts. If the error does
+// not include a line, the start of the document is returned.
+func errorLine(err error, startLine int) int {
+	match := yamlLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return startLine
+	}
+	line, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return startLine
+	}
+	return startLine + line - 1
+}
+
+// decodeNext decodes the next LoadTest configuration found in the file. The
+// line number is advanced past the lines that are read.
+func decodeNext(scanner *bufio.Scanner, lineNumber *int) (*grpcv1.LoadTest, error) {
 	const sep = "---"
 	var lines []string
 	for scanner.Scan() {
+		*lineNumber++
 		line := scanner.Text()
 		if line == sep {
 			break
@@ -0,0 +1,162 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const templatedConfigs = `apiVersion: e2etest.grpc.io/v1
+kind: LoadTest
+metadata:
+  name: {{.prefix}}-unary
+spec:
+  scenariosJSON: '{{"{{.messageSize}}"}}'
+---
+apiVersion: e2etest.grpc.io/v1
+kind: LoadTest
+metadata:
+  name: {{.prefix}}-streaming
+`
+
+var _ = Describe("DecodeFromFilesWithValues", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "runner-configs")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, contents string) string {
+		fileName := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(fileName, []byte(contents), 0644)).To(Succeed())
+		return fileName
+	}
+
+	It("substitutes values in each config", func() {
+		fileName := writeFile("configs.yaml", templatedConfigs)
+		configs, err := DecodeFromFilesWithValues([]string{fileName}, map[string]string{"prefix": "cxx"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configs).To(HaveLen(2))
+		Expect(configs[0].Name).To(Equal("cxx-unary"))
+		Expect(configs[0].Spec.ScenariosJSON).To(Equal("{{.messageSize}}"))
+		Expect(configs[1].Name).To(Equal("cxx-streaming"))
+	})
+
+	It("returns an error for a missing value", func() {
+		fileName := writeFile("configs.yaml", templatedConfigs)
+		_, err := DecodeFromFilesWithValues([]string{fileName}, map[string]string{"other": "value"})
+		Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "prefix"`)))
+		Expect(err).To(MatchError(ContainSubstring(fileName)))
+	})
+
+	It("reports the file and line of invalid YAML", func() {
+		fileName := writeFile("configs.yaml", "metadata:\n  name: a\n---\nmetadata:\n  name: {{.name}}\n")
+		_, err := DecodeFromFilesWithValues([]string{fileName}, map[string]string{"name": "b: c"})
+		Expect(err).To(MatchError(ContainSubstring(fileName)))
+		Expect(err).To(MatchError(ContainSubstring("at line 5")))
+	})
+
+	It("does not expand files without values", func() {
+		fileName := writeFile("configs.yaml", "metadata:\n  name: '{{.name}}'\n")
+		configs, err := DecodeFromFiles([]string{fileName})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configs[0].Name).To(Equal("{{.name}}"))
+	})
+})
+
+var _ = Describe("DecodeValuesFile", func() {
+	It("reads a map of values", func() {
+		file, err := ioutil.TempFile("", "values-*.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(file.Name())
+		_, err = file.WriteString("prefix: cxx\nimage: gcr.io/grpc/cxx\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		values, err := DecodeValuesFile(file.Name())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(values).To(Equal(map[string]string{"prefix": "cxx", "image": "gcr.io/grpc/cxx"}))
+	})
+})
+
+var _ = Describe("reading configs from standard input", func() {
+	var savedStdin io.Reader
+
+	BeforeEach(func() {
+		savedStdin = stdin
+	})
+
+	AfterEach(func() {
+		stdin = savedStdin
+	})
+
+	It("decodes a multipart stream", func() {
+		stdin = strings.NewReader("metadata:\n  name: first\n---\n{\"metadata\": {\"name\": \"second\"}}\n")
+		configs, err := DecodeFromFiles([]string{StdinFileName})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configs).To(HaveLen(2))
+		Expect(configs[0].Name).To(Equal("first"))
+		Expect(configs[1].Name).To(Equal("second"))
+	})
+
+	It("composes with input files in order", func() {
+		file, err := ioutil.TempFile("", "configs-*.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(file.Name())
+		_, err = file.WriteString("metadata:\n  name: from-file\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(file.Close()).To(Succeed())
+
+		stdin = strings.NewReader("metadata:\n  name: {{.prefix}}-stdin\n")
+		configs, err := DecodeFromFilesWithValues([]string{StdinFileName, file.Name()}, map[string]string{"prefix": "piped"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configs).To(HaveLen(2))
+		Expect(configs[0].Name).To(Equal("piped-stdin"))
+		Expect(configs[1].Name).To(Equal("from-file"))
+	})
+
+	It("returns an error when standard input is named twice", func() {
+		stdin = strings.NewReader("metadata:\n  name: first\n")
+		_, err := DecodeFromFiles([]string{StdinFileName, StdinFileName})
+		Expect(err).To(MatchError(ContainSubstring("can only be read once")))
+	})
+
+	It("names standard input in errors", func() {
+		stdin = strings.NewReader("metadata: [\n")
+		_, err := DecodeFromFiles([]string{StdinFileName})
+		Expect(err).To(MatchError(ContainSubstring("<stdin>")))
+	})
+})
+
+var _ = Describe("DecodeFromReader", func() {
+	It("decodes configs from a reader", func() {
+		configs, err := DecodeFromReader("pipe", strings.NewReader("metadata:\n  name: a\n---\nmetadata:\n  name: b\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(configs).To(HaveLen(2))
+		Expect(configs[1].Name).To(Equal("b"))
+	})
+})
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	clientset "github.com/grpc/test-infra/clientset"
+	"github.com/grpc/test-infra/config"
+	"github.com/grpc/test-infra/controllers"
+	"github.com/grpc/test-infra/status"
+)
+
+// DecodeDefaultsFile reads and validates the defaults of the controller from
+// a YAML file, such as the one the controller is started with.
+func DecodeDefaultsFile(fileName string) (*config.Defaults, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read defaults file %q: %v", fileName, err)
+	}
+	defaults := new(config.Defaults)
+	if err := yaml.Unmarshal(data, defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file %q: %v", fileName, err)
+	}
+	if err := defaults.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid defaults in file %q: %v", fileName, err)
+	}
+	return defaults, nil
+}
+
+// Explainer diagnoses why a test is waiting. It reads the test, its pods and
+// the nodes of the cluster, but it never modifies them.
+type Explainer struct {
+	loadTestGetter clientset.LoadTestGetter
+	podGetter      corev1client.PodInterface
+	nodeGetter     corev1client.NodeInterface
+	defaults       *config.Defaults
+}
+
+// NewExplainer creates an Explainer. The defaults should match those of the
+// controller, since they decide the default pools of components and the
+// names of components that the controller has yet to set.
+func NewExplainer(loadTestGetter clientset.LoadTestGetter, podGetter corev1client.PodInterface, nodeGetter corev1client.NodeInterface, defaults *config.Defaults) *Explainer {
+	return &Explainer{
+		loadTestGetter: loadTestGetter,
+		podGetter:      podGetter,
+		nodeGetter:     nodeGetter,
+		defaults:       defaults,
+	}
+}
+
+// Explain writes a diagnosis of the test with the given name. This includes
+// the status of the test, the reason each of its pods is pending and, if the
+// test is missing pods, whether the controller can schedule them. The
+// scheduling decision is made as the controller makes it, reserving nodes
+// for tests that precede this test.
+func (e *Explainer) Explain(w io.Writer, name string) error {
+	test, err := e.loadTestGetter.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get test %q: %v", name, err)
+	}
+
+	fmt.Fprintf(w, "Test: %s\n", test.Name)
+	if test.Status.State == "" {
+		fmt.Fprintf(w, "Status: Unknown (the controller has not reconciled the test)\n")
+	} else {
+		fmt.Fprintf(w, "Status: %s\n", statusString(test))
+	}
+	if test.Status.State.IsTerminated() {
+		fmt.Fprintf(w, "The test has terminated and is not waiting.\n")
+		return nil
+	}
+
+	// The controller sets the names and pools of components when it first
+	// reconciles a test, so they may not be stored yet.
+	test = test.DeepCopy()
+	if err := e.defaults.SetLoadTestDefaults(test); err != nil {
+		return fmt.Errorf("failed to set defaults for test %q: %v", name, err)
+	}
+
+	pods, err := e.podGetter.List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	ownedPods := status.PodsForLoadTest(test, pods.Items)
+	fmt.Fprintf(w, "Pods: %d/%d created\n", len(ownedPods), status.ExpectedPodCount(test))
+	for _, pod := range ownedPods {
+		if reason, message := status.PendingReasonForPod(pod); reason != "" {
+			fmt.Fprintf(w, "  %s: %s\n", reason, message)
+		}
+	}
+
+	missing := status.CheckMissingPods(test, ownedPods)
+	if missing.IsEmpty() {
+		fmt.Fprintf(w, "Scheduling: all pods have been created\n")
+		return nil
+	}
+
+	nodes, err := e.nodeGetter.List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	clusterInfo := controllers.CurrentClusterInfo(nodes.Items, pods.Items, e.defaults.DefaultPoolLabels, logf.NullLogger{})
+
+	preceding, err := e.reserveNodesForPrecedingTests(test, pods.Items, clusterInfo)
+	if err != nil {
+		return err
+	}
+	if len(preceding) > 0 {
+		fmt.Fprintf(w, "Preceding tests holding nodes: %s\n", strings.Join(preceding, ", "))
+	}
+
+	_, err = clusterInfo.ClusterCanSchedule(missing, logf.NullLogger{})
+	var availabilityErr *controllers.InadequateAvailabilityError
+	switch {
+	case errors.As(err, &availabilityErr):
+		fmt.Fprintf(w, "Scheduling: waiting for nodes to be freed\n")
+		for _, shortfall := range availabilityErr.Shortfalls {
+			fmt.Fprintf(w, "  pool %q needs %d more nodes (requires %d, has %d available)\n", shortfall.Pool, shortfall.Deficit(), shortfall.Required, shortfall.Available)
+		}
+	case err != nil:
+		fmt.Fprintf(w, "Scheduling: the test cannot be scheduled: %v\n", err)
+	default:
+		fmt.Fprintf(w, "Scheduling: the cluster has enough available nodes for the missing pods\n")
+	}
+
+	return writePools(w, clusterInfo)
+}
+
+// reserveNodesForPrecedingTests holds the nodes that unfinished tests which
+// precede a test require, mirroring the controller. It returns the names of
+// the tests that hold nodes, sorted by name.
+func (e *Explainer) reserveNodesForPrecedingTests(test *grpcv1.LoadTest, pods []corev1.Pod, clusterInfo *controllers.ClusterInfo) ([]string, error) {
+	tests, err := e.loadTestGetter.List(metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tests: %v", err)
+	}
+
+	var names []string
+	for i := range tests.Items {
+		other := tests.Items[i].DeepCopy()
+		if other.Name == test.Name || other.DeletionTimestamp != nil || other.Status.State.IsTerminated() || !controllers.Precedes(other, test) {
+			continue
+		}
+		if err := e.defaults.SetLoadTestDefaults(other); err != nil {
+			continue
+		}
+
+		missing := status.CheckMissingPods(other, status.PodsForLoadTest(other, pods))
+		if missing.IsEmpty() {
+			continue
+		}
+		clusterInfo.ReserveNodes(missing)
+		names = append(names, other.Name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// writePools writes the capacity and availability of each pool as a table.
+func writePools(w io.Writer, clusterInfo *controllers.ClusterInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "POOL\tCAPACITY\tAVAILABLE")
+	snapshot := clusterInfo.Snapshot()
+	for _, pool := range clusterInfo.PoolNames() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", pool, snapshot[pool].Capacity, snapshot[pool].Available)
+	}
+	return tw.Flush()
+}
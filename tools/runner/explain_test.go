@@ -0,0 +1,196 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/config"
+)
+
+// newExplainTest returns a test with a driver in the drivers pool and a
+// server and client in the workers pool.
+func newExplainTest(name string) *grpcv1.LoadTest {
+	image := "example.com/image"
+	driverName, serverName, clientName := "driver", "server", "client"
+	driverPool, workerPool := "drivers", "workers"
+	return &grpcv1.LoadTest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: grpcv1.LoadTestSpec{
+			Driver: &grpcv1.Driver{
+				Name:     &driverName,
+				Language: "cxx",
+				Pool:     &driverPool,
+				Run:      grpcv1.Run{Image: &image},
+			},
+			Servers: []grpcv1.Server{{
+				Name:     &serverName,
+				Language: "cxx",
+				Pool:     &workerPool,
+				Run:      grpcv1.Run{Image: &image},
+			}},
+			Clients: []grpcv1.Client{{
+				Name:     &clientName,
+				Language: "cxx",
+				Pool:     &workerPool,
+				Run:      grpcv1.Run{Image: &image},
+			}},
+		},
+		Status: grpcv1.LoadTestStatus{
+			State:  grpcv1.Initializing,
+			Reason: grpcv1.PodsMissing,
+		},
+	}
+}
+
+// newExplainNode returns a node in a pool.
+func newExplainNode(name, pool string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{config.PoolLabel: pool},
+		},
+	}
+}
+
+// newExplainPod returns a pending pod of a test that occupies a node in a
+// pool.
+func newExplainPod(name, testName, role, componentName, pool string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				config.LoadTestLabel:      testName,
+				config.RoleLabel:          role,
+				config.ComponentNameLabel: componentName,
+				config.PoolLabel:          pool,
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+var _ = Describe("Explainer", func() {
+	var defaults *config.Defaults
+	var nodes []runtime.Object
+
+	explain := func(getter *fake.LoadTestGetter, objects []runtime.Object, name string) (string, error) {
+		clientset := k8sfake.NewSimpleClientset(objects...)
+		explainer := NewExplainer(getter, clientset.CoreV1().Pods("default"), clientset.CoreV1().Nodes(), defaults)
+		out := new(strings.Builder)
+		err := explainer.Explain(out, name)
+		return out.String(), err
+	}
+
+	BeforeEach(func() {
+		defaults = &config.Defaults{
+			DefaultPoolLabels: &config.PoolLabelMap{
+				Client: "default-client-pool",
+				Driver: "default-driver-pool",
+				Server: "default-server-pool",
+			},
+		}
+		nodes = []runtime.Object{
+			newExplainNode("driver-node", "drivers"),
+			newExplainNode("worker-node-0", "workers"),
+			newExplainNode("worker-node-1", "workers"),
+		}
+	})
+
+	It("reports the shortfall of a test that waits for nodes", func() {
+		objects := append(nodes, newExplainPod("other-client", "other", config.ClientRole, "client", "workers"))
+		getter := fake.NewLoadTestGetter("default", newExplainTest("test"))
+
+		out, err := explain(getter, objects, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("Pods: 0/3 created"))
+		Expect(out).To(ContainSubstring("Scheduling: waiting for nodes to be freed"))
+		Expect(out).To(ContainSubstring(`pool "workers" needs 1 more nodes (requires 2, has 1 available)`))
+		Expect(out).To(MatchRegexp(`workers\s+2\s+1`))
+	})
+
+	It("reserves nodes for preceding tests", func() {
+		preceding := newExplainTest("preceding")
+		preceding.Spec.Priority = 1
+		getter := fake.NewLoadTestGetter("default", newExplainTest("test"), preceding)
+
+		out, err := explain(getter, nodes, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("Preceding tests holding nodes: preceding"))
+		Expect(out).To(ContainSubstring(`pool "drivers" needs 1 more nodes (requires 1, has 0 available)`))
+		Expect(out).To(ContainSubstring(`pool "workers" needs 2 more nodes (requires 2, has 0 available)`))
+	})
+
+	It("reports when the cluster can schedule the missing pods", func() {
+		getter := fake.NewLoadTestGetter("default", newExplainTest("test"))
+
+		out, err := explain(getter, nodes, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("Scheduling: the cluster has enough available nodes for the missing pods"))
+	})
+
+	It("reports the pending reasons of pods that have been created", func() {
+		objects := nodes
+		for _, pod := range []*corev1.Pod{
+			newExplainPod("test-driver", "test", config.DriverRole, "driver", "drivers"),
+			newExplainPod("test-server", "test", config.ServerRole, "server", "workers"),
+			newExplainPod("test-client", "test", config.ClientRole, "client", "workers"),
+		} {
+			pod.Status.Conditions = []corev1.PodCondition{{
+				Type:    corev1.PodScheduled,
+				Status:  corev1.ConditionFalse,
+				Message: "0/3 nodes are available",
+			}}
+			objects = append(objects, pod)
+		}
+		getter := fake.NewLoadTestGetter("default", newExplainTest("test"))
+
+		out, err := explain(getter, objects, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("Pods: 3/3 created"))
+		Expect(out).To(ContainSubstring(`AwaitingSchedule: pod "test-server" is waiting to be scheduled: 0/3 nodes are available`))
+		Expect(out).To(ContainSubstring("Scheduling: all pods have been created"))
+	})
+
+	It("does not diagnose a terminated test", func() {
+		test := newExplainTest("test")
+		test.Status.State = grpcv1.Succeeded
+		test.Status.Reason = ""
+		getter := fake.NewLoadTestGetter("default", test)
+
+		out, err := explain(getter, nodes, "test")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(ContainSubstring("The test has terminated and is not waiting."))
+		Expect(out).ToNot(ContainSubstring("Scheduling"))
+	})
+
+	It("returns an error when the test does not exist", func() {
+		_, err := explain(fake.NewLoadTestGetter("default"), nodes, "test")
+		Expect(err).To(HaveOccurred())
+	})
+})
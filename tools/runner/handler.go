@@ -0,0 +1,88 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// TestInvocation identifies a single run of a test by the runner.
+type TestInvocation struct {
+	// Queue is the name of the queue that ran the test.
+	Queue string
+	// Index is the index of the test in its queue.
+	Index int
+	// Name is the name of the LoadTest.
+	Name string
+	// Drivers is the number of driver pods of the test. This is always one,
+	// since the controller adds a driver to tests that do not specify one.
+	Drivers int
+	// Servers is the number of server pods of the test.
+	Servers int
+	// Clients is the number of client pods of the test.
+	Clients int
+}
+
+// NewTestInvocation returns the invocation of a test, given its queue, its
+// index in the queue and its configuration. The number of pods of each role
+// is counted from the configuration. Each pod takes a node of its pool, so
+// these are also the number of nodes that the test uses, whether its pools
+// are set or left to the defaults of the controller.
+func NewTestInvocation(qName string, index int, config *grpcv1.LoadTest) TestInvocation {
+	return TestInvocation{
+		Queue:   qName,
+		Index:   index,
+		Name:    config.Name,
+		Drivers: 1,
+		Servers: len(config.Spec.Servers),
+		Clients: len(config.Spec.Clients),
+	}
+}
+
+// Nodes returns the number of nodes that the test uses, which is the number
+// of its pods.
+func (i *TestInvocation) Nodes() int {
+	return i.Drivers + i.Servers + i.Clients
+}
+
+// countsString returns a string that describes the number of pods of each
+// role, for logs.
+func (i *TestInvocation) countsString() string {
+	return fmt.Sprintf("%d nodes: %d drivers, %d servers, %d clients", i.Nodes(), i.Drivers, i.Servers, i.Clients)
+}
+
+// ResultHandler is notified of tests that terminate successfully. It can be
+// used to upload artifacts or send notifications once the results of a test
+// are available.
+type ResultHandler interface {
+	// HandleResult is called once for each test that succeeds, with the
+	// terminated LoadTest. An error is logged as a warning, and does not
+	// change the outcome of the test.
+	HandleResult(invocation *TestInvocation, test *grpcv1.LoadTest) error
+}
+
+// handleResult passes a successful test to the result handler, if one is set.
+func (r *Runner) handleResult(loadTest *grpcv1.LoadTest, reporter *TestCaseReporter) {
+	if r.resultHandler == nil || loadTest.Status.State != grpcv1.Succeeded {
+		return
+	}
+	// The name of an adopted test may differ from the name it started with.
+	invocation := reporter.Invocation()
+	invocation.Name = loadTest.Name
+	if err := r.resultHandler.HandleResult(&invocation, loadTest); err != nil {
+		reporter.Warning("Failed to handle result of test %s: %v", nameString(loadTest), err)
+	}
+}
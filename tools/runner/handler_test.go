@@ -0,0 +1,99 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+// fakeResultHandler records the invocations it handles, and returns a fixed
+// error.
+type fakeResultHandler struct {
+	err error
+
+	mux         sync.Mutex
+	invocations []TestInvocation
+}
+
+func (f *fakeResultHandler) HandleResult(invocation *TestInvocation, test *grpcv1.LoadTest) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.invocations = append(f.invocations, *invocation)
+	return f.err
+}
+
+// handled returns a copy of the invocations that were handled.
+func (f *fakeResultHandler) handled() []TestInvocation {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return append([]TestInvocation(nil), f.invocations...)
+}
+
+var _ = Describe("ResultHandler", func() {
+	run := func(state grpcv1.LoadTestState, handler ResultHandler) *junit.TestSuites {
+		getter := newFakeGetter(state)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetResultHandler(handler)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+		return decodeReport(report)
+	}
+
+	It("is called for each test that succeeds", func() {
+		handler := &fakeResultHandler{}
+		run(grpcv1.Succeeded, handler)
+		Expect(handler.handled()).To(Equal([]TestInvocation{
+			{Queue: "queue", Index: 0, Name: "test-0", Drivers: 1},
+			{Queue: "queue", Index: 1, Name: "test-1", Drivers: 1},
+		}))
+	})
+
+	It("is not called for tests that fail", func() {
+		handler := &fakeResultHandler{}
+		run(grpcv1.Errored, handler)
+		Expect(handler.handled()).To(BeEmpty())
+	})
+
+	It("does not fail tests when it returns an error", func() {
+		handler := &fakeResultHandler{err: errors.New("upload failed")}
+		decoded := run(grpcv1.Succeeded, handler)
+		Expect(handler.handled()).To(HaveLen(2))
+		for _, c := range decoded.Suites[0].Cases {
+			Expect(c.Failures).To(BeEmpty())
+		}
+	})
+
+	It("may be nil", func() {
+		decoded := run(grpcv1.Succeeded, nil)
+		Expect(decoded.Suites[0].Cases).To(HaveLen(2))
+	})
+})
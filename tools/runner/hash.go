@@ -0,0 +1,98 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// HashLabel is the label with a hash of the configuration of a test. The
+// runner sets it on each test it creates, so a later run can find tests with
+// identical configurations.
+const HashLabel = "runner-hash"
+
+// hashLength is the number of bytes of the SHA-256 digest that are kept, so
+// the hex-encoded hash fits in a label value.
+const hashLength = 16
+
+// ConfigHash returns a hash of the name, labels, annotations and spec of a
+// test configuration. The HashLabel itself is not included, so the hash does
+// not change once the label is set.
+func ConfigHash(config *grpcv1.LoadTest) (string, error) {
+	labels := make(map[string]string, len(config.Labels))
+	for key, value := range config.Labels {
+		if key != HashLabel {
+			labels[key] = value
+		}
+	}
+
+	data, err := json.Marshal(struct {
+		Name        string              `json:"name"`
+		Labels      map[string]string   `json:"labels"`
+		Annotations map[string]string   `json:"annotations"`
+		Spec        grpcv1.LoadTestSpec `json:"spec"`
+	}{
+		Name:        config.Name,
+		Labels:      labels,
+		Annotations: config.Annotations,
+		Spec:        config.Spec,
+	})
+	if err != nil {
+		return "", fmt.Errorf("could not encode configuration of %s: %v", config.Name, err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:hashLength]), nil
+}
+
+// setHashLabel sets the HashLabel on a test configuration and returns the
+// hash. The labels are copied, so other configurations that share the label
+// map are not changed.
+func setHashLabel(config *grpcv1.LoadTest) (string, error) {
+	hash, err := ConfigHash(config)
+	if err != nil {
+		return "", err
+	}
+	labels := make(map[string]string, len(config.Labels)+1)
+	for key, value := range config.Labels {
+		labels[key] = value
+	}
+	labels[HashLabel] = hash
+	config.Labels = labels
+	return hash, nil
+}
+
+// findExistingTest returns a test on the cluster with the same configuration
+// hash, or nil if there is none.
+func (r *Runner) findExistingTest(hash string) (*grpcv1.LoadTest, error) {
+	list, err := r.loadTestGetter.List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", HashLabel, hash),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		if list.Items[i].DeletionTimestamp == nil {
+			return &list.Items[i], nil
+		}
+	}
+	return nil, nil
+}
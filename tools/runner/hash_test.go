@@ -0,0 +1,160 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("ConfigHash", func() {
+	var config *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		config = &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "test",
+				Labels: map[string]string{"language": "go"},
+			},
+			Spec: grpcv1.LoadTestSpec{TimeoutSeconds: 900},
+		}
+	})
+
+	It("returns a valid label value", func() {
+		hash, err := ConfigHash(config)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(validation.IsValidLabelValue(hash)).To(BeEmpty())
+	})
+
+	It("ignores the hash label", func() {
+		hash, err := ConfigHash(config)
+		Expect(err).ToNot(HaveOccurred())
+		config.Labels[HashLabel] = hash
+		Expect(ConfigHash(config)).To(Equal(hash))
+	})
+
+	It("changes when the spec changes", func() {
+		hash, err := ConfigHash(config)
+		Expect(err).ToNot(HaveOccurred())
+		config.Spec.TimeoutSeconds = 600
+		Expect(ConfigHash(config)).ToNot(Equal(hash))
+	})
+})
+
+var _ = Describe("Runner adopting existing tests", func() {
+	var config *grpcv1.LoadTest
+	var existing *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		config = &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		}
+		hash, err := ConfigHash(config)
+		Expect(err).ToNot(HaveOccurred())
+		existing = &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "test-from-earlier-run",
+				Labels: map[string]string{HashLabel: hash},
+			},
+		}
+	})
+
+	run := func(getter *fake.LoadTestGetter) *junit.TestSuites {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetAdoptExisting(true)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{config}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+		return decodeReport(report)
+	}
+
+	It("monitors a running test instead of creating a new one", func() {
+		existing.Status.State = grpcv1.Running
+		getter := fake.NewLoadTestGetter("default", existing)
+		getter.SetStatuses(existing.Name,
+			grpcv1.LoadTestStatus{State: grpcv1.Running},
+			grpcv1.LoadTestStatus{State: grpcv1.Succeeded},
+		)
+
+		suites := run(getter)
+		Expect(suites.Suites[0].Cases[0].Failures).To(BeEmpty())
+
+		list, err := getter.List(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].Name).To(Equal(existing.Name))
+	})
+
+	It("reports the result of a terminated test", func() {
+		existing.Status.State = grpcv1.Errored
+		getter := fake.NewLoadTestGetter("default", existing)
+
+		suites := run(getter)
+		Expect(suites.Suites[0].Cases[0].Failures).ToNot(BeEmpty())
+
+		list, err := getter.List(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(list.Items).To(HaveLen(1))
+	})
+
+	It("holds a global slot while monitoring a running test", func() {
+		existing.Status.State = grpcv1.Running
+		getter := fake.NewLoadTestGetter("default", existing)
+		getter.SetStatuses(existing.Name, grpcv1.LoadTestStatus{State: grpcv1.Running})
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetAdoptExisting(true)
+		r.SetGlobalConcurrency(1)
+		report := junit.NewReport("report-id", "report")
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan string)
+
+		go r.Run(ctx, []*grpcv1.LoadTest{config}, NewTestSuiteReporter("a", "[%s %d] ", report.NewTestSuite("a", SuiteName("a"))), 1, done)
+		Eventually(func() []string { return actionNames(getter, "get") }).Should(ContainElement(existing.Name))
+
+		other := &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{Name: "other"},
+			Spec:       grpcv1.LoadTestSpec{TimeoutSeconds: 600},
+		}
+		go r.Run(ctx, []*grpcv1.LoadTest{other}, NewTestSuiteReporter("b", "[%s %d] ", report.NewTestSuite("b", SuiteName("b"))), 1, done)
+		Consistently(func() int { return countActions(getter, "create") }).Should(BeZero())
+
+		cancel()
+		Eventually(done).Should(Receive())
+		Eventually(done).Should(Receive())
+	})
+
+	It("creates the test when no test matches", func() {
+		existing.Labels[HashLabel] = "other"
+		getter := fake.NewLoadTestGetter("default", existing)
+		getter.SetStatuses(config.Name, grpcv1.LoadTestStatus{State: grpcv1.Succeeded})
+
+		run(getter)
+
+		created, err := getter.Get(config.Name, metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(created.Labels).To(HaveKey(HashLabel))
+	})
+})
@@ -0,0 +1,44 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+	"net/http"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	clientset "github.com/grpc/test-infra/clientset"
+)
+
+// NewHealthHandler returns a handler for liveness and readiness probes.
+//
+// The handler serves /healthz, which succeeds as long as the process is able
+// to respond, and /readyz, which succeeds only if the cluster is reachable.
+// Readiness is checked by listing at most one LoadTest, so probes are cheap
+// and do not interfere with tests that are running.
+func NewHealthHandler(loadTestGetter clientset.LoadTestGetter) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := loadTestGetter.List(metav1.ListOptions{Limit: 1}); err != nil {
+			http.Error(w, fmt.Sprintf("cluster unreachable: %v", err), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
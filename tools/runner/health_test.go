@@ -0,0 +1,67 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/grpc/test-infra/clientset/fake"
+)
+
+var _ = Describe("NewHealthHandler", func() {
+	serve := func(getter *fake.LoadTestGetter, path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		NewHealthHandler(getter).ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	Context("with a healthy clientset", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+
+		It("reports alive", func() {
+			Expect(serve(getter, "/healthz").Code).To(Equal(http.StatusOK))
+		})
+
+		It("reports ready", func() {
+			Expect(serve(getter, "/readyz").Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	Context("with an unhealthy clientset", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetError("list", errors.New("connection refused"))
+
+		It("reports alive", func() {
+			Expect(serve(getter, "/healthz").Code).To(Equal(http.StatusOK))
+		})
+
+		It("reports not ready with the error", func() {
+			recorder := serve(getter, "/readyz")
+			Expect(recorder.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(recorder.Body.String()).To(ContainSubstring("connection refused"))
+		})
+	})
+
+	It("does not serve other paths", func() {
+		Expect(serve(fake.NewLoadTestGetter(corev1.NamespaceDefault), "/metrics").Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package junit contains a model of JUnit XML reports, as well as code to
+// build a report while tests are running and write it when they are done.
+package junit
+
+import (
+	"encoding/xml"
+)
+
+// FailureType categorizes a failure in a test case.
+type FailureType string
+
+const (
+	// Error indicates that the test case encountered an error that prevented
+	// it from completing successfully.
+	Error FailureType = "error"
+
+	// Timeout indicates that the test case did not complete before its
+	// timeout.
+	Timeout FailureType = "timeout"
+)
+
+// TestSuites is the root element of a JUnit report. It contains the test
+// suites, along with totals for all of their test cases.
+type TestSuites struct {
+	XMLName xml.Name `xml:"testsuites"`
+
+	// ID uniquely identifies the run that produced the report.
+	ID string `xml:"id,attr"`
+
+	// Name is a human readable name for the run.
+	Name string `xml:"name,attr"`
+
+	// TestCount is the number of test cases in all test suites.
+	TestCount int `xml:"tests,attr"`
+
+	// FailureCount is the number of test cases in all test suites that
+	// have at least one failure.
+	FailureCount int `xml:"failures,attr"`
+
+	// SkippedCount is the number of test cases in all test suites that
+	// were skipped.
+	SkippedCount int `xml:"skipped,attr"`
+
+	// TimeInSeconds is the duration of the run.
+	TimeInSeconds float64 `xml:"time,attr"`
+
+	// Properties describe the run as a whole, such as a summary of results.
+	// The properties element is omitted if there are none.
+	Properties []*Property `xml:"properties>property,omitempty"`
+
+	// Suites are the test suites in the report.
+	Suites []*TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a group of related test cases.
+type TestSuite struct {
+	XMLName xml.Name `xml:"testsuite"`
+
+	// ID uniquely identifies the test suite within the report.
+	ID string `xml:"id,attr"`
+
+	// Name is a human readable name for the test suite.
+	Name string `xml:"name,attr"`
+
+	// TestCount is the number of test cases in the test suite.
+	TestCount int `xml:"tests,attr"`
+
+	// FailureCount is the number of test cases in the test suite that have
+	// at least one failure.
+	FailureCount int `xml:"failures,attr"`
+
+	// SkippedCount is the number of test cases in the test suite that were
+	// skipped.
+	SkippedCount int `xml:"skipped,attr"`
+
+	// TimeInSeconds is the duration of the test suite.
+	TimeInSeconds float64 `xml:"time,attr"`
+
+	// Cases are the test cases in the test suite.
+	Cases []*TestCase `xml:"testcase"`
+}
+
+// TestCase is the result of a single test.
+type TestCase struct {
+	XMLName xml.Name `xml:"testcase"`
+
+	// ID uniquely identifies the test case within the report.
+	ID string `xml:"id,attr"`
+
+	// Name is a human readable name for the test case.
+	Name string `xml:"name,attr"`
+
+	// TimeInSeconds is the duration of the test case.
+	TimeInSeconds float64 `xml:"time,attr"`
+
+	// Properties are results of the test case, such as benchmark numbers.
+	// The properties element is omitted if there are none.
+	Properties []*Property `xml:"properties>property,omitempty"`
+
+	// Artifacts are references to files produced by the test case, such as
+	// profiles. The artifacts element is omitted if there are none.
+	Artifacts []*Artifact `xml:"artifacts>artifact,omitempty"`
+
+	// Skipped is set if the test case was not run.
+	Skipped *Skipped `xml:"skipped,omitempty"`
+
+	// Failures are the problems that caused the test case to fail. A test
+	// case without failures has passed, unless it was skipped.
+	Failures []*Failure `xml:"failure,omitempty"`
+}
+
+// Property is a named value that describes a result of a test case.
+type Property struct {
+	XMLName xml.Name `xml:"property"`
+
+	// Name identifies the property.
+	Name string `xml:"name,attr"`
+
+	// Value is the value of the property.
+	Value string `xml:"value,attr"`
+}
+
+// Artifact is a reference to a file produced by a test case.
+type Artifact struct {
+	XMLName xml.Name `xml:"artifact"`
+
+	// Name identifies the artifact.
+	Name string `xml:"name,attr"`
+
+	// URI is the location of the artifact.
+	URI string `xml:"uri,attr"`
+
+	// ContentType is the media type of the artifact, if it is known.
+	ContentType string `xml:"content-type,attr,omitempty"`
+}
+
+// Skipped marks a test case that was not run.
+type Skipped struct {
+	XMLName xml.Name `xml:"skipped"`
+
+	// Message describes why the test case was skipped.
+	Message string `xml:"message,attr"`
+}
+
+// Failure describes a problem that caused a test case to fail.
+type Failure struct {
+	XMLName xml.Name `xml:"failure"`
+
+	// Type categorizes the failure.
+	Type FailureType `xml:"type,attr"`
+
+	// Message is a short description of the failure.
+	Message string `xml:"message,attr"`
+
+	// Text contains details about the failure, such as logs.
+	Text string `xml:",chardata"`
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package junit
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// NameTemplateData contains the fields that may be used in a template for the
+// name of a report. For example, "nightly-{{.Date}}-{{.GitRef}}".
+type NameTemplateData struct {
+	// Date is the date of the run, formatted as YYYYMMDD.
+	Date string
+
+	// Timestamp is the time of the run, formatted as RFC 3339.
+	Timestamp string
+
+	// GitRef is the branch, tag or commit of the code under test.
+	GitRef string
+
+	// Cluster is the name of the cluster where the tests run.
+	Cluster string
+}
+
+// ParseNameTemplate parses a template for the name of a report. Templates are
+// executed once with empty data, so references to unknown fields are caught
+// here rather than after the tests have run.
+func ParseNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse name template: %v", err)
+	}
+	if _, err := RenderName(tmpl, NameTemplateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// RenderName executes a template for the name of a report with the given data.
+func RenderName(tmpl *template.Template, data NameTemplateData) (string, error) {
+	b := &strings.Builder{}
+	if err := tmpl.Execute(b, data); err != nil {
+		return "", fmt.Errorf("failed to render name template: %v", err)
+	}
+	return b.String(), nil
+}
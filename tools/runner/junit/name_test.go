@@ -0,0 +1,60 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package junit
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseNameTemplate", func() {
+	It("parses a template with known fields", func() {
+		_, err := ParseNameTemplate("nightly-{{.Date}}-{{.GitRef}}-{{.Cluster}}")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns an error for a template with a syntax error", func() {
+		_, err := ParseNameTemplate("nightly-{{.Date")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error for a template with an unknown field", func() {
+		_, err := ParseNameTemplate("nightly-{{.Branch}}")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("RenderName", func() {
+	It("renders all fields", func() {
+		tmpl, err := ParseNameTemplate("nightly-{{.Date}}-{{.GitRef}}-{{.Cluster}}")
+		Expect(err).ToNot(HaveOccurred())
+
+		name, err := RenderName(tmpl, NameTemplateData{
+			Date:    "20210401",
+			GitRef:  "v1.37.0",
+			Cluster: "benchmarks-prod",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("nightly-20210401-v1.37.0-benchmarks-prod"))
+	})
+
+	It("renders a template without fields unchanged", func() {
+		tmpl, err := ParseNameTemplate("nightly")
+		Expect(err).ToNot(HaveOccurred())
+
+		name, err := RenderName(tmpl, NameTemplateData{Date: "20210401"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(name).To(Equal("nightly"))
+	})
+})
@@ -0,0 +1,344 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package junit
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Report builds a JUnit report while tests are running. Test suites and test
+// cases are added through the report, which guards them with a mutex. This
+// allows each queue of the runner to record results concurrently.
+type Report struct {
+	mux        sync.Mutex
+	testSuites *TestSuites
+}
+
+// NewReport creates a new, empty report with an ID and a name.
+func NewReport(id, name string) *Report {
+	return &Report{
+		testSuites: &TestSuites{
+			ID:   id,
+			Name: name,
+		},
+	}
+}
+
+// NewTestSuite adds a test suite to the report and returns a handle to it.
+func (r *Report) NewTestSuite(id, name string) *ReportTestSuite {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	testSuite := &TestSuite{
+		ID:   id,
+		Name: name,
+	}
+	r.testSuites.Suites = append(r.testSuites.Suites, testSuite)
+	return &ReportTestSuite{
+		report:    r,
+		testSuite: testSuite,
+	}
+}
+
+// SetDuration records the duration of the whole run.
+func (r *Report) SetDuration(d time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.testSuites.TimeInSeconds = d.Seconds()
+}
+
+// Duration returns the duration of the whole run, as recorded by SetDuration.
+func (r *Report) Duration() time.Duration {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return time.Duration(r.testSuites.TimeInSeconds * float64(time.Second))
+}
+
+// ForEachTestCase calls a function with each test case of the report, in the
+// order of its test suites, while holding the lock of the report. The
+// function must not modify the test case or call methods of the report.
+func (r *Report) ForEachTestCase(fn func(testCase *TestCase)) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, testSuite := range r.testSuites.Suites {
+		for _, testCase := range testSuite.Cases {
+			fn(testCase)
+		}
+	}
+}
+
+// AddProperty records a named value that describes the whole run.
+func (r *Report) AddProperty(name, value string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.testSuites.Properties = append(r.testSuites.Properties, &Property{
+		Name:  name,
+		Value: value,
+	})
+}
+
+// SuiteSummary tallies the outcomes of the test cases in a test suite.
+type SuiteSummary struct {
+	// ID is the ID of the test suite.
+	ID string
+
+	// Passed is the number of test cases that ran without failures.
+	Passed int
+
+	// Failed is the number of test cases with at least one failure.
+	Failed int
+
+	// Skipped is the number of test cases that were skipped without
+	// failures.
+	Skipped int
+}
+
+// Summarize tallies the outcomes of the test cases in each test suite. The
+// summaries are returned in the order in which the test suites were added.
+func (r *Report) Summarize() []SuiteSummary {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	summaries := make([]SuiteSummary, 0, len(r.testSuites.Suites))
+	for _, testSuite := range r.testSuites.Suites {
+		summary := SuiteSummary{ID: testSuite.ID}
+		for _, testCase := range testSuite.Cases {
+			switch {
+			case len(testCase.Failures) > 0:
+				summary.Failed++
+			case testCase.Skipped != nil:
+				summary.Skipped++
+			default:
+				summary.Passed++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// Finalize computes the test, failure and skipped counts for each test suite
+// and for the report as a whole. The time of each test suite is the sum of the times
+// of its test cases.
+func (r *Report) Finalize() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.testSuites.TestCount = 0
+	r.testSuites.FailureCount = 0
+	r.testSuites.SkippedCount = 0
+	for _, testSuite := range r.testSuites.Suites {
+		testSuite.TestCount = 0
+		testSuite.FailureCount = 0
+		testSuite.SkippedCount = 0
+		testSuite.TimeInSeconds = 0
+		for _, testCase := range testSuite.Cases {
+			testSuite.TestCount++
+			if len(testCase.Failures) > 0 {
+				testSuite.FailureCount++
+			}
+			if testCase.Skipped != nil {
+				testSuite.SkippedCount++
+			}
+			testSuite.TimeInSeconds += testCase.TimeInSeconds
+		}
+		r.testSuites.TestCount += testSuite.TestCount
+		r.testSuites.FailureCount += testSuite.FailureCount
+		r.testSuites.SkippedCount += testSuite.SkippedCount
+	}
+}
+
+// Sort orders the test suites by name, and the test cases in each test suite
+// by name. Since queues add test cases concurrently, this makes reports of the
+// same tests comparable. Test suites or test cases with the same name keep
+// their relative order.
+func (r *Report) Sort() {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	suites := r.testSuites.Suites
+	sort.SliceStable(suites, func(i, j int) bool {
+		return suites[i].Name < suites[j].Name
+	})
+	for _, testSuite := range suites {
+		cases := testSuite.Cases
+		sort.SliceStable(cases, func(i, j int) bool {
+			return cases[i].Name < cases[j].Name
+		})
+	}
+}
+
+// WriteToStream writes the report as XML to a stream. Each level of nesting
+// is indented with indentSize spaces.
+func (r *Report) WriteToStream(w io.Writer, indentSize int) error {
+	return r.WriteToStreamContext(context.Background(), w, indentSize)
+}
+
+// WriteToStreamContext writes the report as XML to a stream, like
+// WriteToStream. It returns an error wrapping the context error if the context
+// is done before the report is written, even if a call to the writer is
+// blocked. A blocked call is left to return in the background, so the writer
+// should not be reused. The error reports how many bytes were written.
+func (r *Report) WriteToStreamContext(ctx context.Context, w io.Writer, indentSize int) error {
+	data, err := r.encode(indentSize)
+	if err != nil {
+		return err
+	}
+
+	var written int64
+	done := make(chan error, 1)
+	go func() {
+		var n int
+		for n < len(data) {
+			if err := ctx.Err(); err != nil {
+				done <- err
+				return
+			}
+			m, err := w.Write(data[n:])
+			n += m
+			atomic.StoreInt64(&written, int64(n))
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report (wrote %d of %d bytes): %w", atomic.LoadInt64(&written), len(data), err)
+	}
+	return nil
+}
+
+// encode returns the report as an XML document.
+func (r *Report) encode(indentSize int) ([]byte, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	data, err := xml.MarshalIndent(r.testSuites, "", strings.Repeat(" ", indentSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report: %v", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+	return data, nil
+}
+
+// ReportTestSuite is a handle to a test suite in a report.
+type ReportTestSuite struct {
+	report    *Report
+	testSuite *TestSuite
+}
+
+// NewTestCase adds a test case to the test suite and returns a handle to it.
+func (s *ReportTestSuite) NewTestCase(id, name string) *ReportTestCase {
+	s.report.mux.Lock()
+	defer s.report.mux.Unlock()
+	testCase := &TestCase{
+		ID:   id,
+		Name: name,
+	}
+	s.testSuite.Cases = append(s.testSuite.Cases, testCase)
+	return &ReportTestCase{
+		report:   s.report,
+		testCase: testCase,
+	}
+}
+
+// ReportTestCase is a handle to a test case in a report.
+type ReportTestCase struct {
+	report   *Report
+	testCase *TestCase
+}
+
+// AddFailure records a failure on the test case.
+func (c *ReportTestCase) AddFailure(failureType FailureType, message, text string) {
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	c.testCase.Failures = append(c.testCase.Failures, &Failure{
+		Type:    failureType,
+		Message: message,
+		Text:    text,
+	})
+}
+
+// Failed returns true if any failure was recorded on the test case.
+func (c *ReportTestCase) Failed() bool {
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	return len(c.testCase.Failures) > 0
+}
+
+// AddProperty records a named result on the test case.
+func (c *ReportTestCase) AddProperty(name, value string) {
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	c.testCase.Properties = append(c.testCase.Properties, &Property{
+		Name:  name,
+		Value: value,
+	})
+}
+
+// AddArtifact records a reference to a file produced by the test case. The
+// content type is inferred from the extension of the path of the URI, and is
+// left empty if the extension is unknown.
+func (c *ReportTestCase) AddArtifact(name, uri string) {
+	var contentType string
+	if u, err := url.Parse(uri); err == nil {
+		contentType = mime.TypeByExtension(path.Ext(u.Path))
+	}
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	c.testCase.Artifacts = append(c.testCase.Artifacts, &Artifact{
+		Name:        name,
+		URI:         uri,
+		ContentType: contentType,
+	})
+}
+
+// SetSkipped marks the test case as skipped.
+func (c *ReportTestCase) SetSkipped(message string) {
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	c.testCase.Skipped = &Skipped{
+		Message: message,
+	}
+}
+
+// Skipped returns true if the test case was marked as skipped.
+func (c *ReportTestCase) Skipped() bool {
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	return c.testCase.Skipped != nil
+}
+
+// SetDuration records the duration of the test case.
+func (c *ReportTestCase) SetDuration(d time.Duration) {
+	c.report.mux.Lock()
+	defer c.report.mux.Unlock()
+	c.testCase.TimeInSeconds = d.Seconds()
+}
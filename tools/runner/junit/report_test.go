@@ -0,0 +1,292 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package junit
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Report", func() {
+	It("writes a report that can be decoded", func() {
+		report := NewReport("report-id", "nightly")
+		suite := report.NewTestSuite("suite-id", "loadtests")
+		passed := suite.NewTestCase("case-0", "passed")
+		passed.SetDuration(2 * time.Second)
+		failed := suite.NewTestCase("case-1", "failed")
+		failed.AddFailure(Error, "Errored; ContainerError", "")
+		report.Finalize()
+
+		buf := &bytes.Buffer{}
+		Expect(report.WriteToStream(buf, 2)).To(Succeed())
+
+		decoded := new(TestSuites)
+		Expect(xml.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+		Expect(decoded.Name).To(Equal("nightly"))
+		Expect(decoded.TestCount).To(Equal(2))
+		Expect(decoded.FailureCount).To(Equal(1))
+		Expect(decoded.Suites).To(HaveLen(1))
+		Expect(decoded.Suites[0].Cases).To(HaveLen(2))
+		Expect(decoded.Suites[0].Cases[0].TimeInSeconds).To(Equal(2.0))
+		Expect(decoded.Suites[0].Cases[1].Failures).To(HaveLen(1))
+		Expect(decoded.Suites[0].Cases[1].Failures[0].Type).To(Equal(Error))
+	})
+
+	It("aggregates counts and times for each test suite", func() {
+		report := NewReport("report-id", "nightly")
+
+		suiteA := report.NewTestSuite("queue-a", "queue-a")
+		suiteA.NewTestCase("queue-a/0", "a0").SetDuration(1 * time.Second)
+		failedA := suiteA.NewTestCase("queue-a/1", "a1")
+		failedA.SetDuration(2 * time.Second)
+		failedA.AddFailure(Error, "Errored", "")
+
+		suiteB := report.NewTestSuite("queue-b", "queue-b")
+		suiteB.NewTestCase("queue-b/0", "b0").SetDuration(3 * time.Second)
+		suiteB.NewTestCase("queue-b/1", "b1").SetDuration(4 * time.Second)
+		failedB := suiteB.NewTestCase("queue-b/2", "b2")
+		failedB.AddFailure(Error, "Errored", "")
+		failedB.AddFailure(Error, "Errored again", "")
+
+		report.Finalize()
+
+		suites := report.testSuites.Suites
+		Expect(suites).To(HaveLen(2))
+		Expect(suites[0].TestCount).To(Equal(2))
+		Expect(suites[0].FailureCount).To(Equal(1))
+		Expect(suites[0].TimeInSeconds).To(Equal(3.0))
+		Expect(suites[1].TestCount).To(Equal(3))
+		Expect(suites[1].FailureCount).To(Equal(1))
+		Expect(suites[1].TimeInSeconds).To(Equal(7.0))
+
+		var testCount, failureCount int
+		for _, suite := range suites {
+			testCount += suite.TestCount
+			failureCount += suite.FailureCount
+		}
+		Expect(report.testSuites.TestCount).To(Equal(testCount))
+		Expect(report.testSuites.FailureCount).To(Equal(failureCount))
+	})
+
+	It("recomputes counts when finalized more than once", func() {
+		report := NewReport("report-id", "nightly")
+		suite := report.NewTestSuite("queue", "queue")
+		suite.NewTestCase("queue/0", "0")
+		report.Finalize()
+		suite.NewTestCase("queue/1", "1")
+		report.Finalize()
+
+		Expect(report.testSuites.Suites[0].TestCount).To(Equal(2))
+		Expect(report.testSuites.TestCount).To(Equal(2))
+	})
+
+	It("counts skipped test cases", func() {
+		report := NewReport("report-id", "nightly")
+		suite := report.NewTestSuite("queue", "queue")
+		suite.NewTestCase("queue/0", "0").SetSkipped("dry run")
+		suite.NewTestCase("queue/1", "1")
+		report.Finalize()
+
+		buf := &bytes.Buffer{}
+		Expect(report.WriteToStream(buf, 0)).To(Succeed())
+
+		decoded := new(TestSuites)
+		Expect(xml.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+		Expect(decoded.SkippedCount).To(Equal(1))
+		Expect(decoded.Suites[0].SkippedCount).To(Equal(1))
+		Expect(decoded.Suites[0].Cases[0].Skipped).ToNot(BeNil())
+		Expect(decoded.Suites[0].Cases[0].Skipped.Message).To(Equal("dry run"))
+		Expect(decoded.Suites[0].Cases[1].Skipped).To(BeNil())
+	})
+
+	It("writes properties of test cases", func() {
+		report := NewReport("report-id", "nightly")
+		suite := report.NewTestSuite("queue", "queue")
+		withResults := suite.NewTestCase("queue/0", "0")
+		withResults.AddProperty("qps", "12345.6")
+		withResults.AddProperty("latency-p99", "0.002")
+		suite.NewTestCase("queue/1", "1")
+		report.Finalize()
+
+		buf := &bytes.Buffer{}
+		Expect(report.WriteToStream(buf, 0)).To(Succeed())
+		Expect(strings.Count(buf.String(), "<properties>")).To(Equal(1))
+
+		decoded := new(TestSuites)
+		Expect(xml.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+		properties := decoded.Suites[0].Cases[0].Properties
+		Expect(properties).To(HaveLen(2))
+		Expect(properties[0].Name).To(Equal("qps"))
+		Expect(properties[0].Value).To(Equal("12345.6"))
+		Expect(properties[1].Name).To(Equal("latency-p99"))
+		Expect(properties[1].Value).To(Equal("0.002"))
+		Expect(decoded.Suites[0].Cases[1].Properties).To(BeEmpty())
+	})
+
+	It("writes artifacts of test cases", func() {
+		report := NewReport("report-id", "nightly")
+		suite := report.NewTestSuite("queue", "queue")
+		withArtifacts := suite.NewTestCase("queue/0", "0")
+		withArtifacts.AddArtifact("flamegraph", "gs://results/queue/0/flamegraph.svg?generation=1")
+		withArtifacts.AddArtifact("profile", "gs://results/queue/0/cpu.pprof")
+		suite.NewTestCase("queue/1", "1")
+		report.Finalize()
+
+		buf := &bytes.Buffer{}
+		Expect(report.WriteToStream(buf, 0)).To(Succeed())
+		Expect(strings.Count(buf.String(), "<artifacts>")).To(Equal(1))
+
+		decoded := new(TestSuites)
+		Expect(xml.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+		artifacts := decoded.Suites[0].Cases[0].Artifacts
+		Expect(artifacts).To(HaveLen(2))
+		Expect(artifacts[0].Name).To(Equal("flamegraph"))
+		Expect(artifacts[0].URI).To(Equal("gs://results/queue/0/flamegraph.svg?generation=1"))
+		Expect(artifacts[0].ContentType).To(Equal("image/svg+xml"))
+		Expect(artifacts[1].Name).To(Equal("profile"))
+		Expect(artifacts[1].URI).To(Equal("gs://results/queue/0/cpu.pprof"))
+		Expect(artifacts[1].ContentType).To(BeEmpty())
+		Expect(decoded.Suites[0].Cases[1].Artifacts).To(BeEmpty())
+	})
+
+	It("writes properties of the report", func() {
+		report := NewReport("report-id", "nightly")
+		report.NewTestSuite("queue", "queue").NewTestCase("queue/0", "0")
+		report.AddProperty("queue.passed", "1")
+		report.Finalize()
+
+		buf := &bytes.Buffer{}
+		Expect(report.WriteToStream(buf, 0)).To(Succeed())
+
+		decoded := new(TestSuites)
+		Expect(xml.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+		Expect(decoded.Properties).To(HaveLen(1))
+		Expect(decoded.Properties[0].Name).To(Equal("queue.passed"))
+		Expect(decoded.Properties[0].Value).To(Equal("1"))
+		Expect(decoded.Suites[0].Cases[0].Properties).To(BeEmpty())
+	})
+
+	It("summarizes the outcomes of each test suite", func() {
+		report := NewReport("report-id", "nightly")
+
+		poolA := report.NewTestSuite("pool-a", "pool-a")
+		poolA.NewTestCase("pool-a/0", "a0")
+		poolA.NewTestCase("pool-a/1", "a1")
+		poolA.NewTestCase("pool-a/2", "a2").AddFailure(Error, "Errored", "")
+
+		poolB := report.NewTestSuite("pool-b", "pool-b")
+		failedB := poolB.NewTestCase("pool-b/0", "b0")
+		failedB.AddFailure(Error, "Errored", "")
+		failedB.AddFailure(Error, "Errored again", "")
+		poolB.NewTestCase("pool-b/1", "b1").AddFailure(Error, "Errored", "")
+		poolB.NewTestCase("pool-b/2", "b2").SetSkipped("cancelled")
+		poolB.NewTestCase("pool-b/3", "b3")
+
+		Expect(report.Summarize()).To(Equal([]SuiteSummary{
+			{ID: "pool-a", Passed: 2, Failed: 1, Skipped: 0},
+			{ID: "pool-b", Passed: 1, Failed: 2, Skipped: 1},
+		}))
+	})
+
+	It("sorts test suites and test cases by name", func() {
+		newReport := func(suiteOrder []string, caseOrder []string) *Report {
+			report := NewReport("report-id", "nightly")
+			for _, suiteName := range suiteOrder {
+				suite := report.NewTestSuite(suiteName, suiteName)
+				for _, caseName := range caseOrder {
+					suite.NewTestCase(suiteName+"/"+caseName, caseName)
+				}
+			}
+			report.Finalize()
+			report.Sort()
+			return report
+		}
+		write := func(report *Report) string {
+			buf := &bytes.Buffer{}
+			ExpectWithOffset(1, report.WriteToStream(buf, 2)).To(Succeed())
+			return buf.String()
+		}
+
+		first := newReport([]string{"queue-b", "queue-a"}, []string{"c", "a", "b"})
+		second := newReport([]string{"queue-a", "queue-b"}, []string{"b", "c", "a"})
+		Expect(write(first)).To(Equal(write(second)))
+
+		suites := first.testSuites.Suites
+		Expect(suites[0].Name).To(Equal("queue-a"))
+		Expect(suites[1].Name).To(Equal("queue-b"))
+		var caseNames []string
+		for _, testCase := range suites[0].Cases {
+			caseNames = append(caseNames, testCase.Name)
+		}
+		Expect(caseNames).To(Equal([]string{"a", "b", "c"}))
+	})
+
+	Context("with a context", func() {
+		It("reports partial writes when the deadline passes", func() {
+			report := NewReport("report-id", "nightly")
+			report.NewTestSuite("queue", "queue").NewTestCase("queue/0", "0")
+			report.Finalize()
+
+			blocked := make(chan struct{})
+			defer close(blocked)
+			w := &stuckWriter{limit: 5, blocked: blocked}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := report.WriteToStreamContext(ctx, w, 2)
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+			Expect(err.Error()).To(MatchRegexp(`wrote 5 of \d+ bytes`))
+		})
+
+		It("does not write when the context is already done", func() {
+			report := NewReport("report-id", "nightly")
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			buf := &bytes.Buffer{}
+			err := report.WriteToStreamContext(ctx, buf, 2)
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(buf.Len()).To(BeZero())
+		})
+	})
+})
+
+// stuckWriter accepts up to limit bytes, and then blocks until the blocked
+// channel is closed.
+type stuckWriter struct {
+	limit   int
+	written int
+	blocked chan struct{}
+}
+
+func (w *stuckWriter) Write(p []byte) (int, error) {
+	if w.written < w.limit {
+		n := w.limit - w.written
+		if n > len(p) {
+			n = len(p)
+		}
+		w.written += n
+		return n, nil
+	}
+	<-w.blocked
+	return 0, errors.New("writer closed")
+}
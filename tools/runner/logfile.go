@@ -0,0 +1,179 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// unsafeFileNameChars matches characters that are replaced when a test name
+// is used as a file name.
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// LogFiles creates a log file for each test in a diagnostics directory.
+//
+// The number of files that are open at the same time is bounded. All but one
+// of the handles may be held open by tests. Tests that start while these are
+// in use still get a log file, but it is opened and closed for each message
+// using the remaining handle, so they never wait for other tests to stop.
+type LogFiles struct {
+	dir          string
+	held         chan struct{}
+	transientMux sync.Mutex
+	mux          sync.Mutex
+	names        map[string]int
+}
+
+// NewLogFiles creates a LogFiles instance that writes files to a directory.
+// The directory is created if it does not exist. At most maxOpen files are
+// open at the same time.
+func NewLogFiles(dir string, maxOpen int) (*LogFiles, error) {
+	if maxOpen < 1 {
+		return nil, fmt.Errorf("cannot bound open log files to %d", maxOpen)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create log directory %q: %v", dir, err)
+	}
+	return &LogFiles{
+		dir:   dir,
+		held:  make(chan struct{}, maxOpen-1),
+		names: make(map[string]int),
+	}, nil
+}
+
+// NewLogger creates a logger that writes to the file <name>.log. Characters
+// that are not safe in file names are replaced, and a suffix is added if
+// another logger already uses the same file name.
+func (lf *LogFiles) NewLogger(name string) *FileLogger {
+	base := unsafeFileNameChars.ReplaceAllString(name, "_")
+
+	lf.mux.Lock()
+	count := lf.names[base]
+	lf.names[base]++
+	lf.mux.Unlock()
+
+	if count > 0 {
+		base = fmt.Sprintf("%s-%d", base, count)
+	}
+	return &FileLogger{
+		files: lf,
+		path:  filepath.Join(lf.dir, base+".log"),
+	}
+}
+
+// FileLogger writes the messages of a single test to a file. The file is
+// created when the test starts, and closed when the test stops.
+type FileLogger struct {
+	files *LogFiles
+	path  string
+	mux   sync.Mutex
+	file  *os.File
+}
+
+// Path returns the path of the log file.
+func (l *FileLogger) Path() string {
+	return l.path
+}
+
+// Started implements the TestLogger interface. It creates the log file, and
+// holds it open if the number of open files allows it.
+func (l *FileLogger) Started() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	select {
+	case l.files.held <- struct{}{}:
+		file, err := os.Create(l.path)
+		if err != nil {
+			<-l.files.held
+			log.Printf("Failed to create log file %q: %v", l.path, err)
+			return
+		}
+		l.file = file
+	default:
+		l.withFile(os.O_CREATE|os.O_TRUNC|os.O_WRONLY, func(*os.File) {})
+	}
+}
+
+// Stopped implements the TestLogger interface. It closes the log file.
+func (l *FileLogger) Stopped() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.file == nil {
+		return
+	}
+	if err := l.file.Close(); err != nil {
+		log.Printf("Failed to close log file %q: %v", l.path, err)
+	}
+	l.file = nil
+	<-l.files.held
+}
+
+// Info implements the Logger interface.
+func (l *FileLogger) Info(format string, v ...interface{}) {
+	l.write("INFO", format, v...)
+}
+
+// Warning implements the Logger interface.
+func (l *FileLogger) Warning(format string, v ...interface{}) {
+	l.write("WARNING", format, v...)
+}
+
+// Error implements the Logger interface.
+func (l *FileLogger) Error(format string, v ...interface{}) {
+	l.write("ERROR", format, v...)
+}
+
+// write appends a line with a timestamp and severity to the log file.
+func (l *FileLogger) write(severity string, format string, v ...interface{}) {
+	line := fmt.Sprintf("%s %s %s\n", time.Now().Format(time.RFC3339), severity, fmt.Sprintf(format, v...))
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	writeLine := func(file *os.File) {
+		if _, err := file.WriteString(line); err != nil {
+			log.Printf("Failed to write to log file %q: %v", l.path, err)
+		}
+	}
+	if l.file != nil {
+		writeLine(l.file)
+		return
+	}
+	l.withFile(os.O_APPEND|os.O_CREATE|os.O_WRONLY, writeLine)
+}
+
+// withFile opens the log file using the handle that is reserved for transient
+// use, calls f and closes the file again.
+func (l *FileLogger) withFile(flag int, f func(*os.File)) {
+	l.files.transientMux.Lock()
+	defer l.files.transientMux.Unlock()
+
+	file, err := os.OpenFile(l.path, flag, 0644)
+	if err != nil {
+		log.Printf("Failed to open log file %q: %v", l.path, err)
+		return
+	}
+	f(file)
+	if err = file.Close(); err != nil {
+		log.Printf("Failed to close log file %q: %v", l.path, err)
+	}
+}
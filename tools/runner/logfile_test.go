@@ -0,0 +1,120 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("LogFiles", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "runner-logs")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	readLog := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	It("rejects a bound below one open file", func() {
+		_, err := NewLogFiles(dir, 0)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("writes each test to a distinct file with its lines", func() {
+		logFiles, err := NewLogFiles(dir, 2)
+		Expect(err).ToNot(HaveOccurred())
+
+		loggers := []*FileLogger{
+			logFiles.NewLogger("test-a"),
+			logFiles.NewLogger("test-a"),
+			logFiles.NewLogger("prefix-scenario [test/b]"),
+		}
+		Expect(loggers[0].Path()).To(Equal(filepath.Join(dir, "test-a.log")))
+		Expect(loggers[1].Path()).To(Equal(filepath.Join(dir, "test-a-1.log")))
+		Expect(loggers[2].Path()).To(Equal(filepath.Join(dir, "prefix-scenario_test_b_.log")))
+
+		// Only one file may be held open, so the others are reopened for
+		// each message.
+		for _, logger := range loggers {
+			logger.Started()
+		}
+		for i, logger := range loggers {
+			logger.Info("info from logger %d", i)
+			logger.Warning("warning from logger %d", i)
+			logger.Error("error from logger %d", i)
+		}
+		for _, logger := range loggers {
+			logger.Stopped()
+		}
+
+		for i, logger := range loggers {
+			contents := readLog(logger.Path())
+			Expect(contents).To(ContainSubstring("INFO info from logger %d", i))
+			Expect(contents).To(ContainSubstring("WARNING warning from logger %d", i))
+			Expect(contents).To(ContainSubstring("ERROR error from logger %d", i))
+			for j := range loggers {
+				if j != i {
+					Expect(contents).ToNot(ContainSubstring("logger %d", j))
+				}
+			}
+		}
+	})
+
+	It("writes every message of a test case when sampling", func() {
+		logFiles, err := NewLogFiles(dir, 4)
+		Expect(err).ToNot(HaveOccurred())
+
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+		reporter.SetLoggerWrapper(func(logger Logger) Logger {
+			return NewSamplingLogger(logger, 1, 0)
+		})
+		reporter.SetLogFiles(logFiles)
+
+		caseReporter := reporter.NewTestCaseReporter(&grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{Name: "sampled-test"},
+		})
+		caseReporter.SetStartTime(time.Now())
+		for i := 0; i < 3; i++ {
+			caseReporter.Info("poll %d", i)
+		}
+		caseReporter.SetEndTime(time.Now())
+
+		contents := readLog(filepath.Join(dir, "sampled-test.log"))
+		for i := 0; i < 3; i++ {
+			Expect(contents).To(ContainSubstring("poll %d", i))
+		}
+	})
+})
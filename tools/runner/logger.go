@@ -0,0 +1,389 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package runner contains code for a test runner that can run a list of
+// load tests, wait for them to complete, and report on the results.
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// Logger records messages generated during a test.
+type Logger interface {
+	// Info records an informational message.
+	Info(format string, v ...interface{})
+
+	// Warning records a warning message.
+	Warning(format string, v ...interface{})
+
+	// Error records an error message.
+	Error(format string, v ...interface{})
+}
+
+// TestLogger is a Logger that is notified when a test starts and stops. This
+// allows it to acquire resources, such as files, only for the duration of the
+// test.
+type TestLogger interface {
+	Logger
+
+	// Started is called when the test starts.
+	Started()
+
+	// Stopped is called when the test stops.
+	Stopped()
+}
+
+// TextLogger prints messages to the standard logger. Each message is preceded
+// by a prefix, which identifies the test.
+type TextLogger struct {
+	prefix string
+}
+
+// NewTextLogger creates a new TextLogger with a prefix.
+func NewTextLogger(prefix string) *TextLogger {
+	return &TextLogger{prefix: prefix}
+}
+
+// Info implements the Logger interface.
+func (l *TextLogger) Info(format string, v ...interface{}) {
+	log.Printf(l.prefix+format, v...)
+}
+
+// Warning implements the Logger interface.
+func (l *TextLogger) Warning(format string, v ...interface{}) {
+	log.Printf(l.prefix+format, v...)
+}
+
+// Error implements the Logger interface.
+func (l *TextLogger) Error(format string, v ...interface{}) {
+	log.Printf(l.prefix+format, v...)
+}
+
+// JSONLogWriter writes JSON log events to a stream, one object per line. It
+// is shared by the JSONLogger of each test, and guards the stream with a mutex
+// so that events from concurrent tests do not interleave.
+type JSONLogWriter struct {
+	mux     sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSONLogWriter creates a JSONLogWriter that writes to a stream.
+func NewJSONLogWriter(w io.Writer) *JSONLogWriter {
+	return &JSONLogWriter{encoder: json.NewEncoder(w)}
+}
+
+// NewLogger creates a logger for a test, given the name of its queue, its
+// index in the queue and its name.
+func (w *JSONLogWriter) NewLogger(qName string, index int, testName string) *JSONLogger {
+	return &JSONLogger{
+		writer:   w,
+		qName:    qName,
+		index:    index,
+		testName: testName,
+	}
+}
+
+// write encodes a single event.
+func (w *JSONLogWriter) write(event *jsonLogEvent) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	if err := w.encoder.Encode(event); err != nil {
+		log.Printf("Failed to write JSON log event: %v", err)
+	}
+}
+
+// jsonLogEvent is the JSON object written for each event.
+type jsonLogEvent struct {
+	Timestamp string `json:"timestamp"`
+	Event     string `json:"event"`
+	Severity  string `json:"severity"`
+	Queue     string `json:"queue"`
+	Index     int    `json:"index"`
+	Test      string `json:"test"`
+	Message   string `json:"message,omitempty"`
+}
+
+// JSONLogger writes the events of a test as JSON objects. Each object
+// includes the queue, index and name of the test.
+type JSONLogger struct {
+	writer   *JSONLogWriter
+	qName    string
+	index    int
+	testName string
+}
+
+// write creates an event and passes it to the writer.
+func (l *JSONLogger) write(event, severity, message string) {
+	l.writer.write(&jsonLogEvent{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Event:     event,
+		Severity:  severity,
+		Queue:     l.qName,
+		Index:     l.index,
+		Test:      l.testName,
+		Message:   message,
+	})
+}
+
+// Started implements the TestLogger interface.
+func (l *JSONLogger) Started() {
+	l.write("Started", "INFO", "")
+}
+
+// Stopped implements the TestLogger interface.
+func (l *JSONLogger) Stopped() {
+	l.write("Stopped", "INFO", "")
+}
+
+// Info implements the Logger interface.
+func (l *JSONLogger) Info(format string, v ...interface{}) {
+	l.write("Info", "INFO", fmt.Sprintf(format, v...))
+}
+
+// Warning implements the Logger interface.
+func (l *JSONLogger) Warning(format string, v ...interface{}) {
+	l.write("Warning", "WARNING", fmt.Sprintf(format, v...))
+}
+
+// Error implements the Logger interface.
+func (l *JSONLogger) Error(format string, v ...interface{}) {
+	l.write("Error", "ERROR", fmt.Sprintf(format, v...))
+}
+
+// LoggerList is a list of loggers. Each message is passed to all loggers in
+// the list, in order.
+type LoggerList []Logger
+
+// Info implements the Logger interface.
+func (ll LoggerList) Info(format string, v ...interface{}) {
+	for _, l := range ll {
+		l.Info(format, v...)
+	}
+}
+
+// Warning implements the Logger interface.
+func (ll LoggerList) Warning(format string, v ...interface{}) {
+	for _, l := range ll {
+		l.Warning(format, v...)
+	}
+}
+
+// Error implements the Logger interface.
+func (ll LoggerList) Error(format string, v ...interface{}) {
+	for _, l := range ll {
+		l.Error(format, v...)
+	}
+}
+
+// Started implements the TestLogger interface. It notifies the loggers in the
+// list that implement TestLogger.
+func (ll LoggerList) Started() {
+	for _, l := range ll {
+		if tl, ok := l.(TestLogger); ok {
+			tl.Started()
+		}
+	}
+}
+
+// Stopped implements the TestLogger interface. It notifies the loggers in the
+// list that implement TestLogger.
+func (ll LoggerList) Stopped() {
+	for _, l := range ll {
+		if tl, ok := l.(TestLogger); ok {
+			tl.Stopped()
+		}
+	}
+}
+
+// SamplingLogger limits the number of informational messages passed to
+// another logger. Messages with the same format string are counted together.
+// The first messages for each format are passed through, and after that only
+// every nth message is passed through. Warnings and errors are never dropped.
+type SamplingLogger struct {
+	next   Logger
+	first  int
+	every  int
+	mux    sync.Mutex
+	counts map[string]int
+}
+
+// NewSamplingLogger creates a SamplingLogger that passes messages to the next
+// logger. The first messages with each format are always passed through, and
+// then every nth message. If every is not positive, no more messages with the
+// format are passed through after the first.
+func NewSamplingLogger(next Logger, first, every int) *SamplingLogger {
+	return &SamplingLogger{
+		next:   next,
+		first:  first,
+		every:  every,
+		counts: make(map[string]int),
+	}
+}
+
+// Info implements the Logger interface. The message is dropped if it is not
+// selected by sampling.
+func (l *SamplingLogger) Info(format string, v ...interface{}) {
+	l.mux.Lock()
+	l.counts[format]++
+	count := l.counts[format]
+	l.mux.Unlock()
+
+	if count <= l.first || (l.every > 0 && (count-l.first)%l.every == 0) {
+		l.next.Info(format, v...)
+	}
+}
+
+// Warning implements the Logger interface. Warnings are never sampled.
+func (l *SamplingLogger) Warning(format string, v ...interface{}) {
+	l.next.Warning(format, v...)
+}
+
+// Error implements the Logger interface. Errors are never sampled.
+func (l *SamplingLogger) Error(format string, v ...interface{}) {
+	l.next.Error(format, v...)
+}
+
+// Started implements the TestLogger interface. It notifies the next logger,
+// if it implements TestLogger.
+func (l *SamplingLogger) Started() {
+	if tl, ok := l.next.(TestLogger); ok {
+		tl.Started()
+	}
+}
+
+// Stopped implements the TestLogger interface. It notifies the next logger,
+// if it implements TestLogger.
+func (l *SamplingLogger) Stopped() {
+	if tl, ok := l.next.(TestLogger); ok {
+		tl.Stopped()
+	}
+}
+
+// LogBuffer is shared by the BufferedLogger of each test. It guards the
+// loggers that buffered messages are flushed to with a mutex, so that the
+// messages of a test are written as a contiguous block, without messages of
+// concurrent tests between them.
+type LogBuffer struct {
+	mux            sync.Mutex
+	flushOnWarning bool
+}
+
+// NewLogBuffer creates a LogBuffer. If flushOnWarning is set, warnings and
+// errors are written as soon as they are logged, along with the messages
+// buffered before them, instead of waiting for the test to stop.
+func NewLogBuffer(flushOnWarning bool) *LogBuffer {
+	return &LogBuffer{flushOnWarning: flushOnWarning}
+}
+
+// NewLogger creates a BufferedLogger for a test, which writes to the next
+// logger.
+func (b *LogBuffer) NewLogger(next Logger) *BufferedLogger {
+	return &BufferedLogger{
+		buffer: b,
+		next:   next,
+	}
+}
+
+// bufferedMessage is a message that was logged but not yet written. The log
+// function is the method of the next logger for the severity of the message.
+type bufferedMessage struct {
+	log     func(format string, v ...interface{})
+	message string
+}
+
+// BufferedLogger holds the messages of a test while it runs, and writes them
+// to the next logger when the test stops. This keeps the output of concurrent
+// tests readable. Messages logged before the test starts or after it stops
+// are written right away.
+type BufferedLogger struct {
+	buffer   *LogBuffer
+	next     Logger
+	mux      sync.Mutex
+	running  bool
+	messages []bufferedMessage
+}
+
+// Info implements the Logger interface.
+func (l *BufferedLogger) Info(format string, v ...interface{}) {
+	l.add(l.next.Info, fmt.Sprintf(format, v...), false)
+}
+
+// Warning implements the Logger interface.
+func (l *BufferedLogger) Warning(format string, v ...interface{}) {
+	l.add(l.next.Warning, fmt.Sprintf(format, v...), l.buffer.flushOnWarning)
+}
+
+// Error implements the Logger interface.
+func (l *BufferedLogger) Error(format string, v ...interface{}) {
+	l.add(l.next.Error, fmt.Sprintf(format, v...), l.buffer.flushOnWarning)
+}
+
+// Started implements the TestLogger interface. Messages are buffered until
+// Stopped is called. The next logger is notified, if it implements
+// TestLogger.
+func (l *BufferedLogger) Started() {
+	l.mux.Lock()
+	l.running = true
+	l.mux.Unlock()
+
+	if tl, ok := l.next.(TestLogger); ok {
+		tl.Started()
+	}
+}
+
+// Stopped implements the TestLogger interface. Buffered messages are written
+// before the next logger is notified, if it implements TestLogger.
+func (l *BufferedLogger) Stopped() {
+	l.mux.Lock()
+	l.running = false
+	l.mux.Unlock()
+	l.flush()
+
+	if tl, ok := l.next.(TestLogger); ok {
+		tl.Stopped()
+	}
+}
+
+// add buffers a message while the test is running, and writes all buffered
+// messages if the test is not running or flush is set.
+func (l *BufferedLogger) add(log func(format string, v ...interface{}), message string, flush bool) {
+	l.mux.Lock()
+	l.messages = append(l.messages, bufferedMessage{log: log, message: message})
+	flush = flush || !l.running
+	l.mux.Unlock()
+
+	if flush {
+		l.flush()
+	}
+}
+
+// flush writes the buffered messages to the next logger as a block.
+func (l *BufferedLogger) flush() {
+	l.buffer.mux.Lock()
+	defer l.buffer.mux.Unlock()
+
+	l.mux.Lock()
+	messages := l.messages
+	l.messages = nil
+	l.mux.Unlock()
+
+	for _, m := range messages {
+		m.log("%s", m.message)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordingLogger is a Logger that records each message with its severity.
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Info(format string, v ...interface{}) {
+	l.messages = append(l.messages, "INFO "+fmt.Sprintf(format, v...))
+}
+
+func (l *recordingLogger) Warning(format string, v ...interface{}) {
+	l.messages = append(l.messages, "WARNING "+fmt.Sprintf(format, v...))
+}
+
+func (l *recordingLogger) Error(format string, v ...interface{}) {
+	l.messages = append(l.messages, "ERROR "+fmt.Sprintf(format, v...))
+}
+
+var _ = Describe("LoggerList", func() {
+	It("passes each message to all loggers", func() {
+		a := &recordingLogger{}
+		b := &recordingLogger{}
+		logger := LoggerList{a, b}
+
+		logger.Info("info %d", 1)
+		logger.Warning("warning %d", 2)
+		logger.Error("error %d", 3)
+
+		expected := []string{"INFO info 1", "WARNING warning 2", "ERROR error 3"}
+		Expect(a.messages).To(Equal(expected))
+		Expect(b.messages).To(Equal(expected))
+	})
+})
+
+var _ = Describe("SamplingLogger", func() {
+	var next *recordingLogger
+
+	BeforeEach(func() {
+		next = &recordingLogger{}
+	})
+
+	It("passes the first messages and then every nth message", func() {
+		logger := NewSamplingLogger(next, 2, 3)
+		for i := 1; i <= 10; i++ {
+			logger.Info("polled %d", i)
+		}
+
+		Expect(next.messages).To(Equal([]string{
+			"INFO polled 1",
+			"INFO polled 2",
+			"INFO polled 5",
+			"INFO polled 8",
+		}))
+	})
+
+	It("counts messages with different formats separately", func() {
+		logger := NewSamplingLogger(next, 1, 0)
+		logger.Info("Running")
+		logger.Info("Running")
+		logger.Info("Created test %s", "a")
+		logger.Info("Created test %s", "b")
+
+		Expect(next.messages).To(Equal([]string{
+			"INFO Running",
+			"INFO Created test a",
+		}))
+	})
+
+	It("never drops warnings or errors", func() {
+		logger := NewSamplingLogger(next, 0, 0)
+		for i := 0; i < 5; i++ {
+			logger.Info("info")
+			logger.Warning("warning")
+			logger.Error("error")
+		}
+
+		var warnings, errors int
+		for _, message := range next.messages {
+			switch message {
+			case "WARNING warning":
+				warnings++
+			case "ERROR error":
+				errors++
+			}
+		}
+		Expect(warnings).To(Equal(5))
+		Expect(errors).To(Equal(5))
+		Expect(next.messages).To(HaveLen(10))
+	})
+
+	It("can be composed with a LoggerList", func() {
+		other := &recordingLogger{}
+		logger := LoggerList{NewSamplingLogger(next, 1, 0), other}
+		logger.Info("polled")
+		logger.Info("polled")
+
+		Expect(next.messages).To(HaveLen(1))
+		Expect(other.messages).To(HaveLen(2))
+	})
+})
+
+var _ = Describe("JSONLogger", func() {
+	decodeEvents := func(buf *bytes.Buffer) []map[string]interface{} {
+		var events []map[string]interface{}
+		scanner := bufio.NewScanner(buf)
+		for scanner.Scan() {
+			event := make(map[string]interface{})
+			ExpectWithOffset(1, json.Unmarshal(scanner.Bytes(), &event)).To(Succeed())
+			events = append(events, event)
+		}
+		return events
+	}
+
+	It("writes one object per event with the test fields", func() {
+		buf := &bytes.Buffer{}
+		logger := NewJSONLogWriter(buf).NewLogger("queue-a", 3, "test-name")
+
+		logger.Started()
+		logger.Info("info %d", 1)
+		logger.Warning("warning %d", 2)
+		logger.Error("error %d", 3)
+		logger.Stopped()
+
+		events := decodeEvents(buf)
+		Expect(events).To(HaveLen(5))
+
+		var kinds, severities []interface{}
+		for _, event := range events {
+			Expect(event).To(HaveKeyWithValue("queue", "queue-a"))
+			Expect(event).To(HaveKeyWithValue("index", BeEquivalentTo(3)))
+			Expect(event).To(HaveKeyWithValue("test", "test-name"))
+			Expect(event).To(HaveKey("timestamp"))
+			kinds = append(kinds, event["event"])
+			severities = append(severities, event["severity"])
+		}
+		Expect(kinds).To(Equal([]interface{}{"Started", "Info", "Warning", "Error", "Stopped"}))
+		Expect(severities).To(Equal([]interface{}{"INFO", "INFO", "WARNING", "ERROR", "INFO"}))
+		Expect(events[1]).To(HaveKeyWithValue("message", "info 1"))
+		Expect(events[3]).To(HaveKeyWithValue("message", "error 3"))
+	})
+
+	It("does not interleave events from concurrent loggers", func() {
+		buf := &bytes.Buffer{}
+		writer := NewJSONLogWriter(buf)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(logger *JSONLogger) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					logger.Info("message %d", j)
+				}
+			}(writer.NewLogger("queue", i, fmt.Sprintf("test-%d", i)))
+		}
+		wg.Wait()
+
+		Expect(decodeEvents(buf)).To(HaveLen(8 * 50))
+	})
+})
+
+var _ = Describe("BufferedLogger", func() {
+	var next *recordingLogger
+
+	BeforeEach(func() {
+		next = &recordingLogger{}
+	})
+
+	It("writes the messages of a test when it stops", func() {
+		logger := NewLogBuffer(false).NewLogger(next)
+		logger.Started()
+		logger.Info("info %d", 1)
+		logger.Warning("warning %d", 2)
+		logger.Error("error %d", 3)
+		Expect(next.messages).To(BeEmpty())
+
+		logger.Stopped()
+		Expect(next.messages).To(Equal([]string{"INFO info 1", "WARNING warning 2", "ERROR error 3"}))
+	})
+
+	It("writes messages right away when the test is not running", func() {
+		logger := NewLogBuffer(false).NewLogger(next)
+		logger.Info("Skipped: %s", "dry run")
+		Expect(next.messages).To(Equal([]string{"INFO Skipped: dry run"}))
+	})
+
+	It("flushes on warnings and errors when set", func() {
+		logger := NewLogBuffer(true).NewLogger(next)
+		logger.Started()
+		logger.Info("info")
+		Expect(next.messages).To(BeEmpty())
+		logger.Warning("warning")
+		Expect(next.messages).To(Equal([]string{"INFO info", "WARNING warning"}))
+		logger.Info("after")
+		logger.Error("error")
+		Expect(next.messages).To(Equal([]string{"INFO info", "WARNING warning", "INFO after", "ERROR error"}))
+	})
+
+	It("writes the messages of each test contiguously", func() {
+		buffer := NewLogBuffer(false)
+		const tests = 4
+		const messages = 20
+
+		var wg sync.WaitGroup
+		for i := 0; i < tests; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				logger := buffer.NewLogger(next)
+				logger.Started()
+				for j := 0; j < messages; j++ {
+					logger.Info("test %d message %d", i, j)
+				}
+				logger.Stopped()
+			}(i)
+		}
+		wg.Wait()
+
+		Expect(next.messages).To(HaveLen(tests * messages))
+		for start := 0; start < len(next.messages); start += messages {
+			var test int
+			_, err := fmt.Sscanf(next.messages[start], "INFO test %d message 0", &test)
+			Expect(err).ToNot(HaveOccurred())
+			for j := 0; j < messages; j++ {
+				Expect(next.messages[start+j]).To(Equal(fmt.Sprintf("INFO test %d message %d", test, j)))
+			}
+		}
+	})
+})
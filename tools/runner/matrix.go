@@ -0,0 +1,165 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// ParametersAnnotation is the annotation that records the parameter values
+// of a test that was expanded from a parameter matrix.
+const ParametersAnnotation = "parameters"
+
+// invalidNameChars matches characters that are not allowed in the name of a
+// LoadTest, and are replaced when parameter values are added to the name.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ExpandParameterMatrices expands each configuration with a parameter matrix
+// into one configuration for each combination of parameter values.
+//
+// The ScenariosJSON and Scenarios of each expanded configuration are rendered
+// as templates with the values of the combination, and the values are appended
+// to its name in the order of the sorted parameter names. The timeout of each
+// expanded configuration is the longest of the ParameterTimeouts that match
+// its values, if any match. Configurations without a parameter matrix are
+// returned unchanged. An error is returned if a matrix has more than
+// maxCombinations combinations, if a template cannot be rendered, or if a
+// parameter timeout does not match a value of the matrix.
+func ExpandParameterMatrices(configs []*grpcv1.LoadTest, maxCombinations int) ([]*grpcv1.LoadTest, error) {
+	var expanded []*grpcv1.LoadTest
+	for _, config := range configs {
+		if len(config.Spec.ParameterMatrix) == 0 {
+			expanded = append(expanded, config)
+			continue
+		}
+		c, err := expandParameterMatrix(config, maxCombinations)
+		if err != nil {
+			return nil, fmt.Errorf("error expanding parameter matrix of %q: %v", config.Name, err)
+		}
+		expanded = append(expanded, c...)
+	}
+	return expanded, nil
+}
+
+// expandParameterMatrix expands a single configuration.
+func expandParameterMatrix(config *grpcv1.LoadTest, maxCombinations int) ([]*grpcv1.LoadTest, error) {
+	matrix := config.Spec.ParameterMatrix
+
+	names := make([]string, 0, len(matrix))
+	combinations := 1
+	for name, values := range matrix {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no values for parameter %q", name)
+		}
+		names = append(names, name)
+		combinations *= len(values)
+		if combinations > maxCombinations {
+			return nil, fmt.Errorf("parameter matrix exceeds the limit of %d combinations", maxCombinations)
+		}
+	}
+	sort.Strings(names)
+
+	for _, timeout := range config.Spec.ParameterTimeouts {
+		if !containsString(matrix[timeout.Parameter], timeout.Value) {
+			return nil, fmt.Errorf("timeout for parameter %q with value %q does not match the parameter matrix", timeout.Parameter, timeout.Value)
+		}
+	}
+
+	// The first template renders ScenariosJSON, and the others render the
+	// entries of Scenarios.
+	var tmpls []*template.Template
+	for i, text := range append([]string{config.Spec.ScenariosJSON}, config.Spec.Scenarios...) {
+		tmpl, err := template.New("scenarios").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse scenarios template %d: %v", i, err)
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+
+	var configs []*grpcv1.LoadTest
+	indices := make([]int, len(names))
+	for n := 0; n < combinations; n++ {
+		params := make(map[string]string, len(names))
+		nameElems := []string{config.Name}
+		paramElems := make([]string, 0, len(names))
+		for i, name := range names {
+			value := matrix[name][indices[i]]
+			params[name] = value
+			nameElems = append(nameElems, invalidNameChars.ReplaceAllString(strings.ToLower(value), "-"))
+			paramElems = append(paramElems, fmt.Sprintf("%s=%s", name, value))
+		}
+
+		c := config.DeepCopy()
+		c.Name = strings.Join(nameElems, "-")
+		c.Spec.ParameterMatrix = nil
+		c.Spec.ParameterTimeouts = nil
+		if timeoutSeconds := parameterTimeoutSeconds(config.Spec.ParameterTimeouts, params); timeoutSeconds > 0 {
+			c.Spec.TimeoutSeconds = timeoutSeconds
+		}
+		for j, tmpl := range tmpls {
+			b := &strings.Builder{}
+			if err := tmpl.Execute(b, params); err != nil {
+				return nil, fmt.Errorf("could not render scenarios for %s: %v", strings.Join(paramElems, ","), err)
+			}
+			if j == 0 {
+				c.Spec.ScenariosJSON = b.String()
+			} else {
+				c.Spec.Scenarios[j-1] = b.String()
+			}
+		}
+		if c.Annotations == nil {
+			c.Annotations = make(map[string]string)
+		}
+		c.Annotations[ParametersAnnotation] = strings.Join(paramElems, ",")
+		configs = append(configs, c)
+
+		// Advance the indices, with the last parameter varying fastest.
+		for i := len(indices) - 1; i >= 0; i-- {
+			indices[i]++
+			if indices[i] < len(matrix[names[i]]) {
+				break
+			}
+			indices[i] = 0
+		}
+	}
+	return configs, nil
+}
+
+// parameterTimeoutSeconds returns the longest timeout of the parameter
+// timeouts that match the values of a combination, or zero if none match.
+func parameterTimeoutSeconds(timeouts []grpcv1.ParameterTimeout, params map[string]string) int32 {
+	var timeoutSeconds int32
+	for _, timeout := range timeouts {
+		if value, ok := params[timeout.Parameter]; ok && value == timeout.Value && timeout.TimeoutSeconds > timeoutSeconds {
+			timeoutSeconds = timeout.TimeoutSeconds
+		}
+	}
+	return timeoutSeconds
+}
+
+// containsString returns true if a slice contains a string.
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
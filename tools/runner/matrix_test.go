@@ -0,0 +1,133 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("ExpandParameterMatrices", func() {
+	var config *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		config = &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{Name: "streaming"},
+			Spec: grpcv1.LoadTestSpec{
+				ParameterMatrix: map[string][]string{
+					"messageSize": {"64", "1024"},
+					"connections": {"1", "8"},
+				},
+				ScenariosJSON: `{"size": {{.messageSize}}, "channels": {{.connections}}}`,
+			},
+		}
+	})
+
+	It("expands a 2x2 matrix into four parameterized tests", func() {
+		expanded, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expanded).To(HaveLen(4))
+
+		type result struct {
+			name, parameters, scenarios string
+		}
+		var results []result
+		for _, c := range expanded {
+			Expect(c.Spec.ParameterMatrix).To(BeNil())
+			results = append(results, result{c.Name, c.Annotations[ParametersAnnotation], c.Spec.ScenariosJSON})
+		}
+		Expect(results).To(Equal([]result{
+			{"streaming-1-64", "connections=1,messageSize=64", `{"size": 64, "channels": 1}`},
+			{"streaming-1-1024", "connections=1,messageSize=1024", `{"size": 1024, "channels": 1}`},
+			{"streaming-8-64", "connections=8,messageSize=64", `{"size": 64, "channels": 8}`},
+			{"streaming-8-1024", "connections=8,messageSize=1024", `{"size": 1024, "channels": 8}`},
+		}))
+
+		Expect(config.Spec.ParameterMatrix).To(HaveLen(2))
+	})
+
+	It("renders each entry of the scenarios list", func() {
+		config.Spec.Scenarios = []string{`{"warmup": {{.connections}}}`}
+		expanded, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expanded).To(HaveLen(4))
+		Expect(expanded[0].Spec.Scenarios).To(Equal([]string{`{"warmup": 1}`}))
+		Expect(expanded[3].Spec.Scenarios).To(Equal([]string{`{"warmup": 8}`}))
+		Expect(config.Spec.Scenarios).To(Equal([]string{`{"warmup": {{.connections}}}`}))
+	})
+
+	It("returns configurations without a matrix unchanged", func() {
+		plain := &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{Name: "plain"},
+			Spec: grpcv1.LoadTestSpec{
+				ScenariosJSON: `{"size": {{.messageSize}}}`,
+			},
+		}
+		expanded, err := ExpandParameterMatrices([]*grpcv1.LoadTest{plain}, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expanded).To(Equal([]*grpcv1.LoadTest{plain}))
+	})
+
+	It("returns an error when the matrix exceeds the limit", func() {
+		_, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 3)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns an error when a parameter has no values", func() {
+		config.Spec.ParameterMatrix["connections"] = nil
+		_, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("sets the timeout of each expanded test from its parameter values", func() {
+		config.Spec.TimeoutSeconds = 300
+		config.Spec.ParameterTimeouts = []grpcv1.ParameterTimeout{
+			{Parameter: "messageSize", Value: "1024", TimeoutSeconds: 600},
+			{Parameter: "connections", Value: "8", TimeoutSeconds: 900},
+		}
+		expanded, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).ToNot(HaveOccurred())
+
+		timeouts := make(map[string]int32)
+		for _, c := range expanded {
+			Expect(c.Spec.ParameterTimeouts).To(BeNil())
+			timeouts[c.Name] = c.Spec.TimeoutSeconds
+		}
+		Expect(timeouts).To(Equal(map[string]int32{
+			"streaming-1-64":   300,
+			"streaming-1-1024": 600,
+			"streaming-8-64":   900,
+			"streaming-8-1024": 900,
+		}))
+		Expect(config.Spec.TimeoutSeconds).To(Equal(int32(300)))
+	})
+
+	It("returns an error when a timeout does not match the matrix", func() {
+		config.Spec.ParameterTimeouts = []grpcv1.ParameterTimeout{
+			{Parameter: "messageSize", Value: "4096", TimeoutSeconds: 600},
+		}
+		_, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).To(MatchError(ContainSubstring("does not match the parameter matrix")))
+	})
+
+	It("returns an error when the scenarios use an unknown parameter", func() {
+		config.Spec.ScenariosJSON = `{"size": {{.payloadSize}}}`
+		_, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).To(HaveOccurred())
+	})
+})
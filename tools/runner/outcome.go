@@ -0,0 +1,122 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+const (
+	// OutcomeAnnotation is the annotation that records whether a test
+	// passed or failed, as concluded by the runner.
+	OutcomeAnnotation = "runner-outcome"
+
+	// DurationAnnotation is the annotation that records how long a test ran,
+	// from its start time to its stop time.
+	DurationAnnotation = "runner-duration"
+
+	// ResultsLocationAnnotation is the annotation that records where the
+	// results of a test were stored, as a comma-separated list of
+	// locations.
+	ResultsLocationAnnotation = "runner-results-location"
+)
+
+const (
+	// OutcomePassed is the value of the OutcomeAnnotation for tests that
+	// succeeded.
+	OutcomePassed = "passed"
+
+	// OutcomeFailed is the value of the OutcomeAnnotation for tests that did
+	// not succeed.
+	OutcomeFailed = "failed"
+)
+
+// SetAnnotateOutcome sets whether the runner writes the outcome of each test
+// back to its LoadTest. When set, the runner patches the annotations of each
+// test that terminates with its outcome, its duration and the location of its
+// results, so tools that query the cluster can see them without the report.
+func (r *Runner) SetAnnotateOutcome(annotateOutcome bool) {
+	r.annotateOutcome = annotateOutcome
+}
+
+// OutcomeAnnotations returns the annotations that record the outcome of a
+// terminated test. The duration is omitted if the test has no start or stop
+// time, and the results location is omitted if the test stores no results.
+func OutcomeAnnotations(loadTest *grpcv1.LoadTest) map[string]string {
+	annotations := map[string]string{
+		OutcomeAnnotation: OutcomeFailed,
+	}
+	if loadTest.Status.State == grpcv1.Succeeded {
+		annotations[OutcomeAnnotation] = OutcomePassed
+	}
+
+	if start, stop := loadTest.Status.StartTime, loadTest.Status.StopTime; start != nil && stop != nil {
+		annotations[DurationAnnotation] = stop.Sub(start.Time).String()
+	}
+
+	if locations := resultsLocations(loadTest.Spec.Results); len(locations) > 0 {
+		annotations[ResultsLocationAnnotation] = strings.Join(locations, ",")
+	}
+
+	return annotations
+}
+
+// resultsLocations returns the locations where results are stored, such as
+// "bigquery:dataset.table" and "gs://bucket/prefix".
+func resultsLocations(results *grpcv1.Results) []string {
+	if results == nil {
+		return nil
+	}
+	var locations []string
+	if results.BigQueryTable != nil {
+		locations = append(locations, "bigquery:"+*results.BigQueryTable)
+	}
+	if results.GCSBucket != nil {
+		location := "gs://" + *results.GCSBucket
+		if results.GCSPrefix != nil {
+			location += "/" + *results.GCSPrefix
+		}
+		locations = append(locations, location)
+	}
+	return locations
+}
+
+// writeOutcome patches the annotations of a terminated test with its outcome,
+// if annotating outcomes is enabled. A failure to patch the test is reported
+// as a warning, and does not change the outcome of the test.
+func (r *Runner) writeOutcome(loadTest *grpcv1.LoadTest, reporter *TestCaseReporter) {
+	if !r.annotateOutcome {
+		return
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": OutcomeAnnotations(loadTest),
+		},
+	})
+	if err != nil {
+		reporter.Warning("Failed to encode outcome of test %s: %v", nameString(loadTest), err)
+		return
+	}
+	if _, err := r.loadTestGetter.Patch(loadTest.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		reporter.Warning("Failed to annotate outcome of test %s: %v", nameString(loadTest), err)
+		return
+	}
+	reporter.Info("Annotated test %s with its outcome", nameString(loadTest))
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/optional"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("OutcomeAnnotations", func() {
+	It("records a passed test with its duration", func() {
+		start := metav1.NewTime(time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC))
+		stop := metav1.NewTime(start.Add(90 * time.Second))
+		test := &grpcv1.LoadTest{
+			Status: grpcv1.LoadTestStatus{
+				State:     grpcv1.Succeeded,
+				StartTime: &start,
+				StopTime:  &stop,
+			},
+		}
+		Expect(OutcomeAnnotations(test)).To(Equal(map[string]string{
+			OutcomeAnnotation:  OutcomePassed,
+			DurationAnnotation: "1m30s",
+		}))
+	})
+
+	It("records a failed test without a stop time", func() {
+		test := &grpcv1.LoadTest{
+			Status: grpcv1.LoadTestStatus{State: grpcv1.TimedOut},
+		}
+		Expect(OutcomeAnnotations(test)).To(Equal(map[string]string{
+			OutcomeAnnotation: OutcomeFailed,
+		}))
+	})
+
+	It("records the locations of the results", func() {
+		test := &grpcv1.LoadTest{
+			Spec: grpcv1.LoadTestSpec{
+				Results: &grpcv1.Results{
+					BigQueryTable: optional.StringPtr("e2e_benchmark.results"),
+					GCSBucket:     optional.StringPtr("grpc-testing-results"),
+					GCSPrefix:     optional.StringPtr("master/"),
+				},
+			},
+			Status: grpcv1.LoadTestStatus{State: grpcv1.Succeeded},
+		}
+		Expect(OutcomeAnnotations(test)).To(HaveKeyWithValue(ResultsLocationAnnotation, "bigquery:e2e_benchmark.results,gs://grpc-testing-results/master/"))
+	})
+})
+
+var _ = Describe("Runner annotating outcomes", func() {
+	run := func(getter *fake.LoadTestGetter, annotateOutcome bool) {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetAnnotateOutcome(annotateOutcome)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+	}
+
+	It("patches the outcome of a test that succeeds", func() {
+		start := metav1.Now()
+		stop := metav1.NewTime(start.Add(time.Minute))
+		getter := fake.NewLoadTestGetter("default")
+		getter.SetStatuses("test",
+			grpcv1.LoadTestStatus{State: grpcv1.Running, StartTime: &start},
+			grpcv1.LoadTestStatus{State: grpcv1.Succeeded, StartTime: &start, StopTime: &stop},
+		)
+
+		run(getter, true)
+
+		test, err := getter.Get("test", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(test.Annotations).To(HaveKeyWithValue(OutcomeAnnotation, OutcomePassed))
+		Expect(test.Annotations).To(HaveKeyWithValue(DurationAnnotation, "1m0s"))
+	})
+
+	It("patches the outcome of a test that fails", func() {
+		getter := fake.NewLoadTestGetter("default")
+		getter.SetStatuses("test", grpcv1.LoadTestStatus{State: grpcv1.Errored})
+
+		run(getter, true)
+
+		test, err := getter.Get("test", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(test.Annotations).To(HaveKeyWithValue(OutcomeAnnotation, OutcomeFailed))
+	})
+
+	It("does not patch tests unless enabled", func() {
+		getter := fake.NewLoadTestGetter("default")
+		getter.SetStatuses("test", grpcv1.LoadTestStatus{State: grpcv1.Succeeded})
+
+		run(getter, false)
+
+		test, err := getter.Get("test", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(test.Annotations).ToNot(HaveKey(OutcomeAnnotation))
+	})
+})
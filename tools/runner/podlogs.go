@@ -0,0 +1,94 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+)
+
+// DriverLogs returns the last lines of the logs of the run container of the
+// driver of a test. The driver pod is found by its role label and its owner
+// reference to the test. An error is returned if the pod cannot be found or
+// its logs cannot be read.
+func DriverLogs(podGetter corev1client.PodInterface, test *grpcv1.LoadTest, lines int64) (string, error) {
+	pods, err := podGetter.List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", config.RoleLabel, config.DriverRole),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list driver pods: %v", err)
+	}
+
+	var driver *corev1.Pod
+	for i := range pods.Items {
+		if isOwnedBy(&pods.Items[i], test) {
+			driver = &pods.Items[i]
+			break
+		}
+	}
+	if driver == nil {
+		return "", fmt.Errorf("no driver pod found for test %s", test.Name)
+	}
+
+	stream, err := podGetter.GetLogs(driver.Name, &corev1.PodLogOptions{
+		Container: config.RunContainerName,
+		TailLines: &lines,
+	}).Stream()
+	if err != nil {
+		return "", fmt.Errorf("failed to get logs of pod %s: %v", driver.Name, err)
+	}
+	defer stream.Close()
+
+	data, err := ioutil.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logs of pod %s: %v", driver.Name, err)
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+// isOwnedBy returns true if a pod has an owner reference to a test.
+func isOwnedBy(pod *corev1.Pod, test *grpcv1.LoadTest) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind != "LoadTest" || ref.Name != test.Name {
+			continue
+		}
+		if test.UID == "" || ref.UID == test.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// driverLogs returns the logs of the driver of a test that did not succeed,
+// or an empty string if driver logs are disabled. Failures to get the logs
+// are reported as warnings.
+func (r *Runner) driverLogs(loadTest *grpcv1.LoadTest, reporter *TestCaseReporter) string {
+	if r.podGetter == nil || r.driverLogLines <= 0 || loadTest.Status.State == grpcv1.Succeeded {
+		return ""
+	}
+	logs, err := DriverLogs(r.podGetter, loadTest, r.driverLogLines)
+	if err != nil {
+		reporter.Warning("Failed to get driver logs of test %s: %v", nameString(loadTest), err)
+		return ""
+	}
+	return logs
+}
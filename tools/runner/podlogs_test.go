@@ -0,0 +1,102 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/config"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+// newDriverPod returns a driver pod owned by the named test.
+func newDriverPod(podName, testName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: "default",
+			Labels:    map[string]string{config.RoleLabel: config.DriverRole},
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "LoadTest", Name: testName},
+			},
+		},
+	}
+}
+
+var _ = Describe("DriverLogs", func() {
+	It("returns the logs of the driver owned by the test", func() {
+		clientset := fake.NewSimpleClientset(
+			newDriverPod("other-driver", "other"),
+			newDriverPod("test-0-driver", "test-0"),
+		)
+		test := &grpcv1.LoadTest{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}}
+		logs, err := DriverLogs(clientset.CoreV1().Pods("default"), test, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(logs).To(Equal("fake logs"))
+	})
+
+	It("returns an error when the test has no driver pod", func() {
+		clientset := fake.NewSimpleClientset(newDriverPod("other-driver", "other"))
+		test := &grpcv1.LoadTest{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}}
+		_, err := DriverLogs(clientset.CoreV1().Pods("default"), test, 10)
+		Expect(err).To(MatchError(ContainSubstring("no driver pod found")))
+	})
+})
+
+var _ = Describe("Runner driver logs", func() {
+	run := func(state grpcv1.LoadTestState, lines int64) *junit.TestSuites {
+		clientset := fake.NewSimpleClientset(newDriverPod("test-0-driver", "test-0"))
+		getter := newFakeGetter(state)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetDriverLogs(clientset.CoreV1().Pods("default"), lines)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+		return decodeReport(report)
+	}
+
+	It("adds the driver logs to the failure of tests that errored", func() {
+		failures := run(grpcv1.Errored, 10).Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Type).To(Equal(junit.Error))
+		Expect(failures[0].Text).To(Equal("fake logs"))
+	})
+
+	It("adds the driver logs to the failure of tests that timed out", func() {
+		failures := run(grpcv1.TimedOut, 10).Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Type).To(Equal(junit.Timeout))
+		Expect(failures[0].Text).To(Equal("fake logs"))
+	})
+
+	It("does not get logs when the line count is zero", func() {
+		failures := run(grpcv1.Errored, 0).Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Text).To(BeEmpty())
+	})
+})
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"time"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// PollSchedule decides how long the runner waits before polling a test again.
+type PollSchedule interface {
+	// Next returns the interval before the next poll of a test, given the
+	// state observed in the last poll and how long the test has been
+	// observed in that state.
+	Next(state grpcv1.LoadTestState, elapsed time.Duration) time.Duration
+}
+
+// AdaptivePollSchedule is a PollSchedule that polls often when a test changes
+// state, and less often as it stays in the same state. The interval is a
+// quarter of the time spent in the current state, clamped to [Min, Max].
+// Stopping tests are always polled at Min, since they terminate shortly.
+//
+// If Expected is set, running tests are polled so that a poll happens when
+// the test has been running for Expected, and at Min after that. This
+// detects the end of tests that run for a known duration without polling
+// often during the whole run.
+//
+// If Jitter is set, each interval is randomly shifted by up to that fraction
+// of the interval, like the jitter of the fixed polling interval.
+type AdaptivePollSchedule struct {
+	// Min is the shortest interval between polls.
+	Min time.Duration
+	// Max is the longest interval between polls.
+	Max time.Duration
+	// Expected is how long tests are expected to run. It is ignored if it
+	// is zero.
+	Expected time.Duration
+	// Jitter is the fraction of each interval by which it is randomly
+	// shifted. It is ignored if it is zero.
+	Jitter float64
+}
+
+// Next returns the interval before the next poll of a test.
+func (s *AdaptivePollSchedule) Next(state grpcv1.LoadTestState, elapsed time.Duration) time.Duration {
+	if state == grpcv1.Stopping {
+		return jitter(s.Min, s.Jitter)
+	}
+	d := s.clamp(elapsed / 4)
+	if state == grpcv1.Running && s.Expected > 0 {
+		remaining := s.Expected - elapsed
+		if remaining < d {
+			d = s.clamp(remaining)
+		}
+	}
+	return jitter(d, s.Jitter)
+}
+
+// clamp limits an interval to the range [Min, Max].
+func (s *AdaptivePollSchedule) clamp(d time.Duration) time.Duration {
+	if d > s.Max {
+		d = s.Max
+	}
+	if d < s.Min {
+		d = s.Min
+	}
+	return d
+}
+
+// SetPollSchedule sets the schedule of polls of tests that were created. If
+// the schedule is nil, the runner waits for the polling interval between
+// polls of running and stopping tests, and twice the interval between polls
+// of tests that have not started.
+func (r *Runner) SetPollSchedule(pollSchedule PollSchedule) {
+	r.pollSchedule = pollSchedule
+}
+
+// pollWait stops before the next poll of a test, or until the context is
+// cancelled. It returns false if the context was cancelled.
+func (r *Runner) pollWait(ctx context.Context, state grpcv1.LoadTestState, elapsed time.Duration) bool {
+	if r.pollSchedule != nil {
+		return sleep(ctx, r.pollSchedule.Next(state, elapsed))
+	}
+	if state == grpcv1.Running || state == grpcv1.Stopping {
+		return r.wait(ctx)
+	}
+	// Use a longer polling interval for tests that have not started.
+	return r.wait(ctx) && r.wait(ctx)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("AdaptivePollSchedule", func() {
+	schedule := &AdaptivePollSchedule{
+		Min: 5 * time.Second,
+		Max: time.Minute,
+	}
+
+	It("polls at the minimum interval right after a change of state", func() {
+		Expect(schedule.Next(grpcv1.Initializing, 0)).To(Equal(5 * time.Second))
+		Expect(schedule.Next(grpcv1.Running, time.Second)).To(Equal(5 * time.Second))
+	})
+
+	It("backs off as a test stays in the same state", func() {
+		Expect(schedule.Next(grpcv1.Running, time.Minute)).To(Equal(15 * time.Second))
+		Expect(schedule.Next(grpcv1.Initializing, 2*time.Minute)).To(Equal(30 * time.Second))
+		Expect(schedule.Next(grpcv1.Running, time.Hour)).To(Equal(time.Minute))
+	})
+
+	It("always polls stopping tests at the minimum interval", func() {
+		Expect(schedule.Next(grpcv1.Stopping, time.Hour)).To(Equal(5 * time.Second))
+	})
+
+	It("speeds up as running tests approach the expected duration", func() {
+		expected := *schedule
+		expected.Expected = 10 * time.Minute
+		Expect(expected.Next(grpcv1.Running, 8*time.Minute)).To(Equal(time.Minute))
+		Expect(expected.Next(grpcv1.Running, 9*time.Minute+30*time.Second)).To(Equal(30 * time.Second))
+		Expect(expected.Next(grpcv1.Running, 11*time.Minute)).To(Equal(5 * time.Second))
+		Expect(expected.Next(grpcv1.Initializing, 8*time.Minute)).To(Equal(time.Minute))
+	})
+
+	It("shifts intervals by the jitter", func() {
+		jittered := *schedule
+		jittered.Jitter = 0.5
+		for i := 0; i < 100; i++ {
+			d := jittered.Next(grpcv1.Running, time.Minute)
+			Expect(d).To(BeNumerically(">=", 7500*time.Millisecond))
+			Expect(d).To(BeNumerically("<=", 22500*time.Millisecond))
+			d = jittered.Next(grpcv1.Stopping, time.Hour)
+			Expect(d).To(BeNumerically(">=", 2500*time.Millisecond))
+			Expect(d).To(BeNumerically("<=", 7500*time.Millisecond))
+		}
+	})
+})
+
+// recordingPollSchedule records the states with which it is called, and
+// returns no delay.
+type recordingPollSchedule struct {
+	mux    sync.Mutex
+	states []grpcv1.LoadTestState
+}
+
+func (s *recordingPollSchedule) Next(state grpcv1.LoadTestState, elapsed time.Duration) time.Duration {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.states = append(s.states, state)
+	return 0
+}
+
+var _ = Describe("Runner poll schedule", func() {
+	It("uses the schedule between polls", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetStatuses("test-0",
+			grpcv1.LoadTestStatus{State: grpcv1.Initializing},
+			grpcv1.LoadTestStatus{State: grpcv1.Running},
+			grpcv1.LoadTestStatus{State: grpcv1.Stopping},
+			grpcv1.LoadTestStatus{State: grpcv1.Succeeded},
+		)
+		schedule := &recordingPollSchedule{}
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetPollSchedule(schedule)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+
+		Expect(schedule.states).To(Equal([]grpcv1.LoadTestState{grpcv1.Initializing, grpcv1.Running, grpcv1.Stopping}))
+	})
+})
@@ -18,6 +18,9 @@ package runner
 import (
 	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 )
@@ -69,6 +72,15 @@ func CountConfigs(configMap map[string][]*grpcv1.LoadTest) map[string]int {
 	return m
 }
 
+// SuiteName returns the name of the report test suite for a queue. Tests in
+// the global queue are reported in a suite named "global".
+func SuiteName(qName string) string {
+	if qName == "" {
+		return "global"
+	}
+	return qName
+}
+
 // LogPrefixFmt returns a string to format log line prefixes for each test.
 // This string is used to format queue name and test index into a prefix.
 func LogPrefixFmt(configMap map[string][]*grpcv1.LoadTest) string {
@@ -84,3 +96,60 @@ func LogPrefixFmt(configMap map[string][]*grpcv1.LoadTest) string {
 	logPrefixFmt := fmt.Sprintf("[%%-%ds %%%dd] ", queueWidth, indexWidth)
 	return logPrefixFmt
 }
+
+// QueueAssignment describes the tests that are assigned to a queue.
+type QueueAssignment struct {
+	// Queue is the name of the queue. The global queue has an empty name.
+	Queue string
+
+	// Tests is the number of tests in the queue.
+	Tests int
+
+	// Concurrency is the concurrency level of the queue.
+	Concurrency int
+}
+
+// QueueAssignments returns the number of tests and the concurrency level of
+// each queue, sorted by queue name. It returns an error if a queue has no
+// concurrency level, or if a concurrency level is set for a queue with no
+// tests, which is usually a misspelled queue name.
+func QueueAssignments(configMap map[string][]*grpcv1.LoadTest, concurrencyLevels map[string]int) ([]QueueAssignment, error) {
+	if err := ValidateConcurrencyLevels(configMap, concurrencyLevels); err != nil {
+		return nil, err
+	}
+	var unused []string
+	for qName := range concurrencyLevels {
+		if _, ok := configMap[qName]; !ok {
+			unused = append(unused, SuiteName(qName))
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return nil, fmt.Errorf("concurrency levels specified for queues with no tests: %q", unused)
+	}
+
+	counts := CountConfigs(configMap)
+	assignments := make([]QueueAssignment, 0, len(counts))
+	for qName, count := range counts {
+		assignments = append(assignments, QueueAssignment{
+			Queue:       qName,
+			Tests:       count,
+			Concurrency: concurrencyLevels[qName],
+		})
+	}
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].Queue < assignments[j].Queue
+	})
+	return assignments, nil
+}
+
+// WriteQueueAssignments writes a table with the name, number of tests and
+// concurrency level of each queue. The global queue is named "global".
+func WriteQueueAssignments(w io.Writer, assignments []QueueAssignment) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tTESTS\tCONCURRENCY")
+	for _, a := range assignments {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", SuiteName(a.Queue), a.Tests, a.Concurrency)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("QueueAssignments", func() {
+	var configMap map[string][]*grpcv1.LoadTest
+
+	BeforeEach(func() {
+		var configs []*grpcv1.LoadTest
+		for _, pool := range []string{"workers-8core", "workers-32core", "workers-8core", ""} {
+			configs = append(configs, &grpcv1.LoadTest{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"pool": pool}},
+			})
+		}
+		configMap = CreateQueueMap(configs, QueueSelectorFromAnnotation("pool"))
+	})
+
+	It("returns the tests and concurrency level of each queue sorted by name", func() {
+		assignments, err := QueueAssignments(configMap, map[string]int{"": 1, "workers-8core": 2, "workers-32core": 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(assignments).To(Equal([]QueueAssignment{
+			{Queue: "", Tests: 1, Concurrency: 1},
+			{Queue: "workers-32core", Tests: 1, Concurrency: 1},
+			{Queue: "workers-8core", Tests: 2, Concurrency: 2},
+		}))
+	})
+
+	It("returns an error for a queue without a concurrency level", func() {
+		_, err := QueueAssignments(configMap, map[string]int{"": 1, "workers-8core": 2})
+		Expect(err).To(MatchError(ContainSubstring(`"workers-32core"`)))
+	})
+
+	It("returns an error for a concurrency level of a queue with no tests", func() {
+		_, err := QueueAssignments(configMap, map[string]int{"": 1, "workers-8core": 2, "workers-32core": 1, "workers-64core": 1})
+		Expect(err).To(MatchError(ContainSubstring(`"workers-64core"`)))
+	})
+})
+
+var _ = Describe("WriteQueueAssignments", func() {
+	It("writes a table of queues", func() {
+		var buf bytes.Buffer
+		Expect(WriteQueueAssignments(&buf, []QueueAssignment{
+			{Queue: "", Tests: 1, Concurrency: 1},
+			{Queue: "workers-8core", Tests: 12, Concurrency: 2},
+		})).To(Succeed())
+		Expect(buf.String()).To(Equal("QUEUE          TESTS  CONCURRENCY\n" +
+			"global         1      1\n" +
+			"workers-8core  12     2\n"))
+	})
+})
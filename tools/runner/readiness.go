@@ -0,0 +1,117 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	clientset "github.com/grpc/test-infra/clientset"
+)
+
+// ErrNotReady is returned by WaitUntilReady when the cluster did not become
+// ready to run tests before the deadline.
+var ErrNotReady = errors.New("controller not ready")
+
+// ReadinessChecker checks whether the cluster is ready to run tests.
+type ReadinessChecker interface {
+	// CheckReady returns nil if the cluster is ready, or an error that
+	// describes why it is not.
+	CheckReady() error
+}
+
+// ReadinessCheckerFunc adapts a function to the ReadinessChecker interface.
+type ReadinessCheckerFunc func() error
+
+// CheckReady calls the function.
+func (f ReadinessCheckerFunc) CheckReady() error {
+	return f()
+}
+
+// NewCRDReadinessChecker returns a checker that succeeds once the cluster
+// serves LoadTest resources.
+func NewCRDReadinessChecker(loadTestGetter clientset.LoadTestGetter) ReadinessChecker {
+	return ReadinessCheckerFunc(func() error {
+		return CheckLoadTestCRD(loadTestGetter)
+	})
+}
+
+// NewDeploymentReadinessChecker returns a checker that succeeds once a
+// deployment, such as the one of the controller, has a ready replica.
+func NewDeploymentReadinessChecker(deploymentGetter appsv1client.DeploymentInterface, name string) ReadinessChecker {
+	return ReadinessCheckerFunc(func() error {
+		deployment, err := deploymentGetter.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get deployment %q: %v", name, err)
+		}
+		if deployment.Status.ReadyReplicas == 0 {
+			return fmt.Errorf("deployment %q has no ready replicas", name)
+		}
+		return nil
+	})
+}
+
+// AllReady returns a checker that succeeds when all of the checkers succeed.
+// The checkers are run in order, and the error of the first one that fails is
+// returned.
+func AllReady(checkers ...ReadinessChecker) ReadinessChecker {
+	return ReadinessCheckerFunc(func() error {
+		for _, checker := range checkers {
+			if err := checker.CheckReady(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetReadinessChecker sets a check that WaitUntilReady retries until it
+// succeeds or the timeout passes. The runner does not wait if the checker is
+// nil.
+func (r *Runner) SetReadinessChecker(checker ReadinessChecker, timeout time.Duration) {
+	r.readinessChecker = checker
+	r.readinessTimeout = timeout
+}
+
+// WaitUntilReady runs the readiness check until it succeeds, waiting between
+// attempts with the same backoff as retries of create and poll operations.
+// Tests submitted before the controller is ready remain in an Unknown state,
+// so callers should wait before submitting any tests. An error wrapping
+// ErrNotReady is returned if the check does not succeed within the timeout,
+// or before the context is cancelled.
+func (r *Runner) WaitUntilReady(ctx context.Context) error {
+	if r.readinessChecker == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.readinessTimeout)
+	defer cancel()
+
+	for attempt := uint(1); ; attempt++ {
+		err := r.readinessChecker.CheckReady()
+		if err == nil {
+			return nil
+		}
+		log.Printf("Waiting for the controller to be ready (attempt %d): %v", attempt, err)
+		if !r.backoff(ctx, attempt) {
+			return fmt.Errorf("%w within %v: %v", ErrNotReady, r.readinessTimeout, err)
+		}
+	}
+}
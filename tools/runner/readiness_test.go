@@ -0,0 +1,131 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+
+	"github.com/grpc/test-infra/clientset/fake"
+)
+
+// fakeDeploymentGetter returns a deployment with a number of ready replicas,
+// or an error.
+type fakeDeploymentGetter struct {
+	appsv1client.DeploymentInterface
+	readyReplicas int32
+	err           error
+}
+
+func (g *fakeDeploymentGetter) Get(name string, options metav1.GetOptions) (*appsv1.Deployment, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: g.readyReplicas},
+	}, nil
+}
+
+var _ = Describe("NewDeploymentReadinessChecker", func() {
+	It("succeeds when the deployment has a ready replica", func() {
+		checker := NewDeploymentReadinessChecker(&fakeDeploymentGetter{readyReplicas: 1}, "controller-manager")
+		Expect(checker.CheckReady()).To(Succeed())
+	})
+
+	It("fails when the deployment has no ready replicas", func() {
+		checker := NewDeploymentReadinessChecker(&fakeDeploymentGetter{}, "controller-manager")
+		Expect(checker.CheckReady()).To(MatchError(ContainSubstring("no ready replicas")))
+	})
+
+	It("fails when the deployment cannot be read", func() {
+		checker := NewDeploymentReadinessChecker(&fakeDeploymentGetter{err: errors.New("not found")}, "controller-manager")
+		Expect(checker.CheckReady()).To(MatchError(ContainSubstring("not found")))
+	})
+})
+
+var _ = Describe("AllReady", func() {
+	It("returns the error of the first checker that fails", func() {
+		var calls []string
+		checker := func(name string, err error) ReadinessChecker {
+			return ReadinessCheckerFunc(func() error {
+				calls = append(calls, name)
+				return err
+			})
+		}
+
+		Expect(AllReady(checker("a", nil), checker("b", errors.New("b failed")), checker("c", nil)).CheckReady()).To(MatchError("b failed"))
+		Expect(calls).To(Equal([]string{"a", "b"}))
+	})
+
+	It("succeeds when every checker succeeds", func() {
+		ready := ReadinessCheckerFunc(func() error { return nil })
+		Expect(AllReady(ready, ready).CheckReady()).To(Succeed())
+	})
+})
+
+var _ = Describe("Runner readiness", func() {
+	noDelay := func(uint) time.Duration { return 0 }
+
+	It("does not wait without a readiness checker", func() {
+		r := NewRunner(fake.NewLoadTestGetter(corev1.NamespaceDefault), func() {}, 0, noDelay, false)
+		Expect(r.WaitUntilReady(context.Background())).To(Succeed())
+	})
+
+	It("retries the check until it succeeds", func() {
+		attempts := 0
+		r := NewRunner(fake.NewLoadTestGetter(corev1.NamespaceDefault), func() {}, 0, noDelay, false)
+		r.SetReadinessChecker(ReadinessCheckerFunc(func() error {
+			attempts++
+			if attempts < 3 {
+				return errors.New("controller starting")
+			}
+			return nil
+		}), time.Minute)
+
+		Expect(r.WaitUntilReady(context.Background())).To(Succeed())
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("returns ErrNotReady when the timeout passes", func() {
+		r := NewRunner(fake.NewLoadTestGetter(corev1.NamespaceDefault), func() {}, 0, func(uint) time.Duration { return time.Millisecond }, false)
+		r.SetReadinessChecker(ReadinessCheckerFunc(func() error {
+			return errors.New("controller starting")
+		}), 20*time.Millisecond)
+
+		err := r.WaitUntilReady(context.Background())
+		Expect(errors.Is(err, ErrNotReady)).To(BeTrue())
+		Expect(err).To(MatchError(ContainSubstring("controller starting")))
+	})
+
+	It("returns ErrNotReady when the context is cancelled", func() {
+		r := NewRunner(fake.NewLoadTestGetter(corev1.NamespaceDefault), func() {}, 0, noDelay, false)
+		r.SetReadinessChecker(ReadinessCheckerFunc(func() error {
+			return errors.New("controller starting")
+		}), time.Minute)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		Expect(errors.Is(r.WaitUntilReady(ctx), ErrNotReady)).To(BeTrue())
+	})
+})
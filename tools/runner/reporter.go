@@ -17,10 +17,11 @@ package runner
 
 import (
 	"fmt"
-	"log"
+	"strconv"
 	"time"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/tools/runner/junit"
 )
 
 // TestSuiteReporter manages reports for tests that share a runner queue.
@@ -28,44 +29,118 @@ type TestSuiteReporter struct {
 	qName         string
 	logPrefixFmt  string
 	testCaseCount int
+	reportSuite   *junit.ReportTestSuite
+	wrapLogger    func(Logger) Logger
+	logFiles      *LogFiles
+	jsonLogWriter *JSONLogWriter
 }
 
 // NewTestSuiteReporter creates a new suite reporter instance.
-// TODO: Add a report to be filled in by the reporter.
-func NewTestSuiteReporter(qName string, logPrefixFmt string) *TestSuiteReporter {
+// Test cases created by the reporter are added to the report test suite.
+func NewTestSuiteReporter(qName string, logPrefixFmt string, reportSuite *junit.ReportTestSuite) *TestSuiteReporter {
 	return &TestSuiteReporter{
 		qName:        qName,
 		logPrefixFmt: logPrefixFmt,
+		reportSuite:  reportSuite,
 	}
 }
 
+// SetLoggerWrapper sets a function that decorates the logger for each test
+// case created after the call. This can be used to add loggers with a
+// LoggerList, or to filter messages with a SamplingLogger.
+func (r *TestSuiteReporter) SetLoggerWrapper(wrapLogger func(Logger) Logger) {
+	r.wrapLogger = wrapLogger
+}
+
+// SetLogFiles sets where test cases created after the call write their log
+// files. The messages of each test case are written to a separate file, in
+// addition to the other loggers. Messages in these files are never dropped by
+// the logger wrapper.
+func (r *TestSuiteReporter) SetLogFiles(logFiles *LogFiles) {
+	r.logFiles = logFiles
+}
+
+// SetJSONLogWriter sets a writer for JSON log events. Test cases created
+// after the call log JSON events instead of text.
+func (r *TestSuiteReporter) SetJSONLogWriter(jsonLogWriter *JSONLogWriter) {
+	r.jsonLogWriter = jsonLogWriter
+}
+
 // Queue returns the name of the queue containing tests for this test suite.
 func (r *TestSuiteReporter) Queue() string {
 	return r.qName
 }
 
 // NewTestCaseReporter creates a new reporter instance.
-// TODO: Add a report to be filled in by the reporter.
+// A test case is added to the report test suite for the test.
 func (r *TestSuiteReporter) NewTestCaseReporter(config *grpcv1.LoadTest) *TestCaseReporter {
 	logPrefix := fmt.Sprintf(r.logPrefixFmt, r.qName, r.testCaseCount)
 	index := r.testCaseCount
 	r.testCaseCount++
+	id := fmt.Sprintf("%s/%d", r.qName, index)
+	var logger Logger = NewTextLogger(logPrefix)
+	if r.jsonLogWriter != nil {
+		logger = r.jsonLogWriter.NewLogger(r.qName, index, nameString(config))
+	}
+	if r.wrapLogger != nil {
+		logger = r.wrapLogger(logger)
+	}
+	if r.logFiles != nil {
+		logger = LoggerList{logger, r.logFiles.NewLogger(nameString(config))}
+	}
+	invocation := NewTestInvocation(r.qName, index, config)
+	reportCase := r.reportSuite.NewTestCase(id, nameString(config))
+	reportCase.AddProperty(DriversProperty, strconv.Itoa(invocation.Drivers))
+	reportCase.AddProperty(ServersProperty, strconv.Itoa(invocation.Servers))
+	reportCase.AddProperty(ClientsProperty, strconv.Itoa(invocation.Clients))
 	return &TestCaseReporter{
-		logPrintf: func(format string, v ...interface{}) {
-			log.Printf(logPrefix+format, v...)
-		},
-		index: index,
+		qName:      r.qName,
+		name:       config.Name,
+		logger:     logger,
+		index:      index,
+		invocation: invocation,
+		reportCase: reportCase,
 	}
 }
 
+const (
+	// DriversProperty is the property of each test case with the number of
+	// driver pods of the test.
+	DriversProperty = "drivers"
+
+	// ServersProperty is the property of each test case with the number of
+	// server pods of the test.
+	ServersProperty = "servers"
+
+	// ClientsProperty is the property of each test case with the number of
+	// client pods of the test.
+	ClientsProperty = "clients"
+
+	// NotCreatedProperty is the property of test cases that failed before
+	// their test was created, so the test never ran.
+	NotCreatedProperty = "notCreated"
+)
+
 // TestCaseReporter collects events for logging and reporting during a test.
 type TestCaseReporter struct {
-	// startTime and duration are placeholders.
-	// TODO: Record startTime and duration in a report.
-	startTime time.Time
-	duration  time.Duration
-	logPrintf func(format string, v ...interface{})
-	index     int
+	startTime  time.Time
+	duration   time.Duration
+	logger     Logger
+	qName      string
+	name       string
+	index      int
+	invocation TestInvocation
+	reportCase *junit.ReportTestCase
+}
+
+// Queue returns the name of the queue containing the test.
+func (r *TestCaseReporter) Queue() string {
+	return r.qName
+}
+
+// Name returns the name of the LoadTest of the test case.
+func (r *TestCaseReporter) Name() string {
+	return r.name
 }
 
 // Index returns the index of the test case in the test suite (and queue).
@@ -73,35 +148,100 @@ func (r *TestCaseReporter) Index() int {
 	return r.index
 }
 
+// Invocation returns the invocation of the test, with the number of pods of
+// each role.
+func (r *TestCaseReporter) Invocation() TestInvocation {
+	return r.invocation
+}
+
 // Info records an informational message generated by the test.
 func (r *TestCaseReporter) Info(format string, v ...interface{}) {
-	r.logPrintf(format, v...)
+	r.logger.Info(format, v...)
 }
 
 // Warning records a warning message generated during the test.
 // The error that caused the message to be generated is also included.
 func (r *TestCaseReporter) Warning(format string, v ...interface{}) {
 	// TODO: Record warning.
-	r.logPrintf(format, v...)
+	r.logger.Warning(format, v...)
 }
 
 // Error records an error message generated during the test.
 // The error that caused the message to be generated is also included.
 func (r *TestCaseReporter) Error(format string, v ...interface{}) {
-	// TODO: Record error.
-	r.logPrintf(format, v...)
+	r.fail(junit.Error, "", format, v...)
+}
+
+// Timeout records that the test did not complete before its timeout. This is
+// reported as a failure with the timeout type, instead of an error.
+func (r *TestCaseReporter) Timeout(format string, v ...interface{}) {
+	r.fail(junit.Timeout, "", format, v...)
+}
+
+// NotCreated records a failure of the given type that prevented the test from
+// being created. The test case is marked with NotCreatedProperty, so it can be
+// told apart from tests that ran and failed.
+func (r *TestCaseReporter) NotCreated(failureType junit.FailureType, format string, v ...interface{}) {
+	r.reportCase.AddProperty(NotCreatedProperty, "true")
+	r.fail(failureType, "", format, v...)
+}
+
+// fail records a failure of the given type. The details, such as logs, are
+// logged after the message and used as the text of the failure.
+func (r *TestCaseReporter) fail(failureType junit.FailureType, details string, format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+	r.reportCase.AddFailure(failureType, message, details)
+	if details == "" {
+		r.logger.Error(format, v...)
+		return
+	}
+	r.logger.Error("%s\n%s", message, details)
+}
+
+// Failed returns true if the test has failed.
+func (r *TestCaseReporter) Failed() bool {
+	return r.reportCase.Failed()
+}
+
+// Skipped returns true if the test was skipped.
+func (r *TestCaseReporter) Skipped() bool {
+	return r.reportCase.Skipped()
+}
+
+// AddProperty records a named result of the test.
+func (r *TestCaseReporter) AddProperty(name, value string) {
+	r.reportCase.AddProperty(name, value)
+}
+
+// AddArtifact records a reference to a file produced by the test.
+func (r *TestCaseReporter) AddArtifact(name, uri string) {
+	r.reportCase.AddArtifact(name, uri)
+}
+
+// Skip records that the test was not run.
+func (r *TestCaseReporter) Skip(format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+	r.reportCase.SetSkipped(message)
+	r.logger.Info("Skipped: %s", message)
 }
 
 // SetStartTime records the start time of the test.
+// If the logger implements TestLogger, it is notified that the test started.
 func (r *TestCaseReporter) SetStartTime(startTime time.Time) {
-	// TODO: Record startTime in a report.
 	r.startTime = startTime
+	if tl, ok := r.logger.(TestLogger); ok {
+		tl.Started()
+	}
 }
 
 // SetEndTime records the end time of the test.
+// If the logger implements TestLogger, it is notified that the test stopped.
 func (r *TestCaseReporter) SetEndTime(endTime time.Time) {
-	// TODO: Record duration in a report.
 	r.duration = endTime.Sub(r.startTime)
+	r.reportCase.SetDuration(r.duration)
+	if tl, ok := r.logger.(TestLogger); ok {
+		tl.Stopped()
+	}
 }
 
 // TestDuration returns the duration of the test.
@@ -0,0 +1,89 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("TestSuiteReporter", func() {
+	newConfig := func(name string) *grpcv1.LoadTest {
+		return &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}
+	}
+
+	It("records test cases in the suite for its queue", func() {
+		report := junit.NewReport("report-id", "report")
+		reporterA := NewTestSuiteReporter("queue-a", "[%s %d] ", report.NewTestSuite("queue-a", SuiteName("queue-a")))
+		reporterB := NewTestSuiteReporter("queue-b", "[%s %d] ", report.NewTestSuite("queue-b", SuiteName("queue-b")))
+
+		reporterA.NewTestCaseReporter(newConfig("test-a0"))
+		reporterA.NewTestCaseReporter(newConfig("test-a1")).Error("failed")
+		reporterB.NewTestCaseReporter(newConfig("test-b0"))
+		report.Finalize()
+
+		decoded := decodeReport(report)
+		Expect(decoded.Suites).To(HaveLen(2))
+		Expect(decoded.Suites[0].Name).To(Equal("queue-a"))
+		Expect(decoded.Suites[0].TestCount).To(Equal(2))
+		Expect(decoded.Suites[0].FailureCount).To(Equal(1))
+		Expect(decoded.Suites[1].Name).To(Equal("queue-b"))
+		Expect(decoded.Suites[1].TestCount).To(Equal(1))
+		Expect(decoded.Suites[1].FailureCount).To(Equal(0))
+		Expect(decoded.TestCount).To(Equal(decoded.Suites[0].TestCount + decoded.Suites[1].TestCount))
+		Expect(decoded.FailureCount).To(Equal(decoded.Suites[0].FailureCount + decoded.Suites[1].FailureCount))
+	})
+
+	It("records the number of pods of each role as properties", func() {
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		// The pools of the components are left to the defaults.
+		config := newConfig("test")
+		config.Spec.Servers = []grpcv1.Server{{}}
+		config.Spec.Clients = []grpcv1.Client{{}, {}}
+		caseReporter := reporter.NewTestCaseReporter(config)
+		report.Finalize()
+
+		Expect(caseReporter.Invocation()).To(Equal(TestInvocation{
+			Queue:   "queue",
+			Index:   0,
+			Name:    "test",
+			Drivers: 1,
+			Servers: 1,
+			Clients: 2,
+		}))
+		values := make(map[string]string)
+		for _, property := range decodeReport(report).Suites[0].Cases[0].Properties {
+			values[property.Name] = property.Value
+		}
+		Expect(values).To(Equal(map[string]string{
+			DriversProperty: "1",
+			ServersProperty: "1",
+			ClientsProperty: "2",
+		}))
+	})
+
+	It("names the suite for the global queue", func() {
+		Expect(SuiteName("")).To(Equal("global"))
+		Expect(SuiteName("pool-a")).To(Equal("pool-a"))
+	})
+})
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"fmt"
+	"log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	clientset "github.com/grpc/test-infra/clientset"
+)
+
+// ListTests returns the LoadTests on the cluster that match a label selector.
+// An empty selector matches all tests. This is used to report on tests that
+// already ran, without creating them again.
+func ListTests(loadTestGetter clientset.LoadTestGetter, labelSelector string) ([]*grpcv1.LoadTest, error) {
+	list, err := loadTestGetter.List(metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("could not list tests matching %q: %v", labelSelector, err)
+	}
+	tests := make([]*grpcv1.LoadTest, len(list.Items))
+	for i := range list.Items {
+		tests[i] = &list.Items[i]
+	}
+	return tests, nil
+}
+
+// ReportExisting reports on a set of LoadTests that already exist, using their
+// current status instead of running them. Terminated tests are reported as if
+// the runner had monitored them to completion. Tests that have not terminated
+// are reported as skipped.
+func ReportExisting(loadTests []*grpcv1.LoadTest, suiteReporter *TestSuiteReporter, done chan string) {
+	qName := suiteReporter.Queue()
+	for _, loadTest := range loadTests {
+		reporter := suiteReporter.NewTestCaseReporter(loadTest)
+		if status := loadTest.Status; status.StartTime != nil && status.StopTime != nil {
+			reporter.SetStartTime(status.StartTime.Time)
+			reporter.SetEndTime(status.StopTime.Time)
+		}
+		if !loadTest.Status.State.IsTerminated() {
+			reporter.Skip("test %s has not terminated: %s", nameString(loadTest), statusString(loadTest))
+			continue
+		}
+		reportOutcome(loadTest, reporter, "")
+	}
+	log.Printf("Reported %d existing tests in queue %s", len(loadTests), qName)
+	done <- qName
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("Reporting existing tests", func() {
+	var getter *fake.LoadTestGetter
+
+	BeforeEach(func() {
+		newTest := func(name, suite string, state grpcv1.LoadTestState) *grpcv1.LoadTest {
+			return &grpcv1.LoadTest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{"suite": suite},
+					Annotations: map[string]string{
+						QPSAnnotation: "1000",
+					},
+				},
+				Status: grpcv1.LoadTestStatus{State: state},
+			}
+		}
+		getter = fake.NewLoadTestGetter("default",
+			newTest("test-0", "nightly", grpcv1.Succeeded),
+			newTest("test-1", "nightly", grpcv1.Errored),
+			newTest("test-2", "nightly", grpcv1.Running),
+			newTest("test-3", "presubmit", grpcv1.Succeeded),
+		)
+	})
+
+	It("lists the tests matching the selector", func() {
+		tests, err := ListTests(getter, "suite=nightly")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tests).To(HaveLen(3))
+		for _, test := range tests {
+			Expect(test.Labels["suite"]).To(Equal("nightly"))
+		}
+	})
+
+	It("reports the outcome of each test without creating tests", func() {
+		tests, err := ListTests(getter, "suite=nightly")
+		Expect(err).ToNot(HaveOccurred())
+
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+		done := make(chan string, 1)
+		ReportExisting(tests, reporter, done)
+		Expect(done).To(Receive(Equal("queue")))
+		report.Finalize()
+
+		cases := decodeReport(report).Suites[0].Cases
+		Expect(cases).To(HaveLen(3))
+
+		Expect(cases[0].Failures).To(BeEmpty())
+		Expect(cases[0].Skipped).To(BeNil())
+		Expect(cases[0].Properties).To(HaveLen(4))
+		Expect(cases[0].Properties[3].Name).To(Equal(QPSAnnotation))
+
+		Expect(cases[1].Failures).ToNot(BeEmpty())
+
+		Expect(cases[2].Failures).To(BeEmpty())
+		Expect(cases[2].Skipped).ToNot(BeNil())
+
+		all, err := getter.List(metav1.ListOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(all.Items).To(HaveLen(4))
+	})
+})
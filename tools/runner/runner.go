@@ -16,15 +16,23 @@ limitations under the License.
 package runner
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 
 	grpcv1 "github.com/grpc/test-infra/api/v1"
 	clientset "github.com/grpc/test-infra/clientset"
+	"github.com/grpc/test-infra/tools/runner/junit"
 )
 
 // AfterIntervalFunction returns a function that stops for a time interval.
@@ -35,6 +43,27 @@ func AfterIntervalFunction(d time.Duration) func() {
 	}
 }
 
+// AfterIntervalWithJitter returns a function that stops for a time interval
+// that is randomly adjusted by up to ±frac of the interval. This staggers the
+// polls of concurrent tests. The fraction is clamped to the range [0, 1].
+func AfterIntervalWithJitter(d time.Duration, frac float64) func() {
+	return func() {
+		<-time.After(jitter(d, frac))
+	}
+}
+
+// jitter returns a duration chosen uniformly at random within ±frac of d.
+func jitter(d time.Duration, frac float64) time.Duration {
+	if frac <= 0 {
+		return d
+	}
+	if frac > 1 {
+		frac = 1
+	}
+	offset := (2*rand.Float64() - 1) * frac * float64(d)
+	return d + time.Duration(offset)
+}
+
 // Runner contains the information needed to run multiple sets of LoadTests.
 type Runner struct {
 	// loadTestGetter interacts with the cluster to create, get and delete
@@ -46,65 +75,475 @@ type Runner struct {
 	// retries is the number of times to retry create and poll operations before
 	// failing each test.
 	retries uint
+	// retryBackoff returns the delay before a retry of a failed create or
+	// poll operation, given the number of the retry (starting at 1).
+	retryBackoff func(attempt uint) time.Duration
+	// dryRun skips the creation of LoadTests. Each test is logged and
+	// reported as skipped instead.
+	dryRun bool
+	// adoptExisting monitors an existing LoadTest with the same
+	// configuration hash, instead of creating a new one.
+	adoptExisting bool
+	// batchSize is the number of tests in a queue that are started before
+	// pausing for the batchInterval. Tests are not batched if it is zero.
+	batchSize int
+	// batchInterval is the pause between batches of tests.
+	batchInterval time.Duration
+	// noPodsDeadline is how long a test may wait without pods before it is
+	// aborted. Tests are never aborted if it is zero.
+	noPodsDeadline time.Duration
+	// resultHandler is notified of tests that succeed. It may be nil.
+	resultHandler ResultHandler
+	// podGetter reads the logs of the drivers of tests that fail. Logs are not
+	// read if it is nil.
+	podGetter corev1client.PodInterface
+	// driverLogLines is the number of lines of driver logs that are reported
+	// for each test that fails.
+	driverLogLines int64
+	// globalSlots is a semaphore that limits the number of tests created by
+	// the runner across all queues. Tests are not limited if it is nil.
+	globalSlots chan struct{}
+	// failFast stops all queues from starting tests once a test fails.
+	failFast bool
+	// annotateOutcome patches the annotations of each test that terminates
+	// with its outcome.
+	annotateOutcome bool
+	// keepTestsOnRunTimeout leaves running tests on the cluster when the run
+	// timeout passes, instead of deleting them.
+	keepTestsOnRunTimeout bool
+	// checkpoint records the result of each test that finishes, and skips
+	// tests that passed in an earlier run. It may be nil.
+	checkpoint *Checkpoint
+	// readinessChecker is retried by WaitUntilReady until the cluster is
+	// ready to run tests. The runner does not wait if it is nil.
+	readinessChecker ReadinessChecker
+	// readinessTimeout is how long WaitUntilReady retries the readiness
+	// check before giving up.
+	readinessTimeout time.Duration
+	// failed is set to 1 when a test fails. It is shared by the queues, and
+	// accessed atomically.
+	failed int32
+	// pollSchedule decides the interval between polls of each test. The
+	// afterInterval function is used if it is nil.
+	pollSchedule PollSchedule
+	// activeMux guards active.
+	activeMux sync.Mutex
+	// active holds the names of LoadTests that were created or adopted and
+	// have not finished, so tests left on the cluster can be reported.
+	active map[string]bool
+	// invocationsMux guards invocations.
+	invocationsMux sync.Mutex
+	// invocations holds the status of each test that the runner started or
+	// skipped, for Snapshot.
+	invocations map[TestInvocation]*InvocationStatus
 }
 
-// NewRunner creates a new Runner object.
-func NewRunner(loadTestGetter clientset.LoadTestGetter, afterInterval func(), retries uint) *Runner {
+// NewRunner creates a new Runner object. If retryBackoff is nil, retries use
+// an ExponentialBackoff with the default delays. If dryRun is set, the runner
+// does not create LoadTests, and loadTestGetter may be nil.
+func NewRunner(loadTestGetter clientset.LoadTestGetter, afterInterval func(), retries uint, retryBackoff func(attempt uint) time.Duration, dryRun bool) *Runner {
+	if retryBackoff == nil {
+		retryBackoff = ExponentialBackoff(DefaultRetryBaseDelay, DefaultRetryMaxDelay)
+	}
 	return &Runner{
 		loadTestGetter: loadTestGetter,
 		afterInterval:  afterInterval,
 		retries:        retries,
+		retryBackoff:   retryBackoff,
+		dryRun:         dryRun,
+	}
+}
+
+// SetAdoptExisting sets whether the runner adopts existing tests. When set,
+// the runner looks for a LoadTest with the same configuration hash (see
+// HashLabel) before creating each test. If one exists, the runner monitors it
+// to completion instead of creating a new test, or reports its result if it
+// has already terminated. This makes reruns of the same tests idempotent.
+func (r *Runner) SetAdoptExisting(adoptExisting bool) {
+	r.adoptExisting = adoptExisting
+}
+
+// SetBatching sets the number of tests in each queue that are started at once,
+// and the pause before the next batch is started. This spreads the creation
+// of tests over time, which smooths the load on the API server and the
+// scheduler. Batching is independent of the concurrency level, which limits
+// the number of tests that run at the same time. A batch size of zero
+// disables batching.
+func (r *Runner) SetBatching(batchSize int, batchInterval time.Duration) {
+	r.batchSize = batchSize
+	r.batchInterval = batchInterval
+}
+
+// SetNoPodsDeadline sets how long a test may remain without pods after it was
+// submitted, while it has not started running. Once the deadline passes, the
+// test is deleted and reported as an error. This catches tests that would
+// otherwise wait until their timeout, because the controller is down or the
+// creation of their pods is rejected. A deadline of zero disables the check.
+func (r *Runner) SetNoPodsDeadline(noPodsDeadline time.Duration) {
+	r.noPodsDeadline = noPodsDeadline
+}
+
+// SetResultHandler sets a handler that is called with each test that
+// succeeds, after its result is reported. A nil handler disables the call.
+func (r *Runner) SetResultHandler(resultHandler ResultHandler) {
+	r.resultHandler = resultHandler
+}
+
+// SetDriverLogs sets a client to read the logs of the pods of tests. When a
+// test terminates without succeeding, the last lines of the logs of its
+// driver are logged with the error and added to its failure in the report.
+// A nil client or a line count of zero disables driver logs.
+func (r *Runner) SetDriverLogs(podGetter corev1client.PodInterface, lines int64) {
+	r.podGetter = podGetter
+	r.driverLogLines = lines
+}
+
+// SetGlobalConcurrency limits the number of tests that run at the same time
+// across all queues of the runner, in addition to the concurrency level of
+// each queue. A limit of zero or less removes the limit. It must be called
+// before Run.
+//
+// A test takes a global slot just before it is created, after it has taken a
+// slot of its queue, and releases the global slot when it finishes. Tests
+// adopted with SetAdoptExisting also take a global slot before they are
+// monitored, unless they have already terminated. Since a
+// test waits for a global slot only while it holds no other global slot, and
+// each queue only waits for its own tests to finish, a queue that holds global
+// slots cannot prevent them from being released. Queues that are waiting for
+// slots are served as slots become available, without a fixed order.
+func (r *Runner) SetGlobalConcurrency(limit int) {
+	if limit <= 0 {
+		r.globalSlots = nil
+		return
+	}
+	r.globalSlots = make(chan struct{}, limit)
+}
+
+// SetFailFast sets whether the runner stops starting tests after the first
+// failure. When set, once any test fails, every queue of the runner stops
+// starting tests. Tests that are running are allowed to finish, and tests
+// that were not started are reported as skipped.
+func (r *Runner) SetFailFast(failFast bool) {
+	r.failFast = failFast
+}
+
+// SetKeepTestsOnRunTimeout sets whether tests that are running when the run
+// timeout passes are left on the cluster. The run timeout is the deadline of
+// the context passed to Run. Tests that are running at the deadline are
+// reported as timed out, and are deleted unless this is set.
+func (r *Runner) SetKeepTestsOnRunTimeout(keep bool) {
+	r.keepTestsOnRunTimeout = keep
+}
+
+// SetCheckpoint sets a checkpoint to resume a run from. Tests that passed
+// with the same configuration in the run that wrote the checkpoint are
+// reported as skipped instead of being run again. Tests that failed, did not
+// finish or changed are run. The result of each test that finishes is
+// recorded in the checkpoint. A nil checkpoint disables this.
+func (r *Runner) SetCheckpoint(checkpoint *Checkpoint) {
+	r.checkpoint = checkpoint
+}
+
+const (
+	// DefaultRetryBaseDelay is the default delay before the first retry.
+	DefaultRetryBaseDelay = time.Second
+
+	// DefaultRetryMaxDelay is the default upper bound on retry delays.
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// ExponentialBackoff returns a function that computes retry delays. The delay
+// doubles with each retry, starting at base and capped at max. Each delay is
+// then chosen at random between half its value and its full value, so that
+// concurrent retries do not happen in lockstep.
+func ExponentialBackoff(base, max time.Duration) func(attempt uint) time.Duration {
+	return func(attempt uint) time.Duration {
+		delay := base
+		for i := uint(1); i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		if delay > max {
+			delay = max
+		}
+		half := delay / 2
+		if half <= 0 {
+			return delay
+		}
+		return half + time.Duration(rand.Int63n(int64(delay-half)+1))
 	}
 }
 
 // Run runs a set of LoadTests at a given concurrency level.
-func (r *Runner) Run(configs []*grpcv1.LoadTest, suiteReporter *TestSuiteReporter, concurrencyLevel int, done chan string) {
-	var count, n int
+//
+// If the context is cancelled, tests that have not started are reported as
+// skipped, and tests that are running are deleted and reported as errors. If
+// the deadline of the context passes, which is the run timeout, tests that
+// are running are reported as timed out instead.
+//
+// If batching is set, the runner pauses after starting each batch of tests.
+// Tests that finish during the pause are still recorded as they finish.
+//
+// If fail fast is set, tests that have not started when a test of any queue
+// fails are reported as skipped.
+//
+// If a checkpoint is set, tests that passed in the checkpoint are reported as
+// skipped, and tests that finish are recorded in the checkpoint.
+func (r *Runner) Run(ctx context.Context, configs []*grpcv1.LoadTest, suiteReporter *TestSuiteReporter, concurrencyLevel int, done chan string) {
+	var count, n, started int
 	qName := suiteReporter.Queue()
 	testDone := make(chan *TestCaseReporter)
+	// hashes holds the configuration hash of each started test by index,
+	// for the checkpoint.
+	hashes := make(map[int]string)
+	finish := func(reporter *TestCaseReporter) {
+		reporter.SetEndTime(time.Now())
+		r.recordFinished(reporter)
+		r.recordCheckpoint(reporter, hashes[reporter.Index()])
+		if r.failFast && reporter.Failed() && atomic.CompareAndSwapInt32(&r.failed, 0, 1) {
+			log.Printf("Test %d in queue %s failed, no more tests will be started", reporter.Index(), qName)
+		}
+		log.Printf("Finished test in queue %s after %v", qName, reporter.TestDuration())
+		n--
+		count++
+		log.Printf("Finished %d tests in queue %s", count, qName)
+	}
 	for _, config := range configs {
 		for n >= concurrencyLevel {
-			reporter := <-testDone
-			reporter.SetEndTime(time.Now())
-			log.Printf("Finished test in queue %s after %v", qName, reporter.TestDuration())
-			n--
-			count++
-			log.Printf("Finished %d tests in queue %s", count, qName)
+			finish(<-testDone)
+		}
+		if r.batchSize > 0 && started > 0 && started%r.batchSize == 0 && ctx.Err() == nil {
+			log.Printf("Started %d tests in queue %s, pausing %v before the next batch", started, qName, r.batchInterval)
+			timer := time.NewTimer(r.batchInterval)
+		pause:
+			for {
+				select {
+				case reporter := <-testDone:
+					finish(reporter)
+				case <-timer.C:
+					break pause
+				case <-ctx.Done():
+					timer.Stop()
+					break pause
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			reporter := suiteReporter.NewTestCaseReporter(config)
+			if runTimedOut(ctx) {
+				reporter.Skip("run timeout passed before the test started")
+			} else {
+				reporter.Skip("cancelled before the test started")
+			}
+			r.recordFinished(reporter)
+			continue
+		}
+		if atomic.LoadInt32(&r.failed) != 0 {
+			reporter := suiteReporter.NewTestCaseReporter(config)
+			reporter.Skip("not started after an earlier test failed")
+			r.recordFinished(reporter)
+			continue
 		}
-		n++
 		reporter := suiteReporter.NewTestCaseReporter(config)
-		log.Printf("Starting test %d in queue %s", reporter.Index(), qName)
-		reporter.SetStartTime(time.Now())
-		go r.runTest(config, reporter, testDone)
+		invocation := reporter.Invocation()
+		if r.checkpoint != nil {
+			hash, err := ConfigHash(config)
+			if err == nil && r.checkpoint.Passed(invocation, hash) {
+				reporter.Skip("passed in the run that wrote the checkpoint")
+				r.recordFinished(reporter)
+				continue
+			}
+			hashes[reporter.Index()] = hash
+		}
+		n++
+		started++
+		log.Printf("Starting test %d in queue %s (%s)", reporter.Index(), qName, invocation.countsString())
+		startTime := time.Now()
+		reporter.SetStartTime(startTime)
+		r.recordStarted(reporter, startTime)
+		go r.runTest(ctx, config, reporter, testDone)
 	}
 	for n > 0 {
-		reporter := <-testDone
-		reporter.SetEndTime(time.Now())
-		log.Printf("Finished test in queue %s after %v", qName, reporter.TestDuration())
-		n--
-		count++
-		log.Printf("Finished %d tests in queue %s", count, qName)
+		finish(<-testDone)
 	}
 	done <- qName
 }
 
+// recordCheckpoint records the result of a test that finished in the
+// checkpoint, if one is set. Tests without a configuration hash, or that were
+// skipped, are not recorded, so they are run when the checkpoint is resumed.
+// Failures to write the checkpoint are logged, since they do not affect the
+// results of the run.
+func (r *Runner) recordCheckpoint(reporter *TestCaseReporter, hash string) {
+	if r.checkpoint == nil || hash == "" || reporter.Skipped() {
+		return
+	}
+	result := InvocationPassed
+	if reporter.Failed() {
+		result = InvocationFailed
+	}
+	invocation := reporter.Invocation()
+	err := r.checkpoint.Record(CheckpointEntry{
+		Queue:  invocation.Queue,
+		Index:  invocation.Index,
+		Name:   invocation.Name,
+		Hash:   hash,
+		Result: result,
+	})
+	if err != nil {
+		log.Printf("Failed to record test %d in queue %s in the checkpoint: %v", invocation.Index, invocation.Queue, err)
+	}
+}
+
+// WaitForQueues waits until each of the queues sends its name on the done
+// channel. Once the context is cancelled, queues are given the drain timeout
+// to delete their running tests and finish. If the timeout passes first, the
+// names of the queues that did not finish are returned in sorted order. A
+// drain timeout of zero waits for all queues.
+func WaitForQueues(ctx context.Context, done <-chan string, queues []string, drainTimeout time.Duration) []string {
+	pending := make(map[string]bool, len(queues))
+	for _, qName := range queues {
+		pending[qName] = true
+	}
+
+	cancelled := ctx.Done()
+	var timeout <-chan time.Time
+	for len(pending) > 0 {
+		select {
+		case qName := <-done:
+			delete(pending, qName)
+			log.Printf("Done running tests for queue %q", qName)
+		case <-cancelled:
+			cancelled = nil
+			if drainTimeout > 0 {
+				log.Printf("Waiting up to %v for %d queues to delete their tests", drainTimeout, len(pending))
+				timer := time.NewTimer(drainTimeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+		case <-timeout:
+			var unfinished []string
+			for qName := range pending {
+				unfinished = append(unfinished, qName)
+			}
+			sort.Strings(unfinished)
+			return unfinished
+		}
+	}
+	return nil
+}
+
+// ActiveTests returns the sorted names of the LoadTests that the runner
+// created or adopted, and that have not finished or been deleted.
+func (r *Runner) ActiveTests() []string {
+	r.activeMux.Lock()
+	defer r.activeMux.Unlock()
+	var names []string
+	for name := range r.active {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setActive records whether a LoadTest is active.
+func (r *Runner) setActive(name string, active bool) {
+	r.activeMux.Lock()
+	defer r.activeMux.Unlock()
+	if !active {
+		delete(r.active, name)
+		return
+	}
+	if r.active == nil {
+		r.active = make(map[string]bool)
+	}
+	r.active[name] = true
+}
+
 // runTest creates a single LoadTest and monitors it to completion.
-func (r *Runner) runTest(config *grpcv1.LoadTest, reporter *TestCaseReporter, done chan *TestCaseReporter) {
+// If the context is cancelled after the LoadTest was created, the LoadTest is
+// deleted so it does not remain on the cluster.
+func (r *Runner) runTest(ctx context.Context, config *grpcv1.LoadTest, reporter *TestCaseReporter, done chan *TestCaseReporter) {
 	name := nameString(config)
 	var s, status string
 	var retries uint
 
-	for {
+	if r.dryRun {
+		reporter.Info("Would create test %s", name)
+		reporter.Skip("dry run")
+		done <- reporter
+		return
+	}
+
+	hash, err := setHashLabel(config)
+	if err != nil {
+		reporter.NotCreated(junit.Error, "Failed to hash test %s: %v", name, err)
+		done <- reporter
+		return
+	}
+
+	var adopted bool
+	submitted := time.Now()
+	if r.adoptExisting {
+		existing, err := r.findExistingTest(hash)
+		if err != nil {
+			reporter.Warning("Failed to look for an existing test %s, creating it: %v", name, err)
+		} else if existing != nil {
+			config.Name = existing.Name
+			config.Status = existing.Status
+			name = nameString(config)
+			if !existing.CreationTimestamp.IsZero() {
+				submitted = existing.CreationTimestamp.Time
+			}
+			if existing.Status.State.IsTerminated() {
+				reporter.Info("Found terminated test %s, reporting its result", name)
+			} else {
+				reporter.Info("Found existing test %s, monitoring it", name)
+			}
+			adopted = true
+		}
+	}
+
+	// Adopted tests that are still running hold a global slot while they are
+	// monitored, so they count toward the limit like the tests this run creates.
+	if r.globalSlots != nil && !(adopted && config.Status.State.IsTerminated()) {
+		select {
+		case r.globalSlots <- struct{}{}:
+			defer func() { <-r.globalSlots }()
+			// Waiting for a slot does not count toward the no pods deadline.
+			if !adopted {
+				submitted = time.Now()
+			}
+		case <-ctx.Done():
+			if runTimedOut(ctx) {
+				reporter.Skip("run timeout passed while waiting for a global concurrency slot")
+			} else {
+				reporter.Skip("cancelled while waiting for a global concurrency slot")
+			}
+			done <- reporter
+			return
+		}
+	}
+
+	for !adopted {
 		loadTest, err := r.loadTestGetter.Create(config, metav1.CreateOptions{})
 		if err != nil {
 			reporter.Warning("Failed to create test %s: %v", name, err)
 			if retries < r.retries {
 				retries++
+				r.recordRetry(reporter)
 				reporter.Info("Scheduling retry %d/%d to create test", retries, r.retries)
-				r.afterInterval()
+				if !r.backoff(ctx, retries) {
+					if runTimedOut(ctx) {
+						reporter.NotCreated(junit.Timeout, "Run timeout passed before test %s was created", name)
+					} else {
+						reporter.NotCreated(junit.Error, "Cancelled before test %s was created", name)
+					}
+					done <- reporter
+					return
+				}
 				continue
 			}
-			reporter.Error("Aborting after %d retries to create test %s: %v", r.retries, name, err)
+			reporter.NotCreated(junit.Error, "Aborting after %d retries to create test %s: %v", r.retries, name, err)
 			done <- reporter
 			return
 		}
@@ -114,14 +553,28 @@ func (r *Runner) runTest(config *grpcv1.LoadTest, reporter *TestCaseReporter, do
 		break
 	}
 
+	r.setActive(config.Name, true)
+	defer r.setActive(config.Name, false)
+	r.recordState(reporter, config.Status.State)
+
+	// state is the last observed state of the test, and stateSince is when
+	// it was first observed.
+	state := config.Status.State
+	stateSince := time.Now()
+
 	for {
 		loadTest, err := r.loadTestGetter.Get(config.Name, metav1.GetOptions{})
 		if err != nil {
 			reporter.Warning("Failed to poll test %s: %v", name, err)
 			if retries < r.retries {
 				retries++
+				r.recordRetry(reporter)
 				reporter.Info("Scheduling retry %d/%d to poll test", retries, r.retries)
-				r.afterInterval()
+				if !r.backoff(ctx, retries) {
+					r.cancelTest(ctx, config, reporter)
+					done <- reporter
+					return
+				}
 				continue
 			}
 			reporter.Error("Aborting test after %d retries to poll test %s: %v", r.retries, name, err)
@@ -130,25 +583,250 @@ func (r *Runner) runTest(config *grpcv1.LoadTest, reporter *TestCaseReporter, do
 		}
 		retries = 0
 		config.Status = loadTest.Status
+		r.recordState(reporter, config.Status.State)
 		s = status
 		status = statusString(config)
+		if loadTest.Status.State != state {
+			state = loadTest.Status.State
+			stateSince = time.Now()
+		}
 		switch {
 		case loadTest.Status.State.IsTerminated():
-			reporter.Info("%s", status)
+			reportOutcome(loadTest, reporter, r.driverLogs(loadTest, reporter))
+			r.handleResult(loadTest, reporter)
+			r.writeOutcome(loadTest, reporter)
+			done <- reporter
+			return
+		case clientTimedOut(config, submitted):
+			r.timeOutTest(config, reporter)
 			done <- reporter
 			return
 		case loadTest.Status.State == grpcv1.Running:
 			reporter.Info("%s", status)
-			r.afterInterval()
+			if !r.pollWait(ctx, state, time.Since(stateSince)) {
+				r.cancelTest(ctx, config, reporter)
+				done <- reporter
+				return
+			}
+		case loadTest.Status.State == grpcv1.Stopping:
+			if s != status {
+				reporter.Info("%s", status)
+			}
+			// Stopping tests resolve to a terminal state shortly.
+			if !r.pollWait(ctx, state, time.Since(stateSince)) {
+				r.cancelTest(ctx, config, reporter)
+				done <- reporter
+				return
+			}
 		default:
 			if s != status {
 				reporter.Info("%s", status)
 			}
-			// Use a longer polling interval for tests that have not started.
-			r.afterInterval()
-			r.afterInterval()
+			if r.noPodsDeadline > 0 && loadTest.Status.Pods == 0 && time.Since(submitted) > r.noPodsDeadline {
+				r.abortTest(config, reporter, fmt.Sprintf("no pods were created within %v", r.noPodsDeadline))
+				done <- reporter
+				return
+			}
+			if !r.pollWait(ctx, state, time.Since(stateSince)) {
+				r.cancelTest(ctx, config, reporter)
+				done <- reporter
+				return
+			}
+		}
+	}
+}
+
+// resultAnnotations are the annotations with numeric results of a test, in
+// the order they are reported. Each is reported as a property of the same
+// name. They are only read when the status of the test lacks the result.
+var resultAnnotations = []string{
+	QPSAnnotation,
+	LatencyP50Annotation,
+	LatencyP99Annotation,
+}
+
+const (
+	// QPSAnnotation is the annotation with the queries per second achieved
+	// by a test.
+	QPSAnnotation = "qps"
+
+	// LatencyP50Annotation is the annotation with the median latency of a
+	// test.
+	LatencyP50Annotation = "latency-p50"
+
+	// LatencyP99Annotation is the annotation with the 99th percentile
+	// latency of a test.
+	LatencyP99Annotation = "latency-p99"
+)
+
+// reportOutcome reports the status of a terminated test, with its numeric
+// results. Tests that timed out are reported as timeouts, and other tests that
+// did not succeed are reported as errors. The details, such as driver logs,
+// are added to the failure of tests that did not succeed.
+func reportOutcome(loadTest *grpcv1.LoadTest, reporter *TestCaseReporter, details string) {
+	reportResults(loadTest, reporter)
+	if durations := stateDurations(loadTest.Status.Conditions); durations != "" {
+		reporter.Info("Time in each state: %s", durations)
+	}
+	status := statusString(loadTest)
+	switch {
+	case loadTest.Status.State == grpcv1.Succeeded:
+		reporter.Info("%s", status)
+	case isTimeout(loadTest):
+		reporter.fail(junit.Timeout, details, "%s", status)
+	default:
+		reporter.fail(junit.Error, details, "%s", status)
+	}
+}
+
+// isTimeout returns true if a test terminated because it exceeded its timeout.
+// Tests that were marked Errored with the TimeoutErrored reason, before the
+// TimedOut state existed, are also considered timeouts.
+func isTimeout(loadTest *grpcv1.LoadTest) bool {
+	return loadTest.Status.State == grpcv1.TimedOut || loadTest.Status.Reason == grpcv1.TimeoutErrored
+}
+
+// reportResults records the numeric results of a terminated test as
+// properties. Results in the status of the test, which the controller copies
+// from the termination message of the driver, take precedence; latencies in
+// the status are in milliseconds. Results that are missing from the status are read from the annotations of the test.
+// Missing results are ignored, and annotations that are not numbers are
+// reported as warnings.
+func reportResults(loadTest *grpcv1.LoadTest, reporter *TestCaseReporter) {
+	statusResults := map[string]*float64{
+		QPSAnnotation:        loadTest.Status.QPS,
+		LatencyP50Annotation: loadTest.Status.LatencyP50Ms,
+		LatencyP99Annotation: loadTest.Status.LatencyP99Ms,
+	}
+	for _, key := range resultAnnotations {
+		if result := statusResults[key]; result != nil {
+			reporter.AddProperty(key, strconv.FormatFloat(*result, 'g', -1, 64))
+			continue
 		}
+		value, ok := loadTest.Annotations[key]
+		if !ok {
+			continue
+		}
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			reporter.Warning("Ignoring result %s with value %q: %v", key, value, err)
+			continue
+		}
+		reporter.AddProperty(key, value)
+	}
+}
+
+// wait stops for the polling interval, or until the context is cancelled. It
+// returns false if the context was cancelled.
+func (r *Runner) wait(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	waited := make(chan struct{})
+	go func() {
+		r.afterInterval()
+		close(waited)
+	}()
+	select {
+	case <-waited:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// backoff stops for the retry delay of an attempt, or until the context is
+// cancelled. It returns false if the context was cancelled.
+func (r *Runner) backoff(ctx context.Context, attempt uint) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return sleep(ctx, r.retryBackoff(attempt))
+}
+
+// sleep stops for a duration, or until the context is cancelled. It returns
+// false if the context was cancelled.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// cancelTest deletes a LoadTest that was created but has not terminated, and
+// reports the test as cancelled. If the run timed out, the test is reported
+// as timed out, and it is left on the cluster if the runner keeps tests on
+// the run timeout.
+func (r *Runner) cancelTest(ctx context.Context, config *grpcv1.LoadTest, reporter *TestCaseReporter) {
+	name := nameString(config)
+	if runTimedOut(ctx) {
+		if r.keepTestsOnRunTimeout {
+			reporter.Timeout("Cancelled test %s at the run timeout, which was left on the cluster", name)
+			return
+		}
+		if err := r.loadTestGetter.Delete(config.Name, metav1.DeleteOptions{}); err != nil {
+			reporter.Timeout("Cancelled test %s at the run timeout, but failed to delete it: %v", name, err)
+			return
+		}
+		reporter.Timeout("Cancelled test %s at the run timeout, which was deleted", name)
+		return
 	}
+	if err := r.loadTestGetter.Delete(config.Name, metav1.DeleteOptions{}); err != nil {
+		reporter.Error("Cancelled test %s, but failed to delete it: %v", name, err)
+		return
+	}
+	reporter.Error("Cancelled test %s, which was deleted", name)
+}
+
+// runTimedOut returns true if the context passed its deadline, which is the
+// timeout of the whole run.
+func runTimedOut(ctx context.Context) bool {
+	return ctx.Err() == context.DeadlineExceeded
+}
+
+// abortTest deletes a LoadTest that cannot make progress, and reports the
+// test as an error with the reason.
+func (r *Runner) abortTest(config *grpcv1.LoadTest, reporter *TestCaseReporter, reason string) {
+	name := nameString(config)
+	if err := r.loadTestGetter.Delete(config.Name, metav1.DeleteOptions{}); err != nil {
+		reporter.Error("Aborted test %s (%s), but failed to delete it: %v", name, reason, err)
+		return
+	}
+	reporter.Error("Aborted test %s, which was deleted: %s", name, reason)
+}
+
+// clientTimeoutMargin is added to the timeout of a test before the runner
+// stops waiting for it, so the controller has time to mark it as timed out.
+const clientTimeoutMargin = time.Minute
+
+// clientTimedOut returns true if a test has not terminated within its timeout
+// and the clientTimeoutMargin since it was submitted. This only happens when
+// the controller fails to mark the test as timed out, such as when it is
+// down. Tests without a timeout never time out.
+func clientTimedOut(config *grpcv1.LoadTest, submitted time.Time) bool {
+	if config.Spec.TimeoutSeconds <= 0 || config.Status.State.IsTerminated() {
+		return false
+	}
+	timeout := time.Duration(config.Spec.TimeoutSeconds) * time.Second
+	return time.Since(submitted) > timeout+clientTimeoutMargin
+}
+
+// timeOutTest deletes a LoadTest that did not terminate within its timeout,
+// and reports the test as timed out.
+func (r *Runner) timeOutTest(config *grpcv1.LoadTest, reporter *TestCaseReporter) {
+	name := nameString(config)
+	timeout := time.Duration(config.Spec.TimeoutSeconds) * time.Second
+	if err := r.loadTestGetter.Delete(config.Name, metav1.DeleteOptions{}); err != nil {
+		reporter.Timeout("Test %s did not terminate within its timeout of %v, and failed to be deleted: %v", name, timeout, err)
+		return
+	}
+	reporter.Timeout("Test %s did not terminate within its timeout of %v, and was deleted", name, timeout)
 }
 
 // nameString returns a string to represent the test name in logs.
@@ -182,11 +860,36 @@ func nameString(config *grpcv1.LoadTest) string {
 	return fmt.Sprintf("%s [%s]", name, config.Name)
 }
 
+// pendingReasonDescriptions explain the reasons of tests whose pods have not
+// started, so that users can tell why a test is slow to start.
+var pendingReasonDescriptions = map[string]string{
+	grpcv1.AwaitingSchedule: "waiting for nodes in the pool",
+	grpcv1.PullingImage:     "waiting for images to be pulled",
+}
+
+// stateDurations returns a string with the time that a test spent in each
+// state before it terminated, such as "Initializing 12s, Running 3m0s". The
+// time of each state is taken from its condition and the condition after it,
+// so the last state is omitted. It returns an empty string if the test has
+// fewer than two conditions.
+func stateDurations(conditions []grpcv1.LoadTestCondition) string {
+	var s []string
+	for i := 0; i+1 < len(conditions); i++ {
+		elapsed := conditions[i+1].LastTransitionTime.Sub(conditions[i].LastTransitionTime.Time)
+		s = append(s, fmt.Sprintf("%s %v", conditions[i].State, elapsed.Round(time.Second)))
+	}
+	return strings.Join(s, ", ")
+}
+
 // statusString returns a string to represent the test status in logs.
 // The string consists of state, reason and message (each omitted if empty).
+// Reasons of tests whose pods have not started include a description.
 func statusString(config *grpcv1.LoadTest) string {
 	s := []string{string(config.Status.State)}
 	if reason := strings.TrimSpace(config.Status.Reason); reason != "" {
+		if description, ok := pendingReasonDescriptions[reason]; ok {
+			reason = fmt.Sprintf("%s (%s)", reason, description)
+		}
 		s = append(s, reason)
 	}
 	if message := strings.TrimSpace(config.Status.Message); message != "" {
@@ -0,0 +1,809 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("Runner", func() {
+	Context("in dry run mode", func() {
+		It("reports each test as skipped without creating it", func() {
+			configs := []*grpcv1.LoadTest{
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-2"}},
+			}
+
+			// A nil getter would panic if the runner tried to create a test.
+			r := NewRunner(nil, func() {}, 0, nil, true)
+			report := junit.NewReport("report-id", "report")
+			reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+			done := make(chan string)
+			go r.Run(context.Background(), configs, reporter, 2, done)
+			Eventually(done).Should(Receive(Equal("queue")))
+			report.Finalize()
+
+			decoded := decodeReport(report)
+			Expect(decoded.TestCount).To(Equal(3))
+			Expect(decoded.SkippedCount).To(Equal(3))
+			Expect(decoded.FailureCount).To(Equal(0))
+		})
+	})
+
+	Context("when cancelled", func() {
+		var configs []*grpcv1.LoadTest
+		var report *junit.Report
+		var reporter *TestSuiteReporter
+		var ctx context.Context
+		var cancel context.CancelFunc
+		var polls chan struct{}
+
+		BeforeEach(func() {
+			configs = []*grpcv1.LoadTest{
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-2"}},
+			}
+			report = junit.NewReport("report-id", "report")
+			reporter = NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+			ctx, cancel = context.WithCancel(context.Background())
+			polls = make(chan struct{}, 100)
+		})
+
+		AfterEach(func() {
+			cancel()
+		})
+
+		// afterInterval signals each wait and blocks until cancellation, so
+		// tests never progress past their first wait.
+		afterInterval := func() {
+			polls <- struct{}{}
+			<-ctx.Done()
+		}
+
+		It("deletes running tests and skips tests that have not started", func() {
+			getter := newFakeGetter(grpcv1.Running)
+			r := NewRunner(getter, afterInterval, 0, nil, false)
+
+			done := make(chan string)
+			go r.Run(ctx, configs, reporter, 2, done)
+			Eventually(polls).Should(Receive())
+			Eventually(polls).Should(Receive())
+			cancel()
+			Eventually(done).Should(Receive(Equal("queue")))
+			report.Finalize()
+
+			Expect(actionNames(getter, "create")).To(ConsistOf("test-0", "test-1"))
+			Expect(actionNames(getter, "delete")).To(ConsistOf("test-0", "test-1"))
+
+			decoded := decodeReport(report)
+			Expect(decoded.TestCount).To(Equal(3))
+			Expect(decoded.FailureCount).To(Equal(2))
+			Expect(decoded.SkippedCount).To(Equal(1))
+			for _, testCase := range decoded.Suites[0].Cases[:2] {
+				Expect(testCase.Failures[0].Message).To(HavePrefix("Cancelled test"))
+			}
+		})
+
+		It("does not delete tests that were never created", func() {
+			getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+			getter.SetError("create", errors.New("connection refused"))
+			r := NewRunner(getter, afterInterval, 3, func(uint) time.Duration { return time.Hour }, false)
+
+			done := make(chan string)
+			go r.Run(ctx, configs[:1], reporter, 1, done)
+			Eventually(func() int { return countActions(getter, "create") }).Should(Equal(1))
+			cancel()
+			Eventually(done).Should(Receive(Equal("queue")))
+			report.Finalize()
+
+			Expect(actionNames(getter, "delete")).To(BeEmpty())
+
+			decoded := decodeReport(report)
+			Expect(decoded.FailureCount).To(Equal(1))
+			Expect(decoded.Suites[0].Cases[0].Failures[0].Message).To(HavePrefix("Cancelled before test"))
+		})
+	})
+
+	Context("when the run times out", func() {
+		var configs []*grpcv1.LoadTest
+		var report *junit.Report
+		var reporter *TestSuiteReporter
+
+		BeforeEach(func() {
+			configs = []*grpcv1.LoadTest{
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+			}
+			report = junit.NewReport("report-id", "report")
+			reporter = NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+		})
+
+		run := func(r *Runner) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			done := make(chan string)
+			go r.Run(ctx, configs, reporter, 1, done)
+			Eventually(done).Should(Receive(Equal("queue")))
+			report.Finalize()
+		}
+
+		It("reports running tests as timed out and skips tests that have not started", func() {
+			getter := newFakeGetter(grpcv1.Running)
+			run(NewRunner(getter, AfterIntervalFunction(10*time.Millisecond), 0, nil, false))
+
+			Expect(actionNames(getter, "delete")).To(ConsistOf("test-0"))
+
+			decoded := decodeReport(report)
+			Expect(decoded.FailureCount).To(Equal(1))
+			Expect(decoded.SkippedCount).To(Equal(1))
+			failure := decoded.Suites[0].Cases[0].Failures[0]
+			Expect(failure.Type).To(Equal(junit.Timeout))
+			Expect(failure.Message).To(ContainSubstring("at the run timeout"))
+		})
+
+		It("leaves running tests on the cluster when set to keep them", func() {
+			getter := newFakeGetter(grpcv1.Running)
+			r := NewRunner(getter, AfterIntervalFunction(10*time.Millisecond), 0, nil, false)
+			r.SetKeepTestsOnRunTimeout(true)
+			run(r)
+
+			Expect(actionNames(getter, "delete")).To(BeEmpty())
+
+			decoded := decodeReport(report)
+			Expect(decoded.Suites[0].Cases[0].Failures[0].Type).To(Equal(junit.Timeout))
+		})
+	})
+})
+
+var _ = Describe("AfterIntervalWithJitter", func() {
+	It("does not change the interval without jitter", func() {
+		Expect(jitter(time.Second, 0)).To(Equal(time.Second))
+	})
+
+	It("chooses intervals within the jittered range", func() {
+		for i := 0; i < 1000; i++ {
+			d := jitter(time.Second, 0.25)
+			Expect(d).To(BeNumerically(">=", 750*time.Millisecond))
+			Expect(d).To(BeNumerically("<=", 1250*time.Millisecond))
+		}
+	})
+
+	It("clamps the fraction to the interval", func() {
+		for i := 0; i < 1000; i++ {
+			Expect(jitter(time.Second, 5)).To(BeNumerically(">=", 0))
+		}
+	})
+
+	It("sleeps for a duration within the jittered range", func() {
+		const interval = 50 * time.Millisecond
+		afterInterval := AfterIntervalWithJitter(interval, 0.5)
+		for i := 0; i < 5; i++ {
+			start := time.Now()
+			afterInterval()
+			elapsed := time.Since(start)
+			Expect(elapsed).To(BeNumerically(">=", interval/2))
+			// Allow for scheduling delays beyond the upper bound.
+			Expect(elapsed).To(BeNumerically("<", 3*interval/2+50*time.Millisecond))
+		}
+	})
+})
+
+var _ = Describe("ExponentialBackoff", func() {
+	It("doubles the delay up to the maximum, with jitter", func() {
+		backoff := ExponentialBackoff(time.Second, 10*time.Second)
+		expected := []time.Duration{
+			time.Second,
+			2 * time.Second,
+			4 * time.Second,
+			8 * time.Second,
+			10 * time.Second,
+			10 * time.Second,
+		}
+		for i, delay := range expected {
+			for j := 0; j < 100; j++ {
+				actual := backoff(uint(i + 1))
+				Expect(actual).To(BeNumerically(">=", delay/2))
+				Expect(actual).To(BeNumerically("<=", delay))
+			}
+		}
+	})
+
+	It("does not overflow for large attempts", func() {
+		backoff := ExponentialBackoff(time.Second, time.Minute)
+		Expect(backoff(1000)).To(BeNumerically("<=", time.Minute))
+		Expect(backoff(1000)).To(BeNumerically(">=", 30*time.Second))
+	})
+})
+
+var _ = Describe("Runner retries", func() {
+	It("backs off between failed create operations", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetError("create", errors.New("connection refused"))
+
+		var mux sync.Mutex
+		var attempts []uint
+		backoff := func(attempt uint) time.Duration {
+			mux.Lock()
+			defer mux.Unlock()
+			attempts = append(attempts, attempt)
+			return 0
+		}
+
+		r := NewRunner(getter, func() {}, 3, backoff, false)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+
+		Expect(countActions(getter, "create")).To(Equal(4))
+		mux.Lock()
+		defer mux.Unlock()
+		Expect(attempts).To(Equal([]uint{1, 2, 3}))
+	})
+})
+
+var _ = Describe("Runner results", func() {
+	It("reports tests that timed out as timeout failures", func() {
+		getter := newFakeGetter(grpcv1.TimedOut)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+
+		failures := decodeReport(report).Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Type).To(Equal(junit.Timeout))
+	})
+
+	It("reports tests that errored as error failures", func() {
+		getter := newFakeGetter(grpcv1.Errored)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+
+		failures := decodeReport(report).Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Type).To(Equal(junit.Error))
+	})
+
+	It("reports numeric results of terminated tests as properties", func() {
+		getter := newFakeGetter(grpcv1.Succeeded)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{
+				Name: "test-0",
+				Annotations: map[string]string{
+					QPSAnnotation:        "20000.5",
+					LatencyP50Annotation: "0.0005",
+					LatencyP99Annotation: "not-a-number",
+				},
+			}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+
+		// The first properties are the counts of pods of each role.
+		properties := decodeReport(report).Suites[0].Cases[0].Properties
+		Expect(properties).To(HaveLen(5))
+		Expect(properties[3].Name).To(Equal(QPSAnnotation))
+		Expect(properties[3].Value).To(Equal("20000.5"))
+		Expect(properties[4].Name).To(Equal(LatencyP50Annotation))
+		Expect(properties[4].Value).To(Equal("0.0005"))
+	})
+
+	It("prefers results in the status to results in annotations", func() {
+		qps := 30000.25
+		latencyP99 := 2.5
+		test := &grpcv1.LoadTest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-0",
+				Annotations: map[string]string{
+					QPSAnnotation:        "20000.5",
+					LatencyP50Annotation: "0.8",
+				},
+			},
+			Status: grpcv1.LoadTestStatus{
+				QPS:          &qps,
+				LatencyP99Ms: &latencyP99,
+			},
+		}
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+		reportResults(test, reporter.NewTestCaseReporter(test))
+		report.Finalize()
+
+		values := make(map[string]string)
+		for _, property := range decodeReport(report).Suites[0].Cases[0].Properties {
+			values[property.Name] = property.Value
+		}
+		Expect(values).To(HaveKeyWithValue(QPSAnnotation, "30000.25"))
+		Expect(values).To(HaveKeyWithValue(LatencyP50Annotation, "0.8"))
+		Expect(values).To(HaveKeyWithValue(LatencyP99Annotation, "2.5"))
+	})
+})
+
+var _ = Describe("Runner batching", func() {
+	run := func(getter *fake.LoadTestGetter, batchSize int, batchInterval time.Duration, testCount int) *junit.TestSuites {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetBatching(batchSize, batchInterval)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		var configs []*grpcv1.LoadTest
+		for i := 0; i < testCount; i++ {
+			configs = append(configs, &grpcv1.LoadTest{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("test-%d", i)},
+			})
+		}
+
+		done := make(chan string)
+		go r.Run(context.Background(), configs, reporter, testCount, done)
+		Eventually(done, 5*time.Second).Should(Receive(Equal("queue")))
+		report.Finalize()
+		return decodeReport(report)
+	}
+
+	It("pauses between batches of tests", func() {
+		batchInterval := 200 * time.Millisecond
+		getter := newFakeGetter(grpcv1.Succeeded)
+		run(getter, 2, batchInterval, 5)
+
+		times := actionTimes(getter, "create")
+		Expect(times).To(HaveLen(5))
+		sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+		Expect(times[1].Sub(times[0])).To(BeNumerically("<", batchInterval))
+		Expect(times[2].Sub(times[1])).To(BeNumerically(">=", batchInterval/2))
+		Expect(times[3].Sub(times[2])).To(BeNumerically("<", batchInterval))
+		Expect(times[4].Sub(times[3])).To(BeNumerically(">=", batchInterval/2))
+	})
+
+	It("submits every test in the queue", func() {
+		getter := newFakeGetter(grpcv1.Succeeded)
+		suites := run(getter, 3, time.Millisecond, 7)
+
+		Expect(actionNames(getter, "create")).To(HaveLen(7))
+		Expect(suites.Suites[0].Cases).To(HaveLen(7))
+	})
+
+	It("skips remaining tests when cancelled during a pause", func() {
+		getter := newFakeGetter(grpcv1.Succeeded)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetBatching(1, time.Hour)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan string)
+		go r.Run(ctx, []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+		}, reporter, 2, done)
+		Eventually(func() int { return countActions(getter, "create") }).Should(Equal(1))
+		cancel()
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+
+		cases := decodeReport(report).Suites[0].Cases
+		Expect(cases).To(HaveLen(2))
+		Expect(cases[1].Skipped).ToNot(BeNil())
+		Expect(countActions(getter, "create")).To(Equal(1))
+	})
+})
+
+var _ = Describe("Runner no-pods deadline", func() {
+	run := func(ctx context.Context, getter *fake.LoadTestGetter, noPodsDeadline time.Duration) *junit.TestSuites {
+		r := NewRunner(getter, func() { time.Sleep(5 * time.Millisecond) }, 0, nil, false)
+		r.SetNoPodsDeadline(noPodsDeadline)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(ctx, []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done, 5*time.Second).Should(Receive(Equal("queue")))
+		report.Finalize()
+		return decodeReport(report)
+	}
+
+	It("aborts and deletes a test that never gets pods", func() {
+		getter := newFakeGetter(grpcv1.Initializing)
+		suites := run(context.Background(), getter, 50*time.Millisecond)
+
+		failures := suites.Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Message).To(ContainSubstring("no pods were created within 50ms"))
+
+		Expect(actionNames(getter, "delete")).To(ConsistOf("test-0"))
+	})
+
+	It("aborts a test without a status", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		suites := run(context.Background(), getter, 50*time.Millisecond)
+
+		failures := suites.Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Message).To(ContainSubstring("no pods were created"))
+	})
+
+	It("does not abort a test that has pods", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetCreatedStatus(grpcv1.LoadTestStatus{State: grpcv1.Initializing, Pods: 1})
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		suites := run(ctx, getter, 50*time.Millisecond)
+
+		failures := suites.Suites[0].Cases[0].Failures
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Message).To(HavePrefix("Cancelled"))
+	})
+})
+
+// concurrencyGetter is a fake LoadTestGetter that records the largest number
+// of tests that were created and had not yet been seen to succeed.
+type concurrencyGetter struct {
+	*fake.LoadTestGetter
+
+	countMux  sync.Mutex
+	active    int
+	maxActive int
+}
+
+func (g *concurrencyGetter) Create(test *grpcv1.LoadTest, opts metav1.CreateOptions) (*grpcv1.LoadTest, error) {
+	g.countMux.Lock()
+	g.active++
+	if g.active > g.maxActive {
+		g.maxActive = g.active
+	}
+	g.countMux.Unlock()
+	return g.LoadTestGetter.Create(test, opts)
+}
+
+func (g *concurrencyGetter) Get(name string, opts metav1.GetOptions) (*grpcv1.LoadTest, error) {
+	time.Sleep(5 * time.Millisecond)
+	g.countMux.Lock()
+	g.active--
+	g.countMux.Unlock()
+	return g.LoadTestGetter.Get(name, opts)
+}
+
+// maxActiveCount returns the largest number of tests that ran at once.
+func (g *concurrencyGetter) maxActiveCount() int {
+	g.countMux.Lock()
+	defer g.countMux.Unlock()
+	return g.maxActive
+}
+
+var _ = Describe("Runner global concurrency", func() {
+	runQueues := func(r *Runner, queues []string, testsPerQueue int, concurrencyLevel int) {
+		done := make(chan string)
+		for _, qName := range queues {
+			var configs []*grpcv1.LoadTest
+			for i := 0; i < testsPerQueue; i++ {
+				configs = append(configs, &grpcv1.LoadTest{
+					ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", qName, i)},
+				})
+			}
+			report := junit.NewReport("report-id", "report")
+			reporter := NewTestSuiteReporter(qName, "[%s %d] ", report.NewTestSuite(qName, SuiteName(qName)))
+			go r.Run(context.Background(), configs, reporter, concurrencyLevel, done)
+		}
+		for range queues {
+			Eventually(done, 5*time.Second).Should(Receive())
+		}
+	}
+
+	It("limits the tests that run at once across all queues", func() {
+		getter := &concurrencyGetter{LoadTestGetter: newFakeGetter(grpcv1.Succeeded)}
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetGlobalConcurrency(2)
+
+		runQueues(r, []string{"a", "b", "c"}, 4, 2)
+
+		Expect(actionNames(getter.LoadTestGetter, "create")).To(HaveLen(12))
+		Expect(getter.maxActiveCount()).To(BeNumerically("<=", 2))
+	})
+
+	It("completes every queue when the limit is below the total concurrency", func() {
+		getter := &concurrencyGetter{LoadTestGetter: newFakeGetter(grpcv1.Succeeded)}
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetGlobalConcurrency(1)
+
+		runQueues(r, []string{"a", "b", "c", "d"}, 3, 3)
+
+		Expect(actionNames(getter.LoadTestGetter, "create")).To(HaveLen(12))
+		Expect(getter.maxActiveCount()).To(Equal(1))
+	})
+
+	It("is unlimited when unset", func() {
+		getter := &concurrencyGetter{LoadTestGetter: newFakeGetter(grpcv1.Succeeded)}
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetGlobalConcurrency(0)
+
+		runQueues(r, []string{"a", "b"}, 2, 2)
+
+		Expect(actionNames(getter.LoadTestGetter, "create")).To(HaveLen(4))
+	})
+
+	It("skips tests that are waiting for a slot when cancelled", func() {
+		getter := newFakeGetter(grpcv1.Running)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetGlobalConcurrency(1)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan string)
+		go r.Run(ctx, []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-1"}},
+		}, reporter, 2, done)
+		Eventually(func() int { return countActions(getter, "create") }).Should(Equal(1))
+		cancel()
+		Eventually(done).Should(Receive(Equal("queue")))
+		report.Finalize()
+
+		cases := decodeReport(report).Suites[0].Cases
+		Expect(cases).To(HaveLen(2))
+		Expect(cases[1].Skipped).ToNot(BeNil())
+		Expect(countActions(getter, "create")).To(Equal(1))
+	})
+})
+
+var _ = Describe("Runner fail fast", func() {
+	var getter *fake.LoadTestGetter
+
+	BeforeEach(func() {
+		getter = newFakeGetter(grpcv1.Succeeded)
+		getter.SetStatuses("a-0", grpcv1.LoadTestStatus{State: grpcv1.Errored})
+	})
+
+	run := func(r *Runner, qName string) []*junit.TestCase {
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter(qName, "[%s %d] ", report.NewTestSuite(qName, SuiteName(qName)))
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: qName + "-0"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: qName + "-1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: qName + "-2"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal(qName)))
+		report.Finalize()
+		return decodeReport(report).Suites[0].Cases
+	}
+
+	It("skips tests that were not started after a failure", func() {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetFailFast(true)
+
+		cases := run(r, "a")
+		Expect(cases).To(HaveLen(3))
+		Expect(cases[0].Failures).To(HaveLen(1))
+		Expect(cases[1].Skipped).ToNot(BeNil())
+		Expect(cases[2].Skipped).ToNot(BeNil())
+		Expect(actionNames(getter, "create")).To(Equal([]string{"a-0"}))
+	})
+
+	It("stops other queues of the runner after a failure", func() {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		r.SetFailFast(true)
+
+		run(r, "a")
+		for _, c := range run(r, "b") {
+			Expect(c.Skipped).ToNot(BeNil())
+		}
+		Expect(actionNames(getter, "create")).To(Equal([]string{"a-0"}))
+	})
+
+	It("runs every test when it is not set", func() {
+		r := NewRunner(getter, func() {}, 0, nil, false)
+
+		cases := run(r, "a")
+		Expect(cases[0].Failures).To(HaveLen(1))
+		Expect(cases[1].Skipped).To(BeNil())
+		Expect(cases[2].Skipped).To(BeNil())
+		Expect(actionNames(getter, "create")).To(HaveLen(3))
+	})
+})
+
+// stuckDeleteGetter is a fake LoadTestGetter whose Delete blocks until release
+// is closed.
+type stuckDeleteGetter struct {
+	*fake.LoadTestGetter
+	release chan struct{}
+}
+
+func (g *stuckDeleteGetter) Delete(name string, opts metav1.DeleteOptions) error {
+	<-g.release
+	return g.LoadTestGetter.Delete(name, opts)
+}
+
+var _ = Describe("WaitForQueues", func() {
+	start := func(r *Runner, ctx context.Context, qName string, done chan string) {
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter(qName, "[%s %d] ", report.NewTestSuite(qName, SuiteName(qName)))
+		go r.Run(ctx, []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: qName + "-0"}},
+		}, reporter, 1, done)
+	}
+
+	It("returns once every queue is done", func() {
+		getter := newFakeGetter(grpcv1.Succeeded)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		done := make(chan string)
+		start(r, context.Background(), "a", done)
+		start(r, context.Background(), "b", done)
+
+		Expect(WaitForQueues(context.Background(), done, []string{"a", "b"}, time.Second)).To(BeEmpty())
+		Expect(r.ActiveTests()).To(BeEmpty())
+	})
+
+	It("returns after the drain completes within the timeout", func() {
+		getter := newFakeGetter(grpcv1.Running)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan string)
+		start(r, ctx, "a", done)
+		Eventually(r.ActiveTests).Should(Equal([]string{"a-0"}))
+
+		cancel()
+		Expect(WaitForQueues(ctx, done, []string{"a"}, 5*time.Second)).To(BeEmpty())
+		Expect(actionNames(getter, "delete")).To(Equal([]string{"a-0"}))
+		Expect(r.ActiveTests()).To(BeEmpty())
+	})
+
+	It("gives up on queues with a stuck delete after the timeout", func() {
+		getter := &stuckDeleteGetter{
+			LoadTestGetter: newFakeGetter(grpcv1.Running),
+			release:        make(chan struct{}),
+		}
+		defer close(getter.release)
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan string, 2)
+		start(r, ctx, "a", done)
+		Eventually(r.ActiveTests).Should(Equal([]string{"a-0"}))
+
+		cancel()
+		began := time.Now()
+		Expect(WaitForQueues(ctx, done, []string{"a"}, 50*time.Millisecond)).To(Equal([]string{"a"}))
+		Expect(time.Since(began)).To(BeNumerically("<", time.Second))
+		Expect(r.ActiveTests()).To(Equal([]string{"a-0"}))
+	})
+})
+
+var _ = Describe("clientTimedOut", func() {
+	var config *grpcv1.LoadTest
+
+	BeforeEach(func() {
+		config = &grpcv1.LoadTest{
+			Spec:   grpcv1.LoadTestSpec{TimeoutSeconds: 600},
+			Status: grpcv1.LoadTestStatus{State: grpcv1.Running},
+		}
+	})
+
+	It("returns false for a test within its timeout and margin", func() {
+		submitted := time.Now().Add(-600*time.Second - clientTimeoutMargin/2)
+		Expect(clientTimedOut(config, submitted)).To(BeFalse())
+	})
+
+	It("returns true for a test past its timeout and margin", func() {
+		submitted := time.Now().Add(-600*time.Second - 2*clientTimeoutMargin)
+		Expect(clientTimedOut(config, submitted)).To(BeTrue())
+	})
+
+	It("applies the timeout from the parameter values of an expanded test", func() {
+		config.Spec.ParameterMatrix = map[string][]string{"messageSize": {"64", "1024"}}
+		config.Spec.ParameterTimeouts = []grpcv1.ParameterTimeout{
+			{Parameter: "messageSize", Value: "1024", TimeoutSeconds: 3600},
+		}
+		expanded, err := ExpandParameterMatrices([]*grpcv1.LoadTest{config}, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expanded).To(HaveLen(2))
+
+		submitted := time.Now().Add(-time.Hour / 2)
+		Expect(clientTimedOut(expanded[0], submitted)).To(BeTrue())
+		Expect(clientTimedOut(expanded[1], submitted)).To(BeFalse())
+	})
+
+	It("returns false for a terminated test", func() {
+		config.Status.State = grpcv1.Succeeded
+		Expect(clientTimedOut(config, time.Now().Add(-24*time.Hour))).To(BeFalse())
+	})
+
+	It("returns false for a test without a timeout", func() {
+		config.Spec.TimeoutSeconds = 0
+		Expect(clientTimedOut(config, time.Now().Add(-24*time.Hour))).To(BeFalse())
+	})
+})
+
+var _ = Describe("statusString", func() {
+	It("joins the state, reason and message", func() {
+		test := &grpcv1.LoadTest{Status: grpcv1.LoadTestStatus{
+			State:   grpcv1.Errored,
+			Reason:  grpcv1.ContainerError,
+			Message: "container \"run\" terminated with exit code 1",
+		}}
+		Expect(statusString(test)).To(Equal(`Errored; ContainerError; container "run" terminated with exit code 1`))
+	})
+
+	It("describes why pods have not started", func() {
+		test := &grpcv1.LoadTest{Status: grpcv1.LoadTestStatus{
+			State:   grpcv1.Initializing,
+			Reason:  grpcv1.AwaitingSchedule,
+			Message: "pod \"client\" is waiting to be scheduled",
+		}}
+		Expect(statusString(test)).To(Equal(`Initializing; AwaitingSchedule (waiting for nodes in the pool); pod "client" is waiting to be scheduled`))
+
+		test.Status.Reason = grpcv1.PullingImage
+		test.Status.Message = ""
+		Expect(statusString(test)).To(Equal("Initializing; PullingImage (waiting for images to be pulled)"))
+	})
+})
+
+var _ = Describe("stateDurations", func() {
+	It("returns the time spent in each state before the last", func() {
+		entered := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+		conditions := []grpcv1.LoadTestCondition{
+			{State: grpcv1.Initializing, LastTransitionTime: metav1.NewTime(entered)},
+			{State: grpcv1.Running, LastTransitionTime: metav1.NewTime(entered.Add(12 * time.Second))},
+			{State: grpcv1.Succeeded, LastTransitionTime: metav1.NewTime(entered.Add(3*time.Minute + 12*time.Second))},
+		}
+		Expect(stateDurations(conditions)).To(Equal("Initializing 12s, Running 3m0s"))
+	})
+
+	It("returns an empty string without a transition", func() {
+		Expect(stateDurations(nil)).To(BeEmpty())
+		Expect(stateDurations([]grpcv1.LoadTestCondition{{State: grpcv1.Running}})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"sort"
+	"time"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+// InvocationResult is the outcome of a test invocation that finished.
+type InvocationResult string
+
+const (
+	// InvocationPassed is the result of a test that finished without
+	// failures.
+	InvocationPassed InvocationResult = "Passed"
+
+	// InvocationFailed is the result of a test that finished with a failure,
+	// including tests that errored, timed out or were cancelled.
+	InvocationFailed InvocationResult = "Failed"
+
+	// InvocationSkipped is the result of a test that was not run.
+	InvocationSkipped InvocationResult = "Skipped"
+)
+
+// InvocationStatus describes the progress of a test invocation.
+type InvocationStatus struct {
+	TestInvocation
+
+	// State is the state of the LoadTest in its last poll. It is empty if
+	// the LoadTest has not been created.
+	State grpcv1.LoadTestState
+
+	// StartTime is when the runner started the test. It is zero if the test
+	// has not started.
+	StartTime time.Time
+
+	// Retries is the number of create and poll operations that were retried
+	// for the test.
+	Retries uint
+
+	// Finished is set once the runner is done with the test.
+	Finished bool
+
+	// FinishTime is when the runner was done with the test. It is zero if
+	// the test has not finished.
+	FinishTime time.Time
+
+	// Result is the outcome of the test. It is empty until the test has
+	// finished.
+	Result InvocationResult
+}
+
+// Snapshot returns the status of every test invocation that the runner knows
+// of, sorted by queue and index. It is safe to call while Run is executing
+// in any number of queues.
+func (r *Runner) Snapshot() []InvocationStatus {
+	r.invocationsMux.Lock()
+	defer r.invocationsMux.Unlock()
+	statuses := make([]InvocationStatus, 0, len(r.invocations))
+	for _, status := range r.invocations {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Queue != statuses[j].Queue {
+			return statuses[i].Queue < statuses[j].Queue
+		}
+		return statuses[i].Index < statuses[j].Index
+	})
+	return statuses
+}
+
+// updateInvocation applies a change to the status of a test invocation,
+// adding the invocation if it is not known.
+func (r *Runner) updateInvocation(reporter *TestCaseReporter, update func(status *InvocationStatus)) {
+	invocation := reporter.Invocation()
+	r.invocationsMux.Lock()
+	defer r.invocationsMux.Unlock()
+	if r.invocations == nil {
+		r.invocations = make(map[TestInvocation]*InvocationStatus)
+	}
+	status, ok := r.invocations[invocation]
+	if !ok {
+		status = &InvocationStatus{TestInvocation: invocation}
+		r.invocations[invocation] = status
+	}
+	update(status)
+}
+
+// recordStarted records that a test started.
+func (r *Runner) recordStarted(reporter *TestCaseReporter, startTime time.Time) {
+	r.updateInvocation(reporter, func(status *InvocationStatus) {
+		status.StartTime = startTime
+	})
+}
+
+// recordState records the state of a test after it was created or polled.
+func (r *Runner) recordState(reporter *TestCaseReporter, state grpcv1.LoadTestState) {
+	r.updateInvocation(reporter, func(status *InvocationStatus) {
+		status.State = state
+	})
+}
+
+// recordRetry records that an operation of a test was retried.
+func (r *Runner) recordRetry(reporter *TestCaseReporter) {
+	r.updateInvocation(reporter, func(status *InvocationStatus) {
+		status.Retries++
+	})
+}
+
+// recordFinished records the result of a test that finished.
+func (r *Runner) recordFinished(reporter *TestCaseReporter) {
+	result := InvocationPassed
+	switch {
+	case reporter.Failed():
+		result = InvocationFailed
+	case reporter.Skipped():
+		result = InvocationSkipped
+	}
+	r.updateInvocation(reporter, func(status *InvocationStatus) {
+		status.Finished = true
+		status.FinishTime = time.Now()
+		status.Result = result
+	})
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("Runner snapshot", func() {
+	runQueues := func(r *Runner, queues []string, tests int) {
+		report := junit.NewReport("report-id", "report")
+		done := make(chan string)
+		for _, qName := range queues {
+			reporter := NewTestSuiteReporter(qName, "[%s %d] ", report.NewTestSuite(qName, SuiteName(qName)))
+			var configs []*grpcv1.LoadTest
+			for i := 0; i < tests; i++ {
+				configs = append(configs, &grpcv1.LoadTest{
+					ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%d", qName, i)},
+				})
+			}
+			go r.Run(context.Background(), configs, reporter, 1, done)
+		}
+
+		// Take snapshots while the queues run, to check for races.
+		for finished := 0; finished < len(queues); {
+			select {
+			case <-done:
+				finished++
+			default:
+				r.Snapshot()
+			}
+		}
+	}
+
+	It("is empty before the runner starts", func() {
+		r := NewRunner(fake.NewLoadTestGetter(corev1.NamespaceDefault), func() {}, 0, nil, false)
+		Expect(r.Snapshot()).To(BeEmpty())
+	})
+
+	It("includes the result of each test in every queue", func() {
+		r := NewRunner(newFakeGetter(grpcv1.Succeeded), func() {}, 0, nil, false)
+		runQueues(r, []string{"b", "a"}, 2)
+
+		statuses := r.Snapshot()
+		Expect(statuses).To(HaveLen(4))
+		var invocations []TestInvocation
+		for _, status := range statuses {
+			invocations = append(invocations, status.TestInvocation)
+			Expect(status.State).To(Equal(grpcv1.Succeeded))
+			Expect(status.StartTime.IsZero()).To(BeFalse())
+			Expect(status.Finished).To(BeTrue())
+			Expect(status.Result).To(Equal(InvocationPassed))
+		}
+		Expect(invocations).To(Equal([]TestInvocation{
+			{Queue: "a", Index: 0, Name: "a-0", Drivers: 1},
+			{Queue: "a", Index: 1, Name: "a-1", Drivers: 1},
+			{Queue: "b", Index: 0, Name: "b-0", Drivers: 1},
+			{Queue: "b", Index: 1, Name: "b-1", Drivers: 1},
+		}))
+	})
+
+	It("reports tests that failed", func() {
+		r := NewRunner(newFakeGetter(grpcv1.Errored), func() {}, 0, nil, false)
+		runQueues(r, []string{"queue"}, 1)
+
+		statuses := r.Snapshot()
+		Expect(statuses).To(HaveLen(1))
+		Expect(statuses[0].State).To(Equal(grpcv1.Errored))
+		Expect(statuses[0].Result).To(Equal(InvocationFailed))
+	})
+
+	It("counts retries of failed operations", func() {
+		getter := newFakeGetter(grpcv1.Succeeded)
+		getter.SetError("create", fmt.Errorf("unavailable"))
+		r := NewRunner(getter, func() {}, 2, func(uint) time.Duration { return 0 }, false)
+		runQueues(r, []string{"queue"}, 1)
+
+		statuses := r.Snapshot()
+		Expect(statuses).To(HaveLen(1))
+		Expect(statuses[0].Retries).To(BeEquivalentTo(2))
+		Expect(statuses[0].State).To(BeEmpty())
+		Expect(statuses[0].Result).To(Equal(InvocationFailed))
+	})
+})
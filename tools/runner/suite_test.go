@@ -0,0 +1,40 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+func TestRunner(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Runner Suite")
+}
+
+// decodeReport writes a report and decodes it, so tests can inspect the
+// results that would appear in the XML output.
+func decodeReport(report *junit.Report) *junit.TestSuites {
+	buf := &bytes.Buffer{}
+	ExpectWithOffset(1, report.WriteToStream(buf, 0)).To(Succeed())
+	decoded := new(junit.TestSuites)
+	ExpectWithOffset(1, xml.Unmarshal(buf.Bytes(), decoded)).To(Succeed())
+	return decoded
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+// Summary tallies the outcomes of all tests in a run, across all queues. It is
+// written as JSON, so CI systems can check the outcome of a run without
+// parsing the JUnit report.
+type Summary struct {
+	// Total is the number of tests in the run.
+	Total int `json:"total"`
+
+	// Passed is the number of tests that ran without failures.
+	Passed int `json:"passed"`
+
+	// Failed is the number of tests that were created and failed.
+	Failed int `json:"failed"`
+
+	// NotCreated is the number of tests that failed before they were
+	// created, such as tests whose creation was retried until the runner
+	// gave up. These tests never ran.
+	NotCreated int `json:"notCreated"`
+
+	// Skipped is the number of tests that were skipped without failures.
+	Skipped int `json:"skipped"`
+
+	// DurationSeconds is the wall-clock duration of the whole run.
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// NewSummary tallies the outcomes of the test cases in a report. The report
+// may still be in use by other goroutines. The duration is the one recorded
+// with the SetDuration method of the report.
+func NewSummary(report *junit.Report) Summary {
+	summary := Summary{
+		DurationSeconds: report.Duration().Seconds(),
+	}
+	report.ForEachTestCase(func(testCase *junit.TestCase) {
+		summary.Total++
+		switch {
+		case len(testCase.Failures) > 0 && hasProperty(testCase, NotCreatedProperty):
+			summary.NotCreated++
+		case len(testCase.Failures) > 0:
+			summary.Failed++
+		case testCase.Skipped != nil:
+			summary.Skipped++
+		default:
+			summary.Passed++
+		}
+	})
+	return summary
+}
+
+// WriteJSON writes the summary as indented JSON to a stream.
+func (s Summary) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// hasProperty returns true if a test case has a property with a name.
+func hasProperty(testCase *junit.TestCase, name string) bool {
+	for _, property := range testCase.Properties {
+		if property.Name == name {
+			return true
+		}
+	}
+	return false
+}
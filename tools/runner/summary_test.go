@@ -0,0 +1,84 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+	"github.com/grpc/test-infra/clientset/fake"
+	"github.com/grpc/test-infra/tools/runner/junit"
+)
+
+var _ = Describe("Summary", func() {
+	It("tallies the outcomes of test cases across test suites", func() {
+		report := junit.NewReport("report-id", "report")
+		suiteA := report.NewTestSuite("queue-a", "queue-a")
+		suiteA.NewTestCase("test-0", "test-0")
+		suiteA.NewTestCase("test-1", "test-1").AddFailure(junit.Error, "failed", "")
+		suiteB := report.NewTestSuite("queue-b", "queue-b")
+		suiteB.NewTestCase("test-2", "test-2").SetSkipped("dry run")
+		notCreated := suiteB.NewTestCase("test-3", "test-3")
+		notCreated.AddProperty(NotCreatedProperty, "true")
+		notCreated.AddFailure(junit.Error, "aborting", "")
+		report.SetDuration(90 * time.Second)
+
+		Expect(NewSummary(report)).To(Equal(Summary{
+			Total:           4,
+			Passed:          1,
+			Failed:          1,
+			NotCreated:      1,
+			Skipped:         1,
+			DurationSeconds: 90,
+		}))
+	})
+
+	It("counts tests that could not be created apart from failed tests", func() {
+		getter := fake.NewLoadTestGetter(corev1.NamespaceDefault)
+		getter.SetError("create", errors.New("connection refused"))
+		r := NewRunner(getter, func() {}, 0, nil, false)
+		report := junit.NewReport("report-id", "report")
+		reporter := NewTestSuiteReporter("queue", "[%s %d] ", report.NewTestSuite("queue", SuiteName("queue")))
+
+		done := make(chan string)
+		go r.Run(context.Background(), []*grpcv1.LoadTest{
+			{ObjectMeta: metav1.ObjectMeta{Name: "test-0"}},
+		}, reporter, 1, done)
+		Eventually(done).Should(Receive(Equal("queue")))
+
+		summary := NewSummary(report)
+		Expect(summary.NotCreated).To(Equal(1))
+		Expect(summary.Failed).To(Equal(0))
+	})
+
+	It("writes the summary as JSON", func() {
+		var buf bytes.Buffer
+		Expect(Summary{Total: 2, Passed: 1, Failed: 1}.WriteJSON(&buf)).To(Succeed())
+
+		var decoded map[string]interface{}
+		Expect(json.Unmarshal(buf.Bytes(), &decoded)).To(Succeed())
+		Expect(decoded).To(HaveKeyWithValue("total", BeEquivalentTo(2)))
+		Expect(decoded).To(HaveKeyWithValue("notCreated", BeEquivalentTo(0)))
+	})
+})
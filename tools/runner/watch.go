@@ -0,0 +1,132 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// clearScreen moves the cursor of a terminal to its top left corner and
+// clears the screen, so a table can be redrawn in place.
+const clearScreen = "\x1b[H\x1b[2J"
+
+// Watcher prints the progress of the tests of a runner while it runs. The
+// progress is read from snapshots of the runner, so watching shares the data
+// of its polls and does not contact the cluster.
+//
+// On a terminal, a table with every test is redrawn on each update. Otherwise,
+// a line is printed for each test whose state or result changed since the
+// previous update.
+type Watcher struct {
+	snapshot func() []InvocationStatus
+	out      io.Writer
+	redraw   bool
+	last     map[TestInvocation]InvocationStatus
+}
+
+// NewWatcher creates a watcher that prints the snapshots returned by the
+// snapshot function, such as Runner.Snapshot, to out. When redraw is true,
+// the table is redrawn in place, which requires out to be a terminal.
+func NewWatcher(snapshot func() []InvocationStatus, out io.Writer, redraw bool) *Watcher {
+	return &Watcher{
+		snapshot: snapshot,
+		out:      out,
+		redraw:   redraw,
+		last:     make(map[TestInvocation]InvocationStatus),
+	}
+}
+
+// IsTerminal returns true if a file is a terminal, rather than a pipe or a
+// regular file.
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Watch prints an update each interval until the context is cancelled. A
+// final update is printed before it returns, so the last state of every test
+// is shown.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.Update(time.Now())
+			return
+		case <-ticker.C:
+			w.Update(time.Now())
+		}
+	}
+}
+
+// Update prints the progress of the tests at a point in time.
+func (w *Watcher) Update(now time.Time) {
+	statuses := w.snapshot()
+	if w.redraw {
+		fmt.Fprint(w.out, clearScreen)
+		writeWatchTable(w.out, statuses, now)
+		return
+	}
+
+	for _, status := range statuses {
+		last, ok := w.last[status.TestInvocation]
+		if ok && last.State == status.State && last.Finished == status.Finished {
+			continue
+		}
+		w.last[status.TestInvocation] = status
+		fmt.Fprintf(w.out, "%s/%d %s: %s (%s)\n", status.Queue, status.Index, status.Name, watchState(status), watchElapsed(status, now))
+	}
+}
+
+// writeWatchTable writes a table with the state, elapsed time, retries and
+// result of each test.
+func writeWatchTable(out io.Writer, statuses []InvocationStatus, now time.Time) {
+	tw := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "QUEUE\tINDEX\tNAME\tSTATE\tELAPSED\tRETRIES\tRESULT")
+	for _, status := range statuses {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%d\t%s\n", status.Queue, status.Index, status.Name, watchState(status), watchElapsed(status, now), status.Retries, status.Result)
+	}
+	tw.Flush()
+}
+
+// watchState returns the state of a test for display. Tests that have not
+// been created yet are shown as Pending.
+func watchState(status InvocationStatus) string {
+	if status.State == "" {
+		return "Pending"
+	}
+	return string(status.State)
+}
+
+// watchElapsed returns the time since a test started, or the time it took if
+// it finished, rounded to seconds. Tests that have not started show "-".
+func watchElapsed(status InvocationStatus, now time.Time) string {
+	if status.StartTime.IsZero() {
+		return "-"
+	}
+	end := now
+	if status.Finished {
+		end = status.FinishTime
+	}
+	return end.Sub(status.StartTime).Round(time.Second).String()
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 gRPC authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	grpcv1 "github.com/grpc/test-infra/api/v1"
+)
+
+var _ = Describe("Watcher", func() {
+	var now time.Time
+	var statuses []InvocationStatus
+	var out *bytes.Buffer
+
+	snapshot := func() []InvocationStatus {
+		return statuses
+	}
+
+	BeforeEach(func() {
+		now = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+		statuses = []InvocationStatus{
+			{
+				TestInvocation: TestInvocation{Queue: "queue", Index: 0, Name: "test-0"},
+				State:          grpcv1.Running,
+				StartTime:      now.Add(-90 * time.Second),
+			},
+			{
+				TestInvocation: TestInvocation{Queue: "queue", Index: 1, Name: "test-1"},
+			},
+		}
+		out = new(bytes.Buffer)
+	})
+
+	It("redraws a table with every test", func() {
+		w := NewWatcher(snapshot, out, true)
+		w.Update(now)
+		w.Update(now)
+
+		screens := strings.Split(out.String(), clearScreen)
+		Expect(screens).To(HaveLen(3))
+		lines := strings.Split(strings.TrimSpace(screens[2]), "\n")
+		Expect(lines).To(HaveLen(3))
+		Expect(strings.Fields(lines[0])).To(Equal([]string{"QUEUE", "INDEX", "NAME", "STATE", "ELAPSED", "RETRIES", "RESULT"}))
+		Expect(strings.Fields(lines[1])).To(Equal([]string{"queue", "0", "test-0", "Running", "1m30s", "0"}))
+		Expect(strings.Fields(lines[2])).To(Equal([]string{"queue", "1", "test-1", "Pending", "-", "0"}))
+	})
+
+	It("prints a line for each test that changed without redrawing", func() {
+		w := NewWatcher(snapshot, out, false)
+		w.Update(now)
+		Expect(out.String()).To(Equal("queue/0 test-0: Running (1m30s)\nqueue/1 test-1: Pending (-)\n"))
+
+		out.Reset()
+		w.Update(now.Add(time.Minute))
+		Expect(out.String()).To(BeEmpty())
+
+		statuses[0].State = grpcv1.Succeeded
+		statuses[0].Finished = true
+		statuses[0].FinishTime = now
+		statuses[0].Result = InvocationPassed
+		w.Update(now.Add(time.Minute))
+		Expect(out.String()).To(Equal("queue/0 test-0: Succeeded (1m30s)\n"))
+		Expect(out.String()).ToNot(ContainSubstring(clearScreen))
+	})
+
+	It("prints a final update when the context is cancelled", func() {
+		w := NewWatcher(snapshot, out, false)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		w.Watch(ctx, time.Hour)
+		Expect(out.String()).To(ContainSubstring("test-0: Running"))
+	})
+})